@@ -26,8 +26,30 @@ host = "localhost"
 # The port number for the server
 port = 3596
 
+# The host interface and port of the HTTP/JSON API, a port of 0 disables it
+http_host = "localhost"
+http_port = 0
+
+# The host interface and port of the Redis protocol (RESP) front end, a port
+# of 0 disables it
+resp_host = "localhost"
+resp_port = 0
+
+# Properties of the sketches created by RESP commands referencing missing
+# ones: the maxUniqueItems of MEMB and FREQ sketches and the size of RANK
+# sketches
+resp_max_unique_items = 1000000
+resp_rank_size = 100
+
 # Treshold for saving a sketch to disk
 save_threshold_seconds = 1
+
+# When to sync the append-only file to disk: "always" before acknowledging
+# each operation, "everysec" once per second or "no" to leave it to the OS
+fsync = "everysec"
+
+# Take a snapshot when shutting down, making the next startup faster
+snapshot_on_shutdown = false
 `
 
 var logger = loggo.GetLogger("config")
@@ -38,7 +60,15 @@ type Config struct {
 	DataDir              string `toml:"data_dir"`
 	Host                 string `toml:"host"`
 	Port                 int    `toml:"port"`
+	HTTPHost             string `toml:"http_host"`
+	HTTPPort             int    `toml:"http_port"`
+	RESPHost             string `toml:"resp_host"`
+	RESPPort             int    `toml:"resp_port"`
+	RESPMaxUniqueItems   int64  `toml:"resp_max_unique_items"`
+	RESPRankSize         int64  `toml:"resp_rank_size"`
 	SaveThresholdSeconds uint   `toml:"save_threshold_seconds"`
+	Fsync                string `toml:"fsync"`
+	SnapshotOnShutdown   bool   `toml:"snapshot_on_shutdown"`
 }
 
 var config *Config
@@ -50,8 +80,24 @@ var DataDir              string
 var Host                 string
 // Port initialized from config file
 var Port                 int
+// HTTPHost initialized from config file
+var HTTPHost             string
+// HTTPPort initialized from config file
+var HTTPPort             int
+// RESPHost initialized from config file
+var RESPHost             string
+// RESPPort initialized from config file
+var RESPPort             int
+// RESPMaxUniqueItems initialized from config file
+var RESPMaxUniqueItems   int64
+// RESPRankSize initialized from config file
+var RESPRankSize         int64
 // SaveThresholdSeconds initialized from config file
 var SaveThresholdSeconds uint
+// Fsync initialized from config file
+var Fsync                string
+// SnapshotOnShutdown initialized from config file
+var SnapshotOnShutdown   bool
 
 // MaxKeySize for BoltDB keys in bytes
 const MaxKeySize int = 32768
@@ -92,7 +138,15 @@ func GetConfig() *Config {
 		DataDir = config.DataDir
 		Host = config.Host
 		Port = config.Port
+		HTTPHost = config.HTTPHost
+		HTTPPort = config.HTTPPort
+		RESPHost = config.RESPHost
+		RESPPort = config.RESPPort
+		RESPMaxUniqueItems = config.RESPMaxUniqueItems
+		RESPRankSize = config.RESPRankSize
 		SaveThresholdSeconds = config.SaveThresholdSeconds
+		Fsync = config.Fsync
+		SnapshotOnShutdown = config.SnapshotOnShutdown
 
 		if err := os.MkdirAll(InfoDir, os.ModePerm); err != nil {
 			panic(err)
@@ -7,13 +7,17 @@ HLLPP	=> HyperLogLogPlusPlus
 CML		=> Count-min-log sketch
 TopK	=> Top-K
 Bloom 	=> Bloom Filter
+TDigest	=> t-digest
+MinHash	=> Bottom-k MinHash
 */
 const (
-	DOM   = "dom"
-	HLLPP = "card"
-	CML   = "freq"
-	TopK  = "rank"
-	Bloom = "memb"
+	DOM     = "dom"
+	HLLPP   = "card"
+	CML     = "freq"
+	TopK    = "rank"
+	Bloom   = "memb"
+	TDigest = "quan"
+	MinHash = "simi"
 )
 
 /*
@@ -21,15 +25,20 @@ const (
   FREQ = 2;
   RANK = 3;
   CARD = 4;
+  QUAN = 5;
+  SIMI = 6;
 */
 var typeMap = map[pb.SketchType]string{
 	pb.SketchType_MEMB: Bloom,
 	pb.SketchType_FREQ: CML,
 	pb.SketchType_RANK: TopK,
 	pb.SketchType_CARD: HLLPP,
+	pb.SketchType_QUAN: TDigest,
+	pb.SketchType_SIMI: MinHash,
 }
 
-// GetTypes ...
+// GetTypes returns the sketch types taking any value and answering queries on
+// their own, QUAN sketches only take numbers and SIMI sketches are compared
 func GetTypes() []string {
 	return []string{HLLPP, CML, TopK, Bloom}
 }
@@ -39,7 +48,8 @@ func GetTypeString(typ pb.SketchType) string {
 	return typeMap[typ]
 }
 
-// GetTypesPb ...
+// GetTypesPb returns the sketch types taking any value as above, they make up
+// the default sketches of a domain
 func GetTypesPb() []pb.SketchType {
 	return []pb.SketchType{
 		pb.SketchType_MEMB,
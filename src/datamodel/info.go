@@ -45,10 +45,19 @@ func (info *Info) Copy() *Info {
 				ErrorRate:      utils.Float32p(info.Properties.GetErrorRate()),
 				MaxUniqueItems: utils.Int64p(info.Properties.GetMaxUniqueItems()),
 				Size:           utils.Int64p(info.Properties.GetSize()),
+				BucketDuration: utils.Int64p(info.Properties.GetBucketDuration()),
+				BucketCount:    utils.Int64p(info.Properties.GetBucketCount()),
+				ThresholdSize:  utils.Int64p(info.Properties.GetThresholdSize()),
+				Compression:    utils.Int64p(info.Properties.GetCompression()),
+				Deletable:      utils.Boolp(info.Properties.GetDeletable()),
 			},
 			State: &pb.SketchState{
 				FillRate:     utils.Float32p(info.State.GetFillRate()),
 				LastSnapshot: utils.Int64p(info.State.GetLastSnapshot()),
+				Exact:        utils.Boolp(info.State.GetExact()),
+				ValuesAdded:  utils.Int64p(info.State.GetValuesAdded()),
+				Memory:       utils.Int64p(info.State.GetMemory()),
+				Created:      utils.Int64p(info.State.GetCreated()),
 			},
 			Name: utils.Stringp(info.GetName()),
 			Type: &typ,
@@ -17,24 +17,46 @@ It has these top-level messages:
 	Membership
 	Frequency
 	Rank
+	Quantile
+	CDFPoint
 	CreateSnapshotRequest
 	CreateSnapshotReply
 	GetSnapshotRequest
 	GetSnapshotReply
+	SnapshotMarker
 	ListRequest
 	ListReply
 	ListDomainsReply
+	MergeRequest
+	WeightedValue
 	AddRequest
+	Insertion
+	AddResult
 	AddReply
+	AddStreamError
+	AddStreamReply
+	RemoveRequest
+	Removal
+	RemoveReply
 	GetRequest
 	MembershipResult
 	FrequencyResult
 	CardinalityResult
 	RankingsResult
+	QuantilesResult
 	GetMembershipReply
 	GetFrequencyReply
 	GetCardinalityReply
 	GetRankingsReply
+	GetQuantilesReply
+	CardinalityEstimate
+	GetSetCardinalityReply
+	GetSimilarityReply
+	QueryResult
+	DomainSketchRequest
+	QueryDomainRequest
+	QueryDomainReply
+	QueryReply
 */
 package protobuf
 
@@ -66,6 +88,8 @@ const (
 	SketchType_FREQ SketchType = 2
 	SketchType_RANK SketchType = 3
 	SketchType_CARD SketchType = 4
+	SketchType_QUAN SketchType = 5
+	SketchType_SIMI SketchType = 6
 )
 
 var SketchType_name = map[int32]string{
@@ -73,12 +97,16 @@ var SketchType_name = map[int32]string{
 	2: "FREQ",
 	3: "RANK",
 	4: "CARD",
+	5: "QUAN",
+	6: "SIMI",
 }
 var SketchType_value = map[string]int32{
 	"MEMB": 1,
 	"FREQ": 2,
 	"RANK": 3,
 	"CARD": 4,
+	"QUAN": 5,
+	"SIMI": 6,
 }
 
 func (x SketchType) Enum() *SketchType {
@@ -152,10 +180,17 @@ func (*Empty) ProtoMessage()               {}
 func (*Empty) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{0} }
 
 type SketchProperties struct {
-	MaxUniqueItems   *int64   `protobuf:"varint,1,opt,name=maxUniqueItems" json:"maxUniqueItems,omitempty"`
-	ErrorRate        *float32 `protobuf:"fixed32,2,opt,name=errorRate" json:"errorRate,omitempty"`
-	Size             *int64   `protobuf:"varint,3,opt,name=size" json:"size,omitempty"`
-	XXX_unrecognized []byte   `json:"-"`
+	MaxUniqueItems *int64   `protobuf:"varint,1,opt,name=maxUniqueItems" json:"maxUniqueItems,omitempty"`
+	ErrorRate      *float32 `protobuf:"fixed32,2,opt,name=errorRate" json:"errorRate,omitempty"`
+	Size           *int64   `protobuf:"varint,3,opt,name=size" json:"size,omitempty"`
+	BucketDuration *int64   `protobuf:"varint,4,opt,name=bucketDuration" json:"bucketDuration,omitempty"`
+	BucketCount    *int64   `protobuf:"varint,5,opt,name=bucketCount" json:"bucketCount,omitempty"`
+	ThresholdSize  *int64   `protobuf:"varint,6,opt,name=thresholdSize" json:"thresholdSize,omitempty"`
+	// sketch (0 for maxUniqueItems/10, -1 to disable, the size used is returned)
+	Compression *int64 `protobuf:"varint,7,opt,name=compression" json:"compression,omitempty"`
+	// accurate (0 for the default, the compression used is returned)
+	Deletable        *bool  `protobuf:"varint,8,opt,name=deletable" json:"deletable,omitempty"`
+	XXX_unrecognized []byte `json:"-"`
 }
 
 func (m *SketchProperties) Reset()                    { *m = SketchProperties{} }
@@ -184,9 +219,48 @@ func (m *SketchProperties) GetSize() int64 {
 	return 0
 }
 
+func (m *SketchProperties) GetBucketDuration() int64 {
+	if m != nil && m.BucketDuration != nil {
+		return *m.BucketDuration
+	}
+	return 0
+}
+
+func (m *SketchProperties) GetBucketCount() int64 {
+	if m != nil && m.BucketCount != nil {
+		return *m.BucketCount
+	}
+	return 0
+}
+
+func (m *SketchProperties) GetThresholdSize() int64 {
+	if m != nil && m.ThresholdSize != nil {
+		return *m.ThresholdSize
+	}
+	return 0
+}
+
+func (m *SketchProperties) GetCompression() int64 {
+	if m != nil && m.Compression != nil {
+		return *m.Compression
+	}
+	return 0
+}
+
+func (m *SketchProperties) GetDeletable() bool {
+	if m != nil && m.Deletable != nil {
+		return *m.Deletable
+	}
+	return false
+}
+
 type SketchState struct {
 	FillRate         *float32 `protobuf:"fixed32,1,opt,name=fillRate" json:"fillRate,omitempty"`
 	LastSnapshot     *int64   `protobuf:"varint,2,opt,name=lastSnapshot" json:"lastSnapshot,omitempty"`
+	Exact            *bool    `protobuf:"varint,3,opt,name=exact" json:"exact,omitempty"`
+	ValuesAdded      *int64   `protobuf:"varint,4,opt,name=valuesAdded" json:"valuesAdded,omitempty"`
+	Memory           *int64   `protobuf:"varint,5,opt,name=memory" json:"memory,omitempty"`
+	Created          *int64   `protobuf:"varint,6,opt,name=created" json:"created,omitempty"`
 	XXX_unrecognized []byte   `json:"-"`
 }
 
@@ -209,7 +283,35 @@ func (m *SketchState) GetLastSnapshot() int64 {
 	return 0
 }
 
-// CreateDomain: name:required, propertiess:optional (array = nSketchTypes, order of types above)
+func (m *SketchState) GetExact() bool {
+	if m != nil && m.Exact != nil {
+		return *m.Exact
+	}
+	return false
+}
+
+func (m *SketchState) GetValuesAdded() int64 {
+	if m != nil && m.ValuesAdded != nil {
+		return *m.ValuesAdded
+	}
+	return 0
+}
+
+func (m *SketchState) GetMemory() int64 {
+	if m != nil && m.Memory != nil {
+		return *m.Memory
+	}
+	return 0
+}
+
+func (m *SketchState) GetCreated() int64 {
+	if m != nil && m.Created != nil {
+		return *m.Created
+	}
+	return 0
+}
+
+// CreateDomain: name:required, sketches:required (one per type, type:required, properties:optional)
 // DeleteDomain: name:required
 // GetDomain   : name:required
 type Domain struct {
@@ -306,9 +408,13 @@ func (m *Membership) GetIsMember() bool {
 	return false
 }
 
+// lower and upper approximately bound the count of a value: collisions with
+// high probability, and log counters to one standard deviation
 type Frequency struct {
 	Value            *string `protobuf:"bytes,1,req,name=value" json:"value,omitempty"`
 	Count            *int64  `protobuf:"varint,2,req,name=count" json:"count,omitempty"`
+	Lower            *int64  `protobuf:"varint,3,opt,name=lower" json:"lower,omitempty"`
+	Upper            *int64  `protobuf:"varint,4,opt,name=upper" json:"upper,omitempty"`
 	XXX_unrecognized []byte  `json:"-"`
 }
 
@@ -331,9 +437,24 @@ func (m *Frequency) GetCount() int64 {
 	return 0
 }
 
+func (m *Frequency) GetLower() int64 {
+	if m != nil && m.Lower != nil {
+		return *m.Lower
+	}
+	return 0
+}
+
+func (m *Frequency) GetUpper() int64 {
+	if m != nil && m.Upper != nil {
+		return *m.Upper
+	}
+	return 0
+}
+
 type Rank struct {
 	Value            *string `protobuf:"bytes,1,req,name=value" json:"value,omitempty"`
 	Count            *int64  `protobuf:"varint,2,req,name=count" json:"count,omitempty"`
+	Error            *int64  `protobuf:"varint,3,opt,name=error" json:"error,omitempty"`
 	XXX_unrecognized []byte  `json:"-"`
 }
 
@@ -356,6 +477,64 @@ func (m *Rank) GetCount() int64 {
 	return 0
 }
 
+func (m *Rank) GetError() int64 {
+	if m != nil && m.Error != nil {
+		return *m.Error
+	}
+	return 0
+}
+
+type Quantile struct {
+	Quantile         *float64 `protobuf:"fixed64,1,req,name=quantile" json:"quantile,omitempty"`
+	Value            *float64 `protobuf:"fixed64,2,req,name=value" json:"value,omitempty"`
+	XXX_unrecognized []byte   `json:"-"`
+}
+
+func (m *Quantile) Reset()                    { *m = Quantile{} }
+func (m *Quantile) String() string            { return proto.CompactTextString(m) }
+func (*Quantile) ProtoMessage()               {}
+func (*Quantile) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{8} }
+
+func (m *Quantile) GetQuantile() float64 {
+	if m != nil && m.Quantile != nil {
+		return *m.Quantile
+	}
+	return 0
+}
+
+func (m *Quantile) GetValue() float64 {
+	if m != nil && m.Value != nil {
+		return *m.Value
+	}
+	return 0
+}
+
+// Fraction of the values added that are less than or equal to value
+type CDFPoint struct {
+	Value            *float64 `protobuf:"fixed64,1,req,name=value" json:"value,omitempty"`
+	Fraction         *float64 `protobuf:"fixed64,2,req,name=fraction" json:"fraction,omitempty"`
+	XXX_unrecognized []byte   `json:"-"`
+}
+
+func (m *CDFPoint) Reset()                    { *m = CDFPoint{} }
+func (m *CDFPoint) String() string            { return proto.CompactTextString(m) }
+func (*CDFPoint) ProtoMessage()               {}
+func (*CDFPoint) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{9} }
+
+func (m *CDFPoint) GetValue() float64 {
+	if m != nil && m.Value != nil {
+		return *m.Value
+	}
+	return 0
+}
+
+func (m *CDFPoint) GetFraction() float64 {
+	if m != nil && m.Fraction != nil {
+		return *m.Fraction
+	}
+	return 0
+}
+
 // Right now empty but in the future can request specific snapshot location
 // (e.g. S3 or disk) and snapshot options
 type CreateSnapshotRequest struct {
@@ -365,7 +544,7 @@ type CreateSnapshotRequest struct {
 func (m *CreateSnapshotRequest) Reset()                    { *m = CreateSnapshotRequest{} }
 func (m *CreateSnapshotRequest) String() string            { return proto.CompactTextString(m) }
 func (*CreateSnapshotRequest) ProtoMessage()               {}
-func (*CreateSnapshotRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{8} }
+func (*CreateSnapshotRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{10} }
 
 type CreateSnapshotReply struct {
 	Status           *SnapshotStatus `protobuf:"varint,1,req,name=status,enum=protobuf.SnapshotStatus" json:"status,omitempty"`
@@ -376,7 +555,7 @@ type CreateSnapshotReply struct {
 func (m *CreateSnapshotReply) Reset()                    { *m = CreateSnapshotReply{} }
 func (m *CreateSnapshotReply) String() string            { return proto.CompactTextString(m) }
 func (*CreateSnapshotReply) ProtoMessage()               {}
-func (*CreateSnapshotReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{9} }
+func (*CreateSnapshotReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{11} }
 
 func (m *CreateSnapshotReply) GetStatus() SnapshotStatus {
 	if m != nil && m.Status != nil {
@@ -400,7 +579,7 @@ type GetSnapshotRequest struct {
 func (m *GetSnapshotRequest) Reset()                    { *m = GetSnapshotRequest{} }
 func (m *GetSnapshotRequest) String() string            { return proto.CompactTextString(m) }
 func (*GetSnapshotRequest) ProtoMessage()               {}
-func (*GetSnapshotRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{10} }
+func (*GetSnapshotRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{12} }
 
 type GetSnapshotReply struct {
 	Status           *SnapshotStatus `protobuf:"varint,1,req,name=status,enum=protobuf.SnapshotStatus" json:"status,omitempty"`
@@ -412,7 +591,7 @@ type GetSnapshotReply struct {
 func (m *GetSnapshotReply) Reset()                    { *m = GetSnapshotReply{} }
 func (m *GetSnapshotReply) String() string            { return proto.CompactTextString(m) }
 func (*GetSnapshotReply) ProtoMessage()               {}
-func (*GetSnapshotReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{11} }
+func (*GetSnapshotReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{13} }
 
 func (m *GetSnapshotReply) GetStatus() SnapshotStatus {
 	if m != nil && m.Status != nil {
@@ -435,6 +614,25 @@ func (m *GetSnapshotReply) GetTimestamp() int64 {
 	return 0
 }
 
+// SnapshotMarker is appended to the AOF at the position of a snapshot,
+// operations following it are replayed on top of the snapshot
+type SnapshotMarker struct {
+	Id               *int64 `protobuf:"varint,1,req,name=id" json:"id,omitempty"`
+	XXX_unrecognized []byte `json:"-"`
+}
+
+func (m *SnapshotMarker) Reset()                    { *m = SnapshotMarker{} }
+func (m *SnapshotMarker) String() string            { return proto.CompactTextString(m) }
+func (*SnapshotMarker) ProtoMessage()               {}
+func (*SnapshotMarker) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{14} }
+
+func (m *SnapshotMarker) GetId() int64 {
+	if m != nil && m.Id != nil {
+		return *m.Id
+	}
+	return 0
+}
+
 type ListRequest struct {
 	Type             *SketchType `protobuf:"varint,1,req,name=type,enum=protobuf.SketchType" json:"type,omitempty"`
 	XXX_unrecognized []byte      `json:"-"`
@@ -443,7 +641,7 @@ type ListRequest struct {
 func (m *ListRequest) Reset()                    { *m = ListRequest{} }
 func (m *ListRequest) String() string            { return proto.CompactTextString(m) }
 func (*ListRequest) ProtoMessage()               {}
-func (*ListRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{12} }
+func (*ListRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{15} }
 
 func (m *ListRequest) GetType() SketchType {
 	if m != nil && m.Type != nil {
@@ -460,7 +658,7 @@ type ListReply struct {
 func (m *ListReply) Reset()                    { *m = ListReply{} }
 func (m *ListReply) String() string            { return proto.CompactTextString(m) }
 func (*ListReply) ProtoMessage()               {}
-func (*ListReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{13} }
+func (*ListReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{16} }
 
 func (m *ListReply) GetSketches() []*Sketch {
 	if m != nil {
@@ -477,7 +675,7 @@ type ListDomainsReply struct {
 func (m *ListDomainsReply) Reset()                    { *m = ListDomainsReply{} }
 func (m *ListDomainsReply) String() string            { return proto.CompactTextString(m) }
 func (*ListDomainsReply) ProtoMessage()               {}
-func (*ListDomainsReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{14} }
+func (*ListDomainsReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{17} }
 
 func (m *ListDomainsReply) GetNames() []string {
 	if m != nil {
@@ -486,17 +684,73 @@ func (m *ListDomainsReply) GetNames() []string {
 	return nil
 }
 
+// Merge: destination and sources must be of the same type, have the same
+// errorRate and, for MEMB and FREQ, the same maxUniqueItems, for RANK the same
+// size
+type MergeRequest struct {
+	Destination      *Sketch   `protobuf:"bytes,1,req,name=destination" json:"destination,omitempty"`
+	Sources          []*Sketch `protobuf:"bytes,2,rep,name=sources" json:"sources,omitempty"`
+	XXX_unrecognized []byte    `json:"-"`
+}
+
+func (m *MergeRequest) Reset()                    { *m = MergeRequest{} }
+func (m *MergeRequest) String() string            { return proto.CompactTextString(m) }
+func (*MergeRequest) ProtoMessage()               {}
+func (*MergeRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{18} }
+
+func (m *MergeRequest) GetDestination() *Sketch {
+	if m != nil {
+		return m.Destination
+	}
+	return nil
+}
+
+func (m *MergeRequest) GetSources() []*Sketch {
+	if m != nil {
+		return m.Sources
+	}
+	return nil
+}
+
+// A value added count times at once
+type WeightedValue struct {
+	Value            *string `protobuf:"bytes,1,req,name=value" json:"value,omitempty"`
+	Count            *int64  `protobuf:"varint,2,req,name=count" json:"count,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *WeightedValue) Reset()                    { *m = WeightedValue{} }
+func (m *WeightedValue) String() string            { return proto.CompactTextString(m) }
+func (*WeightedValue) ProtoMessage()               {}
+func (*WeightedValue) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{19} }
+
+func (m *WeightedValue) GetValue() string {
+	if m != nil && m.Value != nil {
+		return *m.Value
+	}
+	return ""
+}
+
+func (m *WeightedValue) GetCount() int64 {
+	if m != nil && m.Count != nil {
+		return *m.Count
+	}
+	return 0
+}
+
 type AddRequest struct {
-	Domain           *Domain  `protobuf:"bytes,1,opt,name=domain" json:"domain,omitempty"`
-	Sketch           *Sketch  `protobuf:"bytes,2,opt,name=sketch" json:"sketch,omitempty"`
-	Values           []string `protobuf:"bytes,3,rep,name=values" json:"values,omitempty"`
-	XXX_unrecognized []byte   `json:"-"`
+	Domain           *Domain          `protobuf:"bytes,1,opt,name=domain" json:"domain,omitempty"`
+	Sketch           *Sketch          `protobuf:"bytes,2,opt,name=sketch" json:"sketch,omitempty"`
+	Values           []string         `protobuf:"bytes,3,rep,name=values" json:"values,omitempty"`
+	Timestamp        *int64           `protobuf:"varint,4,opt,name=timestamp" json:"timestamp,omitempty"`
+	WeightedValues   []*WeightedValue `protobuf:"bytes,5,rep,name=weightedValues" json:"weightedValues,omitempty"`
+	XXX_unrecognized []byte           `json:"-"`
 }
 
 func (m *AddRequest) Reset()                    { *m = AddRequest{} }
 func (m *AddRequest) String() string            { return proto.CompactTextString(m) }
 func (*AddRequest) ProtoMessage()               {}
-func (*AddRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{15} }
+func (*AddRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{20} }
 
 func (m *AddRequest) GetDomain() *Domain {
 	if m != nil {
@@ -519,172 +773,1051 @@ func (m *AddRequest) GetValues() []string {
 	return nil
 }
 
-type AddReply struct {
-	XXX_unrecognized []byte `json:"-"`
+func (m *AddRequest) GetTimestamp() int64 {
+	if m != nil && m.Timestamp != nil {
+		return *m.Timestamp
+	}
+	return 0
 }
 
-func (m *AddReply) Reset()                    { *m = AddReply{} }
-func (m *AddReply) String() string            { return proto.CompactTextString(m) }
-func (*AddReply) ProtoMessage()               {}
-func (*AddReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{16} }
+func (m *AddRequest) GetWeightedValues() []*WeightedValue {
+	if m != nil {
+		return m.WeightedValues
+	}
+	return nil
+}
 
-// All Sketches will be of one kind
-// All values will apply to all sketches (if card or ranking, values will be ignored)
-type GetRequest struct {
-	Sketches         []*Sketch `protobuf:"bytes,1,rep,name=sketches" json:"sketches,omitempty"`
-	Values           []string  `protobuf:"bytes,2,rep,name=values" json:"values,omitempty"`
-	XXX_unrecognized []byte    `json:"-"`
+// Whether a value added to a MEMB sketch was new to it, a value added more
+// than once in a request is only new the first time. False positives of the
+// sketch report new values as already present.
+type Insertion struct {
+	Value            *string `protobuf:"bytes,1,req,name=value" json:"value,omitempty"`
+	Inserted         *bool   `protobuf:"varint,2,req,name=inserted" json:"inserted,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
 }
 
-func (m *GetRequest) Reset()                    { *m = GetRequest{} }
-func (m *GetRequest) String() string            { return proto.CompactTextString(m) }
-func (*GetRequest) ProtoMessage()               {}
-func (*GetRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{17} }
+func (m *Insertion) Reset()                    { *m = Insertion{} }
+func (m *Insertion) String() string            { return proto.CompactTextString(m) }
+func (*Insertion) ProtoMessage()               {}
+func (*Insertion) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{21} }
 
-func (m *GetRequest) GetSketches() []*Sketch {
+func (m *Insertion) GetValue() string {
+	if m != nil && m.Value != nil {
+		return *m.Value
+	}
+	return ""
+}
+
+func (m *Insertion) GetInserted() bool {
+	if m != nil && m.Inserted != nil {
+		return *m.Inserted
+	}
+	return false
+}
+
+// Outcome of adding to a sketch, the insertions of MEMB sketches are in the
+// order of the values followed by the weighted values of the request. CARD
+// sketches only change when their cardinality does, MEMB sketches when a value
+// is inserted.
+type AddResult struct {
+	Sketch           *Sketch      `protobuf:"bytes,1,req,name=sketch" json:"sketch,omitempty"`
+	Success          *bool        `protobuf:"varint,2,req,name=success" json:"success,omitempty"`
+	Error            *string      `protobuf:"bytes,3,opt,name=error" json:"error,omitempty"`
+	Insertions       []*Insertion `protobuf:"bytes,4,rep,name=insertions" json:"insertions,omitempty"`
+	Changed          *bool        `protobuf:"varint,5,opt,name=changed" json:"changed,omitempty"`
+	XXX_unrecognized []byte       `json:"-"`
+}
+
+func (m *AddResult) Reset()                    { *m = AddResult{} }
+func (m *AddResult) String() string            { return proto.CompactTextString(m) }
+func (*AddResult) ProtoMessage()               {}
+func (*AddResult) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{22} }
+
+func (m *AddResult) GetSketch() *Sketch {
 	if m != nil {
-		return m.Sketches
+		return m.Sketch
 	}
 	return nil
 }
 
-func (m *GetRequest) GetValues() []string {
+func (m *AddResult) GetSuccess() bool {
+	if m != nil && m.Success != nil {
+		return *m.Success
+	}
+	return false
+}
+
+func (m *AddResult) GetError() string {
+	if m != nil && m.Error != nil {
+		return *m.Error
+	}
+	return ""
+}
+
+func (m *AddResult) GetInsertions() []*Insertion {
 	if m != nil {
-		return m.Values
+		return m.Insertions
 	}
 	return nil
 }
 
-type MembershipResult struct {
-	Memberships      []*Membership `protobuf:"bytes,1,rep,name=memberships" json:"memberships,omitempty"`
-	XXX_unrecognized []byte        `json:"-"`
+func (m *AddResult) GetChanged() bool {
+	if m != nil && m.Changed != nil {
+		return *m.Changed
+	}
+	return false
 }
 
-func (m *MembershipResult) Reset()                    { *m = MembershipResult{} }
-func (m *MembershipResult) String() string            { return proto.CompactTextString(m) }
-func (*MembershipResult) ProtoMessage()               {}
-func (*MembershipResult) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{18} }
+// One result per sketch added to
+type AddReply struct {
+	Results          []*AddResult `protobuf:"bytes,1,rep,name=results" json:"results,omitempty"`
+	XXX_unrecognized []byte       `json:"-"`
+}
 
-func (m *MembershipResult) GetMemberships() []*Membership {
+func (m *AddReply) Reset()                    { *m = AddReply{} }
+func (m *AddReply) String() string            { return proto.CompactTextString(m) }
+func (*AddReply) ProtoMessage()               {}
+func (*AddReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{23} }
+
+func (m *AddReply) GetResults() []*AddResult {
 	if m != nil {
-		return m.Memberships
+		return m.Results
 	}
 	return nil
 }
 
-type FrequencyResult struct {
-	Frequencies      []*Frequency `protobuf:"bytes,2,rep,name=frequencies" json:"frequencies,omitempty"`
-	XXX_unrecognized []byte       `json:"-"`
+type AddStreamError struct {
+	Request          *int64  `protobuf:"varint,1,req,name=request" json:"request,omitempty"`
+	Target           *string `protobuf:"bytes,2,req,name=target" json:"target,omitempty"`
+	Error            *string `protobuf:"bytes,3,req,name=error" json:"error,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
 }
 
-func (m *FrequencyResult) Reset()                    { *m = FrequencyResult{} }
-func (m *FrequencyResult) String() string            { return proto.CompactTextString(m) }
-func (*FrequencyResult) ProtoMessage()               {}
-func (*FrequencyResult) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{19} }
+func (m *AddStreamError) Reset()                    { *m = AddStreamError{} }
+func (m *AddStreamError) String() string            { return proto.CompactTextString(m) }
+func (*AddStreamError) ProtoMessage()               {}
+func (*AddStreamError) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{24} }
 
-func (m *FrequencyResult) GetFrequencies() []*Frequency {
+func (m *AddStreamError) GetRequest() int64 {
+	if m != nil && m.Request != nil {
+		return *m.Request
+	}
+	return 0
+}
+
+func (m *AddStreamError) GetTarget() string {
+	if m != nil && m.Target != nil {
+		return *m.Target
+	}
+	return ""
+}
+
+func (m *AddStreamError) GetError() string {
+	if m != nil && m.Error != nil {
+		return *m.Error
+	}
+	return ""
+}
+
+// Acks of an add stream are sent periodically and once the client closes the
+// stream, the counts are for the whole stream and the errors since the last ack
+type AddStreamReply struct {
+	Requests         *int64            `protobuf:"varint,1,req,name=requests" json:"requests,omitempty"`
+	Values           *int64            `protobuf:"varint,2,req,name=values" json:"values,omitempty"`
+	Errors           []*AddStreamError `protobuf:"bytes,3,rep,name=errors" json:"errors,omitempty"`
+	XXX_unrecognized []byte            `json:"-"`
+}
+
+func (m *AddStreamReply) Reset()                    { *m = AddStreamReply{} }
+func (m *AddStreamReply) String() string            { return proto.CompactTextString(m) }
+func (*AddStreamReply) ProtoMessage()               {}
+func (*AddStreamReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{25} }
+
+func (m *AddStreamReply) GetRequests() int64 {
+	if m != nil && m.Requests != nil {
+		return *m.Requests
+	}
+	return 0
+}
+
+func (m *AddStreamReply) GetValues() int64 {
+	if m != nil && m.Values != nil {
+		return *m.Values
+	}
+	return 0
+}
+
+func (m *AddStreamReply) GetErrors() []*AddStreamError {
 	if m != nil {
-		return m.Frequencies
+		return m.Errors
 	}
 	return nil
 }
 
-type CardinalityResult struct {
-	Cardinality      *int64 `protobuf:"varint,1,req,name=cardinality" json:"cardinality,omitempty"`
-	XXX_unrecognized []byte `json:"-"`
+// Remove: only deletable MEMB sketches support removing values
+type RemoveRequest struct {
+	Sketch           *Sketch  `protobuf:"bytes,1,req,name=sketch" json:"sketch,omitempty"`
+	Values           []string `protobuf:"bytes,2,rep,name=values" json:"values,omitempty"`
+	XXX_unrecognized []byte   `json:"-"`
 }
 
-func (m *CardinalityResult) Reset()                    { *m = CardinalityResult{} }
-func (m *CardinalityResult) String() string            { return proto.CompactTextString(m) }
-func (*CardinalityResult) ProtoMessage()               {}
-func (*CardinalityResult) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{20} }
+func (m *RemoveRequest) Reset()                    { *m = RemoveRequest{} }
+func (m *RemoveRequest) String() string            { return proto.CompactTextString(m) }
+func (*RemoveRequest) ProtoMessage()               {}
+func (*RemoveRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{26} }
 
-func (m *CardinalityResult) GetCardinality() int64 {
-	if m != nil && m.Cardinality != nil {
-		return *m.Cardinality
+func (m *RemoveRequest) GetSketch() *Sketch {
+	if m != nil {
+		return m.Sketch
+	}
+	return nil
+}
+
+func (m *RemoveRequest) GetValues() []string {
+	if m != nil {
+		return m.Values
+	}
+	return nil
+}
+
+// Whether a value was held by the sketch and removed from it. Values sharing a
+// fingerprint are held once, removing one of them removes the others.
+type Removal struct {
+	Value            *string `protobuf:"bytes,1,req,name=value" json:"value,omitempty"`
+	Removed          *bool   `protobuf:"varint,2,req,name=removed" json:"removed,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *Removal) Reset()                    { *m = Removal{} }
+func (m *Removal) String() string            { return proto.CompactTextString(m) }
+func (*Removal) ProtoMessage()               {}
+func (*Removal) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{27} }
+
+func (m *Removal) GetValue() string {
+	if m != nil && m.Value != nil {
+		return *m.Value
+	}
+	return ""
+}
+
+func (m *Removal) GetRemoved() bool {
+	if m != nil && m.Removed != nil {
+		return *m.Removed
+	}
+	return false
+}
+
+type RemoveReply struct {
+	Removals         []*Removal `protobuf:"bytes,1,rep,name=removals" json:"removals,omitempty"`
+	XXX_unrecognized []byte     `json:"-"`
+}
+
+func (m *RemoveReply) Reset()                    { *m = RemoveReply{} }
+func (m *RemoveReply) String() string            { return proto.CompactTextString(m) }
+func (*RemoveReply) ProtoMessage()               {}
+func (*RemoveReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{28} }
+
+func (m *RemoveReply) GetRemovals() []*Removal {
+	if m != nil {
+		return m.Removals
+	}
+	return nil
+}
+
+// All Sketches will be of one kind
+// All values will apply to all sketches (if card or ranking, values will be ignored)
+type GetRequest struct {
+	Sketches []*Sketch `protobuf:"bytes,1,rep,name=sketches" json:"sketches,omitempty"`
+	Values   []string  `protobuf:"bytes,2,rep,name=values" json:"values,omitempty"`
+	// Windowed sketches are queried over all their buckets unless either the
+	// last buckets or a range in seconds since epoch are given
+	Buckets *int64 `protobuf:"varint,3,opt,name=buckets" json:"buckets,omitempty"`
+	From    *int64 `protobuf:"varint,4,opt,name=from" json:"from,omitempty"`
+	To      *int64 `protobuf:"varint,5,opt,name=to" json:"to,omitempty"`
+	// QUAN sketches return the quantiles, in [0, 1], and the CDF of the values
+	// given here instead of values
+	Quantiles        []float64 `protobuf:"fixed64,6,rep,name=quantiles" json:"quantiles,omitempty"`
+	Cdf              []float64 `protobuf:"fixed64,7,rep,name=cdf" json:"cdf,omitempty"`
+	XXX_unrecognized []byte    `json:"-"`
+}
+
+func (m *GetRequest) Reset()                    { *m = GetRequest{} }
+func (m *GetRequest) String() string            { return proto.CompactTextString(m) }
+func (*GetRequest) ProtoMessage()               {}
+func (*GetRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{29} }
+
+func (m *GetRequest) GetSketches() []*Sketch {
+	if m != nil {
+		return m.Sketches
+	}
+	return nil
+}
+
+func (m *GetRequest) GetValues() []string {
+	if m != nil {
+		return m.Values
+	}
+	return nil
+}
+
+func (m *GetRequest) GetBuckets() int64 {
+	if m != nil && m.Buckets != nil {
+		return *m.Buckets
+	}
+	return 0
+}
+
+func (m *GetRequest) GetFrom() int64 {
+	if m != nil && m.From != nil {
+		return *m.From
+	}
+	return 0
+}
+
+func (m *GetRequest) GetTo() int64 {
+	if m != nil && m.To != nil {
+		return *m.To
+	}
+	return 0
+}
+
+func (m *GetRequest) GetQuantiles() []float64 {
+	if m != nil {
+		return m.Quantiles
+	}
+	return nil
+}
+
+func (m *GetRequest) GetCdf() []float64 {
+	if m != nil {
+		return m.Cdf
+	}
+	return nil
+}
+
+// falsePositiveRate is the probability that a value that was not added is
+// reported as a member, at the current fill of the sketch
+type MembershipResult struct {
+	Memberships       []*Membership `protobuf:"bytes,1,rep,name=memberships" json:"memberships,omitempty"`
+	FalsePositiveRate *float64      `protobuf:"fixed64,2,opt,name=falsePositiveRate" json:"falsePositiveRate,omitempty"`
+	Exact             *bool         `protobuf:"varint,3,opt,name=exact" json:"exact,omitempty"`
+	XXX_unrecognized  []byte        `json:"-"`
+}
+
+func (m *MembershipResult) Reset()                    { *m = MembershipResult{} }
+func (m *MembershipResult) String() string            { return proto.CompactTextString(m) }
+func (*MembershipResult) ProtoMessage()               {}
+func (*MembershipResult) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{30} }
+
+func (m *MembershipResult) GetMemberships() []*Membership {
+	if m != nil {
+		return m.Memberships
+	}
+	return nil
+}
+
+func (m *MembershipResult) GetFalsePositiveRate() float64 {
+	if m != nil && m.FalsePositiveRate != nil {
+		return *m.FalsePositiveRate
+	}
+	return 0
+}
+
+func (m *MembershipResult) GetExact() bool {
+	if m != nil && m.Exact != nil {
+		return *m.Exact
+	}
+	return false
+}
+
+type FrequencyResult struct {
+	Frequencies      []*Frequency `protobuf:"bytes,2,rep,name=frequencies" json:"frequencies,omitempty"`
+	Exact            *bool        `protobuf:"varint,3,opt,name=exact" json:"exact,omitempty"`
+	XXX_unrecognized []byte       `json:"-"`
+}
+
+func (m *FrequencyResult) Reset()                    { *m = FrequencyResult{} }
+func (m *FrequencyResult) String() string            { return proto.CompactTextString(m) }
+func (*FrequencyResult) ProtoMessage()               {}
+func (*FrequencyResult) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{31} }
+
+func (m *FrequencyResult) GetFrequencies() []*Frequency {
+	if m != nil {
+		return m.Frequencies
+	}
+	return nil
+}
+
+func (m *FrequencyResult) GetExact() bool {
+	if m != nil && m.Exact != nil {
+		return *m.Exact
+	}
+	return false
+}
+
+// lower and upper are one standard error away from the cardinality
+type CardinalityResult struct {
+	Cardinality      *int64 `protobuf:"varint,1,req,name=cardinality" json:"cardinality,omitempty"`
+	Lower            *int64 `protobuf:"varint,2,opt,name=lower" json:"lower,omitempty"`
+	Upper            *int64 `protobuf:"varint,3,opt,name=upper" json:"upper,omitempty"`
+	Exact            *bool  `protobuf:"varint,4,opt,name=exact" json:"exact,omitempty"`
+	XXX_unrecognized []byte `json:"-"`
+}
+
+func (m *CardinalityResult) Reset()                    { *m = CardinalityResult{} }
+func (m *CardinalityResult) String() string            { return proto.CompactTextString(m) }
+func (*CardinalityResult) ProtoMessage()               {}
+func (*CardinalityResult) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{32} }
+
+func (m *CardinalityResult) GetCardinality() int64 {
+	if m != nil && m.Cardinality != nil {
+		return *m.Cardinality
+	}
+	return 0
+}
+
+func (m *CardinalityResult) GetLower() int64 {
+	if m != nil && m.Lower != nil {
+		return *m.Lower
+	}
+	return 0
+}
+
+func (m *CardinalityResult) GetUpper() int64 {
+	if m != nil && m.Upper != nil {
+		return *m.Upper
+	}
+	return 0
+}
+
+func (m *CardinalityResult) GetExact() bool {
+	if m != nil && m.Exact != nil {
+		return *m.Exact
+	}
+	return false
+}
+
+type RankingsResult struct {
+	Rankings         []*Rank `protobuf:"bytes,1,rep,name=rankings" json:"rankings,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *RankingsResult) Reset()                    { *m = RankingsResult{} }
+func (m *RankingsResult) String() string            { return proto.CompactTextString(m) }
+func (*RankingsResult) ProtoMessage()               {}
+func (*RankingsResult) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{33} }
+
+func (m *RankingsResult) GetRankings() []*Rank {
+	if m != nil {
+		return m.Rankings
+	}
+	return nil
+}
+
+// Min and max are missing and the quantiles and CDF are NaN if no values were
+// added
+type QuantilesResult struct {
+	Count            *int64      `protobuf:"varint,1,req,name=count" json:"count,omitempty"`
+	Min              *float64    `protobuf:"fixed64,2,opt,name=min" json:"min,omitempty"`
+	Max              *float64    `protobuf:"fixed64,3,opt,name=max" json:"max,omitempty"`
+	Quantiles        []*Quantile `protobuf:"bytes,4,rep,name=quantiles" json:"quantiles,omitempty"`
+	Cdf              []*CDFPoint `protobuf:"bytes,5,rep,name=cdf" json:"cdf,omitempty"`
+	XXX_unrecognized []byte      `json:"-"`
+}
+
+func (m *QuantilesResult) Reset()                    { *m = QuantilesResult{} }
+func (m *QuantilesResult) String() string            { return proto.CompactTextString(m) }
+func (*QuantilesResult) ProtoMessage()               {}
+func (*QuantilesResult) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{34} }
+
+func (m *QuantilesResult) GetCount() int64 {
+	if m != nil && m.Count != nil {
+		return *m.Count
+	}
+	return 0
+}
+
+func (m *QuantilesResult) GetMin() float64 {
+	if m != nil && m.Min != nil {
+		return *m.Min
+	}
+	return 0
+}
+
+func (m *QuantilesResult) GetMax() float64 {
+	if m != nil && m.Max != nil {
+		return *m.Max
+	}
+	return 0
+}
+
+func (m *QuantilesResult) GetQuantiles() []*Quantile {
+	if m != nil {
+		return m.Quantiles
+	}
+	return nil
+}
+
+func (m *QuantilesResult) GetCdf() []*CDFPoint {
+	if m != nil {
+		return m.Cdf
+	}
+	return nil
+}
+
+type GetMembershipReply struct {
+	Results          []*MembershipResult `protobuf:"bytes,1,rep,name=results" json:"results,omitempty"`
+	XXX_unrecognized []byte              `json:"-"`
+}
+
+func (m *GetMembershipReply) Reset()                    { *m = GetMembershipReply{} }
+func (m *GetMembershipReply) String() string            { return proto.CompactTextString(m) }
+func (*GetMembershipReply) ProtoMessage()               {}
+func (*GetMembershipReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{35} }
+
+func (m *GetMembershipReply) GetResults() []*MembershipResult {
+	if m != nil {
+		return m.Results
+	}
+	return nil
+}
+
+type GetFrequencyReply struct {
+	Results          []*FrequencyResult `protobuf:"bytes,1,rep,name=results" json:"results,omitempty"`
+	XXX_unrecognized []byte             `json:"-"`
+}
+
+func (m *GetFrequencyReply) Reset()                    { *m = GetFrequencyReply{} }
+func (m *GetFrequencyReply) String() string            { return proto.CompactTextString(m) }
+func (*GetFrequencyReply) ProtoMessage()               {}
+func (*GetFrequencyReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{36} }
+
+func (m *GetFrequencyReply) GetResults() []*FrequencyResult {
+	if m != nil {
+		return m.Results
+	}
+	return nil
+}
+
+type GetCardinalityReply struct {
+	Results          []*CardinalityResult `protobuf:"bytes,1,rep,name=results" json:"results,omitempty"`
+	XXX_unrecognized []byte               `json:"-"`
+}
+
+func (m *GetCardinalityReply) Reset()                    { *m = GetCardinalityReply{} }
+func (m *GetCardinalityReply) String() string            { return proto.CompactTextString(m) }
+func (*GetCardinalityReply) ProtoMessage()               {}
+func (*GetCardinalityReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{37} }
+
+func (m *GetCardinalityReply) GetResults() []*CardinalityResult {
+	if m != nil {
+		return m.Results
+	}
+	return nil
+}
+
+type GetRankingsReply struct {
+	Results          []*RankingsResult `protobuf:"bytes,1,rep,name=results" json:"results,omitempty"`
+	XXX_unrecognized []byte            `json:"-"`
+}
+
+func (m *GetRankingsReply) Reset()                    { *m = GetRankingsReply{} }
+func (m *GetRankingsReply) String() string            { return proto.CompactTextString(m) }
+func (*GetRankingsReply) ProtoMessage()               {}
+func (*GetRankingsReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{38} }
+
+func (m *GetRankingsReply) GetResults() []*RankingsResult {
+	if m != nil {
+		return m.Results
+	}
+	return nil
+}
+
+type GetQuantilesReply struct {
+	Results          []*QuantilesResult `protobuf:"bytes,1,rep,name=results" json:"results,omitempty"`
+	XXX_unrecognized []byte             `json:"-"`
+}
+
+func (m *GetQuantilesReply) Reset()                    { *m = GetQuantilesReply{} }
+func (m *GetQuantilesReply) String() string            { return proto.CompactTextString(m) }
+func (*GetQuantilesReply) ProtoMessage()               {}
+func (*GetQuantilesReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{39} }
+
+func (m *GetQuantilesReply) GetResults() []*QuantilesResult {
+	if m != nil {
+		return m.Results
+	}
+	return nil
+}
+
+// Cardinality derived from the estimates of several others
+type CardinalityEstimate struct {
+	Cardinality      *int64 `protobuf:"varint,1,req,name=cardinality" json:"cardinality,omitempty"`
+	Error            *int64 `protobuf:"varint,2,req,name=error" json:"error,omitempty"`
+	XXX_unrecognized []byte `json:"-"`
+}
+
+func (m *CardinalityEstimate) Reset()                    { *m = CardinalityEstimate{} }
+func (m *CardinalityEstimate) String() string            { return proto.CompactTextString(m) }
+func (*CardinalityEstimate) ProtoMessage()               {}
+func (*CardinalityEstimate) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{40} }
+
+func (m *CardinalityEstimate) GetCardinality() int64 {
+	if m != nil && m.Cardinality != nil {
+		return *m.Cardinality
+	}
+	return 0
+}
+
+func (m *CardinalityEstimate) GetError() int64 {
+	if m != nil && m.Error != nil {
+		return *m.Error
+	}
+	return 0
+}
+
+// Set algebra over the CARD sketches of a request: A ∪ B ∪ ..., A ∩ B ∩ ...
+// and A \ (B ∪ ...) where A is the first sketch
+type GetSetCardinalityReply struct {
+	Union            *CardinalityEstimate `protobuf:"bytes,1,req,name=union" json:"union,omitempty"`
+	Intersection     *CardinalityEstimate `protobuf:"bytes,2,req,name=intersection" json:"intersection,omitempty"`
+	Difference       *CardinalityEstimate `protobuf:"bytes,3,req,name=difference" json:"difference,omitempty"`
+	XXX_unrecognized []byte               `json:"-"`
+}
+
+func (m *GetSetCardinalityReply) Reset()                    { *m = GetSetCardinalityReply{} }
+func (m *GetSetCardinalityReply) String() string            { return proto.CompactTextString(m) }
+func (*GetSetCardinalityReply) ProtoMessage()               {}
+func (*GetSetCardinalityReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{41} }
+
+func (m *GetSetCardinalityReply) GetUnion() *CardinalityEstimate {
+	if m != nil {
+		return m.Union
+	}
+	return nil
+}
+
+func (m *GetSetCardinalityReply) GetIntersection() *CardinalityEstimate {
+	if m != nil {
+		return m.Intersection
+	}
+	return nil
+}
+
+func (m *GetSetCardinalityReply) GetDifference() *CardinalityEstimate {
+	if m != nil {
+		return m.Difference
+	}
+	return nil
+}
+
+// Similarity of the sets of values added to the SIMI sketches of a request,
+// estimated from their MinHash signatures
+type GetSimilarityReply struct {
+	Jaccard          *float64 `protobuf:"fixed64,1,req,name=jaccard" json:"jaccard,omitempty"`
+	Intersection     *int64   `protobuf:"varint,2,req,name=intersection" json:"intersection,omitempty"`
+	Union            *int64   `protobuf:"varint,3,req,name=union" json:"union,omitempty"`
+	XXX_unrecognized []byte   `json:"-"`
+}
+
+func (m *GetSimilarityReply) Reset()                    { *m = GetSimilarityReply{} }
+func (m *GetSimilarityReply) String() string            { return proto.CompactTextString(m) }
+func (*GetSimilarityReply) ProtoMessage()               {}
+func (*GetSimilarityReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{42} }
+
+func (m *GetSimilarityReply) GetJaccard() float64 {
+	if m != nil && m.Jaccard != nil {
+		return *m.Jaccard
+	}
+	return 0
+}
+
+func (m *GetSimilarityReply) GetIntersection() int64 {
+	if m != nil && m.Intersection != nil {
+		return *m.Intersection
+	}
+	return 0
+}
+
+func (m *GetSimilarityReply) GetUnion() int64 {
+	if m != nil && m.Union != nil {
+		return *m.Union
+	}
+	return 0
+}
+
+// Result of querying a single sketch, either the result matching the type of
+// the sketch or an error
+type QueryResult struct {
+	Sketch *Sketch `protobuf:"bytes,1,req,name=sketch" json:"sketch,omitempty"`
+	// Types that are valid to be assigned to Result:
+	//	*QueryResult_Membership
+	//	*QueryResult_Frequency
+	//	*QueryResult_Cardinality
+	//	*QueryResult_Rankings
+	//	*QueryResult_Quantiles
+	Result           isQueryResult_Result `protobuf_oneof:"result"`
+	Error            *string              `protobuf:"bytes,6,opt,name=error" json:"error,omitempty"`
+	XXX_unrecognized []byte               `json:"-"`
+}
+
+func (m *QueryResult) Reset()                    { *m = QueryResult{} }
+func (m *QueryResult) String() string            { return proto.CompactTextString(m) }
+func (*QueryResult) ProtoMessage()               {}
+func (*QueryResult) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{43} }
+
+type isQueryResult_Result interface{ isQueryResult_Result() }
+
+type QueryResult_Membership struct {
+	Membership *MembershipResult `protobuf:"bytes,2,opt,name=membership,oneof"`
+}
+type QueryResult_Frequency struct {
+	Frequency *FrequencyResult `protobuf:"bytes,3,opt,name=frequency,oneof"`
+}
+type QueryResult_Cardinality struct {
+	Cardinality *CardinalityResult `protobuf:"bytes,4,opt,name=cardinality,oneof"`
+}
+type QueryResult_Rankings struct {
+	Rankings *RankingsResult `protobuf:"bytes,5,opt,name=rankings,oneof"`
+}
+type QueryResult_Quantiles struct {
+	Quantiles *QuantilesResult `protobuf:"bytes,7,opt,name=quantiles,oneof"`
+}
+
+func (*QueryResult_Membership) isQueryResult_Result()  {}
+func (*QueryResult_Frequency) isQueryResult_Result()   {}
+func (*QueryResult_Cardinality) isQueryResult_Result() {}
+func (*QueryResult_Rankings) isQueryResult_Result()    {}
+func (*QueryResult_Quantiles) isQueryResult_Result()   {}
+
+func (m *QueryResult) GetResult() isQueryResult_Result {
+	if m != nil {
+		return m.Result
+	}
+	return nil
+}
+
+func (m *QueryResult) GetSketch() *Sketch {
+	if m != nil {
+		return m.Sketch
+	}
+	return nil
+}
+
+func (m *QueryResult) GetMembership() *MembershipResult {
+	if x, ok := m.GetResult().(*QueryResult_Membership); ok {
+		return x.Membership
+	}
+	return nil
+}
+
+func (m *QueryResult) GetFrequency() *FrequencyResult {
+	if x, ok := m.GetResult().(*QueryResult_Frequency); ok {
+		return x.Frequency
+	}
+	return nil
+}
+
+func (m *QueryResult) GetCardinality() *CardinalityResult {
+	if x, ok := m.GetResult().(*QueryResult_Cardinality); ok {
+		return x.Cardinality
+	}
+	return nil
+}
+
+func (m *QueryResult) GetRankings() *RankingsResult {
+	if x, ok := m.GetResult().(*QueryResult_Rankings); ok {
+		return x.Rankings
+	}
+	return nil
+}
+
+func (m *QueryResult) GetQuantiles() *QuantilesResult {
+	if x, ok := m.GetResult().(*QueryResult_Quantiles); ok {
+		return x.Quantiles
+	}
+	return nil
+}
+
+func (m *QueryResult) GetError() string {
+	if m != nil && m.Error != nil {
+		return *m.Error
+	}
+	return ""
+}
+
+// XXX_OneofFuncs is for the internal use of the proto package.
+func (*QueryResult) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer) error, func(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error), func(msg proto.Message) (n int), []interface{}) {
+	return _QueryResult_OneofMarshaler, _QueryResult_OneofUnmarshaler, _QueryResult_OneofSizer, []interface{}{
+		(*QueryResult_Membership)(nil),
+		(*QueryResult_Frequency)(nil),
+		(*QueryResult_Cardinality)(nil),
+		(*QueryResult_Rankings)(nil),
+		(*QueryResult_Quantiles)(nil),
+	}
+}
+
+func _QueryResult_OneofMarshaler(msg proto.Message, b *proto.Buffer) error {
+	m := msg.(*QueryResult)
+	// result
+	switch x := m.Result.(type) {
+	case *QueryResult_Membership:
+		b.EncodeVarint(2<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Membership); err != nil {
+			return err
+		}
+	case *QueryResult_Frequency:
+		b.EncodeVarint(3<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Frequency); err != nil {
+			return err
+		}
+	case *QueryResult_Cardinality:
+		b.EncodeVarint(4<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Cardinality); err != nil {
+			return err
+		}
+	case *QueryResult_Rankings:
+		b.EncodeVarint(5<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Rankings); err != nil {
+			return err
+		}
+	case *QueryResult_Quantiles:
+		b.EncodeVarint(7<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Quantiles); err != nil {
+			return err
+		}
+	case nil:
+	default:
+		return fmt.Errorf("QueryResult.Result has unexpected type %T", x)
+	}
+	return nil
+}
+
+func _QueryResult_OneofUnmarshaler(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error) {
+	m := msg.(*QueryResult)
+	switch tag {
+	case 2: // result.membership
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(MembershipResult)
+		err := b.DecodeMessage(msg)
+		m.Result = &QueryResult_Membership{msg}
+		return true, err
+	case 3: // result.frequency
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(FrequencyResult)
+		err := b.DecodeMessage(msg)
+		m.Result = &QueryResult_Frequency{msg}
+		return true, err
+	case 4: // result.cardinality
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(CardinalityResult)
+		err := b.DecodeMessage(msg)
+		m.Result = &QueryResult_Cardinality{msg}
+		return true, err
+	case 5: // result.rankings
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(RankingsResult)
+		err := b.DecodeMessage(msg)
+		m.Result = &QueryResult_Rankings{msg}
+		return true, err
+	case 7: // result.quantiles
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(QuantilesResult)
+		err := b.DecodeMessage(msg)
+		m.Result = &QueryResult_Quantiles{msg}
+		return true, err
+	default:
+		return false, nil
+	}
+}
+
+func _QueryResult_OneofSizer(msg proto.Message) (n int) {
+	m := msg.(*QueryResult)
+	// result
+	switch x := m.Result.(type) {
+	case *QueryResult_Membership:
+		s := proto.Size(x.Membership)
+		n += proto.SizeVarint(2<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *QueryResult_Frequency:
+		s := proto.Size(x.Frequency)
+		n += proto.SizeVarint(3<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *QueryResult_Cardinality:
+		s := proto.Size(x.Cardinality)
+		n += proto.SizeVarint(4<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *QueryResult_Rankings:
+		s := proto.Size(x.Rankings)
+		n += proto.SizeVarint(5<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *QueryResult_Quantiles:
+		s := proto.Size(x.Quantiles)
+		n += proto.SizeVarint(7<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case nil:
+	default:
+		panic(fmt.Sprintf("proto: unexpected type %T in oneof", x))
+	}
+	return n
+}
+
+// AddSketchToDomain     : domain.name:required, sketch.type:required, sketch.properties:optional
+// RemoveSketchFromDomain: domain.name:required, sketch.type:required
+// The sketches of a domain are named after it, the name of sketch is ignored
+type DomainSketchRequest struct {
+	Domain           *Domain `protobuf:"bytes,1,req,name=domain" json:"domain,omitempty"`
+	Sketch           *Sketch `protobuf:"bytes,2,req,name=sketch" json:"sketch,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *DomainSketchRequest) Reset()                    { *m = DomainSketchRequest{} }
+func (m *DomainSketchRequest) String() string            { return proto.CompactTextString(m) }
+func (*DomainSketchRequest) ProtoMessage()               {}
+func (*DomainSketchRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{44} }
+
+func (m *DomainSketchRequest) GetDomain() *Domain {
+	if m != nil {
+		return m.Domain
+	}
+	return nil
+}
+
+func (m *DomainSketchRequest) GetSketch() *Sketch {
+	if m != nil {
+		return m.Sketch
+	}
+	return nil
+}
+
+type QueryDomainRequest struct {
+	Domain           *Domain   `protobuf:"bytes,1,req,name=domain" json:"domain,omitempty"`
+	Values           []string  `protobuf:"bytes,2,rep,name=values" json:"values,omitempty"`
+	Quantiles        []float64 `protobuf:"fixed64,3,rep,name=quantiles" json:"quantiles,omitempty"`
+	Cdf              []float64 `protobuf:"fixed64,4,rep,name=cdf" json:"cdf,omitempty"`
+	XXX_unrecognized []byte    `json:"-"`
+}
+
+func (m *QueryDomainRequest) Reset()                    { *m = QueryDomainRequest{} }
+func (m *QueryDomainRequest) String() string            { return proto.CompactTextString(m) }
+func (*QueryDomainRequest) ProtoMessage()               {}
+func (*QueryDomainRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{45} }
+
+func (m *QueryDomainRequest) GetDomain() *Domain {
+	if m != nil {
+		return m.Domain
 	}
-	return 0
+	return nil
 }
 
-type RankingsResult struct {
-	Rankings         []*Rank `protobuf:"bytes,1,rep,name=rankings" json:"rankings,omitempty"`
-	XXX_unrecognized []byte  `json:"-"`
+func (m *QueryDomainRequest) GetValues() []string {
+	if m != nil {
+		return m.Values
+	}
+	return nil
 }
 
-func (m *RankingsResult) Reset()                    { *m = RankingsResult{} }
-func (m *RankingsResult) String() string            { return proto.CompactTextString(m) }
-func (*RankingsResult) ProtoMessage()               {}
-func (*RankingsResult) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{21} }
+func (m *QueryDomainRequest) GetQuantiles() []float64 {
+	if m != nil {
+		return m.Quantiles
+	}
+	return nil
+}
 
-func (m *RankingsResult) GetRankings() []*Rank {
+func (m *QueryDomainRequest) GetCdf() []float64 {
 	if m != nil {
-		return m.Rankings
+		return m.Cdf
 	}
 	return nil
 }
 
-type GetMembershipReply struct {
-	Results          []*MembershipResult `protobuf:"bytes,1,rep,name=results" json:"results,omitempty"`
-	XXX_unrecognized []byte              `json:"-"`
+// Results of all the sketches of a domain, a result is missing if the domain
+// has no sketch of its type
+type QueryDomainReply struct {
+	Domain           *Domain            `protobuf:"bytes,1,req,name=domain" json:"domain,omitempty"`
+	Cardinality      *CardinalityResult `protobuf:"bytes,2,opt,name=cardinality" json:"cardinality,omitempty"`
+	Rankings         *RankingsResult    `protobuf:"bytes,3,opt,name=rankings" json:"rankings,omitempty"`
+	Frequencies      *FrequencyResult   `protobuf:"bytes,4,opt,name=frequencies" json:"frequencies,omitempty"`
+	Memberships      *MembershipResult  `protobuf:"bytes,5,opt,name=memberships" json:"memberships,omitempty"`
+	Quantiles        *QuantilesResult   `protobuf:"bytes,6,opt,name=quantiles" json:"quantiles,omitempty"`
+	XXX_unrecognized []byte             `json:"-"`
 }
 
-func (m *GetMembershipReply) Reset()                    { *m = GetMembershipReply{} }
-func (m *GetMembershipReply) String() string            { return proto.CompactTextString(m) }
-func (*GetMembershipReply) ProtoMessage()               {}
-func (*GetMembershipReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{22} }
+func (m *QueryDomainReply) Reset()                    { *m = QueryDomainReply{} }
+func (m *QueryDomainReply) String() string            { return proto.CompactTextString(m) }
+func (*QueryDomainReply) ProtoMessage()               {}
+func (*QueryDomainReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{46} }
 
-func (m *GetMembershipReply) GetResults() []*MembershipResult {
+func (m *QueryDomainReply) GetDomain() *Domain {
 	if m != nil {
-		return m.Results
+		return m.Domain
 	}
 	return nil
 }
 
-type GetFrequencyReply struct {
-	Results          []*FrequencyResult `protobuf:"bytes,1,rep,name=results" json:"results,omitempty"`
-	XXX_unrecognized []byte             `json:"-"`
+func (m *QueryDomainReply) GetCardinality() *CardinalityResult {
+	if m != nil {
+		return m.Cardinality
+	}
+	return nil
 }
 
-func (m *GetFrequencyReply) Reset()                    { *m = GetFrequencyReply{} }
-func (m *GetFrequencyReply) String() string            { return proto.CompactTextString(m) }
-func (*GetFrequencyReply) ProtoMessage()               {}
-func (*GetFrequencyReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{23} }
-
-func (m *GetFrequencyReply) GetResults() []*FrequencyResult {
+func (m *QueryDomainReply) GetRankings() *RankingsResult {
 	if m != nil {
-		return m.Results
+		return m.Rankings
 	}
 	return nil
 }
 
-type GetCardinalityReply struct {
-	Results          []*CardinalityResult `protobuf:"bytes,1,rep,name=results" json:"results,omitempty"`
-	XXX_unrecognized []byte               `json:"-"`
+func (m *QueryDomainReply) GetFrequencies() *FrequencyResult {
+	if m != nil {
+		return m.Frequencies
+	}
+	return nil
 }
 
-func (m *GetCardinalityReply) Reset()                    { *m = GetCardinalityReply{} }
-func (m *GetCardinalityReply) String() string            { return proto.CompactTextString(m) }
-func (*GetCardinalityReply) ProtoMessage()               {}
-func (*GetCardinalityReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{24} }
+func (m *QueryDomainReply) GetMemberships() *MembershipResult {
+	if m != nil {
+		return m.Memberships
+	}
+	return nil
+}
 
-func (m *GetCardinalityReply) GetResults() []*CardinalityResult {
+func (m *QueryDomainReply) GetQuantiles() *QuantilesResult {
 	if m != nil {
-		return m.Results
+		return m.Quantiles
 	}
 	return nil
 }
 
-type GetRankingsReply struct {
-	Results          []*RankingsResult `protobuf:"bytes,1,rep,name=results" json:"results,omitempty"`
-	XXX_unrecognized []byte            `json:"-"`
+// Sketches of mixed types can be queried at once, results are in the order of
+// the requested sketches
+type QueryReply struct {
+	Results          []*QueryResult `protobuf:"bytes,1,rep,name=results" json:"results,omitempty"`
+	XXX_unrecognized []byte         `json:"-"`
 }
 
-func (m *GetRankingsReply) Reset()                    { *m = GetRankingsReply{} }
-func (m *GetRankingsReply) String() string            { return proto.CompactTextString(m) }
-func (*GetRankingsReply) ProtoMessage()               {}
-func (*GetRankingsReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{25} }
+func (m *QueryReply) Reset()                    { *m = QueryReply{} }
+func (m *QueryReply) String() string            { return proto.CompactTextString(m) }
+func (*QueryReply) ProtoMessage()               {}
+func (*QueryReply) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{47} }
 
-func (m *GetRankingsReply) GetResults() []*RankingsResult {
+func (m *QueryReply) GetResults() []*QueryResult {
 	if m != nil {
 		return m.Results
 	}
@@ -700,24 +1833,46 @@ func init() {
 	proto.RegisterType((*Membership)(nil), "protobuf.Membership")
 	proto.RegisterType((*Frequency)(nil), "protobuf.Frequency")
 	proto.RegisterType((*Rank)(nil), "protobuf.Rank")
+	proto.RegisterType((*Quantile)(nil), "protobuf.Quantile")
+	proto.RegisterType((*CDFPoint)(nil), "protobuf.CDFPoint")
 	proto.RegisterType((*CreateSnapshotRequest)(nil), "protobuf.CreateSnapshotRequest")
 	proto.RegisterType((*CreateSnapshotReply)(nil), "protobuf.CreateSnapshotReply")
 	proto.RegisterType((*GetSnapshotRequest)(nil), "protobuf.GetSnapshotRequest")
 	proto.RegisterType((*GetSnapshotReply)(nil), "protobuf.GetSnapshotReply")
+	proto.RegisterType((*SnapshotMarker)(nil), "protobuf.SnapshotMarker")
 	proto.RegisterType((*ListRequest)(nil), "protobuf.ListRequest")
 	proto.RegisterType((*ListReply)(nil), "protobuf.ListReply")
 	proto.RegisterType((*ListDomainsReply)(nil), "protobuf.ListDomainsReply")
+	proto.RegisterType((*MergeRequest)(nil), "protobuf.MergeRequest")
+	proto.RegisterType((*WeightedValue)(nil), "protobuf.WeightedValue")
 	proto.RegisterType((*AddRequest)(nil), "protobuf.AddRequest")
+	proto.RegisterType((*Insertion)(nil), "protobuf.Insertion")
+	proto.RegisterType((*AddResult)(nil), "protobuf.AddResult")
 	proto.RegisterType((*AddReply)(nil), "protobuf.AddReply")
+	proto.RegisterType((*AddStreamError)(nil), "protobuf.AddStreamError")
+	proto.RegisterType((*AddStreamReply)(nil), "protobuf.AddStreamReply")
+	proto.RegisterType((*RemoveRequest)(nil), "protobuf.RemoveRequest")
+	proto.RegisterType((*Removal)(nil), "protobuf.Removal")
+	proto.RegisterType((*RemoveReply)(nil), "protobuf.RemoveReply")
 	proto.RegisterType((*GetRequest)(nil), "protobuf.GetRequest")
 	proto.RegisterType((*MembershipResult)(nil), "protobuf.MembershipResult")
 	proto.RegisterType((*FrequencyResult)(nil), "protobuf.FrequencyResult")
 	proto.RegisterType((*CardinalityResult)(nil), "protobuf.CardinalityResult")
 	proto.RegisterType((*RankingsResult)(nil), "protobuf.RankingsResult")
+	proto.RegisterType((*QuantilesResult)(nil), "protobuf.QuantilesResult")
 	proto.RegisterType((*GetMembershipReply)(nil), "protobuf.GetMembershipReply")
 	proto.RegisterType((*GetFrequencyReply)(nil), "protobuf.GetFrequencyReply")
 	proto.RegisterType((*GetCardinalityReply)(nil), "protobuf.GetCardinalityReply")
 	proto.RegisterType((*GetRankingsReply)(nil), "protobuf.GetRankingsReply")
+	proto.RegisterType((*GetQuantilesReply)(nil), "protobuf.GetQuantilesReply")
+	proto.RegisterType((*CardinalityEstimate)(nil), "protobuf.CardinalityEstimate")
+	proto.RegisterType((*GetSetCardinalityReply)(nil), "protobuf.GetSetCardinalityReply")
+	proto.RegisterType((*GetSimilarityReply)(nil), "protobuf.GetSimilarityReply")
+	proto.RegisterType((*QueryResult)(nil), "protobuf.QueryResult")
+	proto.RegisterType((*DomainSketchRequest)(nil), "protobuf.DomainSketchRequest")
+	proto.RegisterType((*QueryDomainRequest)(nil), "protobuf.QueryDomainRequest")
+	proto.RegisterType((*QueryDomainReply)(nil), "protobuf.QueryDomainReply")
+	proto.RegisterType((*QueryReply)(nil), "protobuf.QueryReply")
 	proto.RegisterEnum("protobuf.SketchType", SketchType_name, SketchType_value)
 	proto.RegisterEnum("protobuf.SnapshotStatus", SnapshotStatus_name, SnapshotStatus_value)
 }
@@ -737,14 +1892,24 @@ type SkizzeClient interface {
 	CreateDomain(ctx context.Context, in *Domain, opts ...grpc.CallOption) (*Domain, error)
 	DeleteDomain(ctx context.Context, in *Domain, opts ...grpc.CallOption) (*Empty, error)
 	GetDomain(ctx context.Context, in *Domain, opts ...grpc.CallOption) (*Domain, error)
+	QueryDomain(ctx context.Context, in *QueryDomainRequest, opts ...grpc.CallOption) (*QueryDomainReply, error)
+	AddSketchToDomain(ctx context.Context, in *DomainSketchRequest, opts ...grpc.CallOption) (*Domain, error)
+	RemoveSketchFromDomain(ctx context.Context, in *DomainSketchRequest, opts ...grpc.CallOption) (*Domain, error)
 	CreateSketch(ctx context.Context, in *Sketch, opts ...grpc.CallOption) (*Sketch, error)
 	DeleteSketch(ctx context.Context, in *Sketch, opts ...grpc.CallOption) (*Empty, error)
 	GetSketch(ctx context.Context, in *Sketch, opts ...grpc.CallOption) (*Sketch, error)
 	Add(ctx context.Context, in *AddRequest, opts ...grpc.CallOption) (*AddReply, error)
+	AddStream(ctx context.Context, opts ...grpc.CallOption) (Skizze_AddStreamClient, error)
+	Merge(ctx context.Context, in *MergeRequest, opts ...grpc.CallOption) (*Empty, error)
+	Remove(ctx context.Context, in *RemoveRequest, opts ...grpc.CallOption) (*RemoveReply, error)
 	GetMembership(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetMembershipReply, error)
 	GetFrequency(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetFrequencyReply, error)
 	GetCardinality(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetCardinalityReply, error)
 	GetRankings(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetRankingsReply, error)
+	GetQuantiles(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetQuantilesReply, error)
+	GetSimilarity(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetSimilarityReply, error)
+	GetSetCardinality(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetSetCardinalityReply, error)
+	Query(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*QueryReply, error)
 }
 
 type skizzeClient struct {
@@ -827,6 +1992,33 @@ func (c *skizzeClient) GetDomain(ctx context.Context, in *Domain, opts ...grpc.C
 	return out, nil
 }
 
+func (c *skizzeClient) QueryDomain(ctx context.Context, in *QueryDomainRequest, opts ...grpc.CallOption) (*QueryDomainReply, error) {
+	out := new(QueryDomainReply)
+	err := grpc.Invoke(ctx, "/protobuf.Skizze/QueryDomain", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *skizzeClient) AddSketchToDomain(ctx context.Context, in *DomainSketchRequest, opts ...grpc.CallOption) (*Domain, error) {
+	out := new(Domain)
+	err := grpc.Invoke(ctx, "/protobuf.Skizze/AddSketchToDomain", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *skizzeClient) RemoveSketchFromDomain(ctx context.Context, in *DomainSketchRequest, opts ...grpc.CallOption) (*Domain, error) {
+	out := new(Domain)
+	err := grpc.Invoke(ctx, "/protobuf.Skizze/RemoveSketchFromDomain", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *skizzeClient) CreateSketch(ctx context.Context, in *Sketch, opts ...grpc.CallOption) (*Sketch, error) {
 	out := new(Sketch)
 	err := grpc.Invoke(ctx, "/protobuf.Skizze/CreateSketch", in, out, c.cc, opts...)
@@ -863,6 +2055,55 @@ func (c *skizzeClient) Add(ctx context.Context, in *AddRequest, opts ...grpc.Cal
 	return out, nil
 }
 
+func (c *skizzeClient) AddStream(ctx context.Context, opts ...grpc.CallOption) (Skizze_AddStreamClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_Skizze_serviceDesc.Streams[0], c.cc, "/protobuf.Skizze/AddStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &skizzeAddStreamClient{stream}
+	return x, nil
+}
+
+type Skizze_AddStreamClient interface {
+	Send(*AddRequest) error
+	Recv() (*AddStreamReply, error)
+	grpc.ClientStream
+}
+
+type skizzeAddStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *skizzeAddStreamClient) Send(m *AddRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *skizzeAddStreamClient) Recv() (*AddStreamReply, error) {
+	m := new(AddStreamReply)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *skizzeClient) Merge(ctx context.Context, in *MergeRequest, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := grpc.Invoke(ctx, "/protobuf.Skizze/Merge", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *skizzeClient) Remove(ctx context.Context, in *RemoveRequest, opts ...grpc.CallOption) (*RemoveReply, error) {
+	out := new(RemoveReply)
+	err := grpc.Invoke(ctx, "/protobuf.Skizze/Remove", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *skizzeClient) GetMembership(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetMembershipReply, error) {
 	out := new(GetMembershipReply)
 	err := grpc.Invoke(ctx, "/protobuf.Skizze/GetMembership", in, out, c.cc, opts...)
@@ -899,6 +2140,42 @@ func (c *skizzeClient) GetRankings(ctx context.Context, in *GetRequest, opts ...
 	return out, nil
 }
 
+func (c *skizzeClient) GetQuantiles(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetQuantilesReply, error) {
+	out := new(GetQuantilesReply)
+	err := grpc.Invoke(ctx, "/protobuf.Skizze/GetQuantiles", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *skizzeClient) GetSimilarity(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetSimilarityReply, error) {
+	out := new(GetSimilarityReply)
+	err := grpc.Invoke(ctx, "/protobuf.Skizze/GetSimilarity", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *skizzeClient) GetSetCardinality(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetSetCardinalityReply, error) {
+	out := new(GetSetCardinalityReply)
+	err := grpc.Invoke(ctx, "/protobuf.Skizze/GetSetCardinality", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *skizzeClient) Query(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*QueryReply, error) {
+	out := new(QueryReply)
+	err := grpc.Invoke(ctx, "/protobuf.Skizze/Query", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // Server API for Skizze service
 
 type SkizzeServer interface {
@@ -910,14 +2187,24 @@ type SkizzeServer interface {
 	CreateDomain(context.Context, *Domain) (*Domain, error)
 	DeleteDomain(context.Context, *Domain) (*Empty, error)
 	GetDomain(context.Context, *Domain) (*Domain, error)
+	QueryDomain(context.Context, *QueryDomainRequest) (*QueryDomainReply, error)
+	AddSketchToDomain(context.Context, *DomainSketchRequest) (*Domain, error)
+	RemoveSketchFromDomain(context.Context, *DomainSketchRequest) (*Domain, error)
 	CreateSketch(context.Context, *Sketch) (*Sketch, error)
 	DeleteSketch(context.Context, *Sketch) (*Empty, error)
 	GetSketch(context.Context, *Sketch) (*Sketch, error)
 	Add(context.Context, *AddRequest) (*AddReply, error)
+	AddStream(Skizze_AddStreamServer) error
+	Merge(context.Context, *MergeRequest) (*Empty, error)
+	Remove(context.Context, *RemoveRequest) (*RemoveReply, error)
 	GetMembership(context.Context, *GetRequest) (*GetMembershipReply, error)
 	GetFrequency(context.Context, *GetRequest) (*GetFrequencyReply, error)
 	GetCardinality(context.Context, *GetRequest) (*GetCardinalityReply, error)
 	GetRankings(context.Context, *GetRequest) (*GetRankingsReply, error)
+	GetQuantiles(context.Context, *GetRequest) (*GetQuantilesReply, error)
+	GetSimilarity(context.Context, *GetRequest) (*GetSimilarityReply, error)
+	GetSetCardinality(context.Context, *GetRequest) (*GetSetCardinalityReply, error)
+	Query(context.Context, *GetRequest) (*QueryReply, error)
 }
 
 func RegisterSkizzeServer(s *grpc.Server, srv SkizzeServer) {
@@ -1020,6 +2307,42 @@ func _Skizze_GetDomain_Handler(srv interface{}, ctx context.Context, dec func(in
 	return out, nil
 }
 
+func _Skizze_QueryDomain_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+	in := new(QueryDomainRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(SkizzeServer).QueryDomain(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Skizze_AddSketchToDomain_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+	in := new(DomainSketchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(SkizzeServer).AddSketchToDomain(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Skizze_RemoveSketchFromDomain_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+	in := new(DomainSketchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(SkizzeServer).RemoveSketchFromDomain(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func _Skizze_CreateSketch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
 	in := new(Sketch)
 	if err := dec(in); err != nil {
@@ -1068,6 +2391,56 @@ func _Skizze_Add_Handler(srv interface{}, ctx context.Context, dec func(interfac
 	return out, nil
 }
 
+func _Skizze_AddStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(SkizzeServer).AddStream(&skizzeAddStreamServer{stream})
+}
+
+type Skizze_AddStreamServer interface {
+	Send(*AddStreamReply) error
+	Recv() (*AddRequest, error)
+	grpc.ServerStream
+}
+
+type skizzeAddStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *skizzeAddStreamServer) Send(m *AddStreamReply) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *skizzeAddStreamServer) Recv() (*AddRequest, error) {
+	m := new(AddRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Skizze_Merge_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+	in := new(MergeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(SkizzeServer).Merge(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Skizze_Remove_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+	in := new(RemoveRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(SkizzeServer).Remove(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func _Skizze_GetMembership_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
 	in := new(GetRequest)
 	if err := dec(in); err != nil {
@@ -1116,6 +2489,54 @@ func _Skizze_GetRankings_Handler(srv interface{}, ctx context.Context, dec func(
 	return out, nil
 }
 
+func _Skizze_GetQuantiles_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+	in := new(GetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(SkizzeServer).GetQuantiles(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Skizze_GetSimilarity_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+	in := new(GetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(SkizzeServer).GetSimilarity(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Skizze_GetSetCardinality_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+	in := new(GetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(SkizzeServer).GetSetCardinality(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Skizze_Query_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+	in := new(GetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(SkizzeServer).Query(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 var _Skizze_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "protobuf.Skizze",
 	HandlerType: (*SkizzeServer)(nil),
@@ -1152,6 +2573,18 @@ var _Skizze_serviceDesc = grpc.ServiceDesc{
 			MethodName: "GetDomain",
 			Handler:    _Skizze_GetDomain_Handler,
 		},
+		{
+			MethodName: "QueryDomain",
+			Handler:    _Skizze_QueryDomain_Handler,
+		},
+		{
+			MethodName: "AddSketchToDomain",
+			Handler:    _Skizze_AddSketchToDomain_Handler,
+		},
+		{
+			MethodName: "RemoveSketchFromDomain",
+			Handler:    _Skizze_RemoveSketchFromDomain_Handler,
+		},
 		{
 			MethodName: "CreateSketch",
 			Handler:    _Skizze_CreateSketch_Handler,
@@ -1168,6 +2601,14 @@ var _Skizze_serviceDesc = grpc.ServiceDesc{
 			MethodName: "Add",
 			Handler:    _Skizze_Add_Handler,
 		},
+		{
+			MethodName: "Merge",
+			Handler:    _Skizze_Merge_Handler,
+		},
+		{
+			MethodName: "Remove",
+			Handler:    _Skizze_Remove_Handler,
+		},
 		{
 			MethodName: "GetMembership",
 			Handler:    _Skizze_GetMembership_Handler,
@@ -1184,75 +2625,172 @@ var _Skizze_serviceDesc = grpc.ServiceDesc{
 			MethodName: "GetRankings",
 			Handler:    _Skizze_GetRankings_Handler,
 		},
+		{
+			MethodName: "GetQuantiles",
+			Handler:    _Skizze_GetQuantiles_Handler,
+		},
+		{
+			MethodName: "GetSimilarity",
+			Handler:    _Skizze_GetSimilarity_Handler,
+		},
+		{
+			MethodName: "GetSetCardinality",
+			Handler:    _Skizze_GetSetCardinality_Handler,
+		},
+		{
+			MethodName: "Query",
+			Handler:    _Skizze_Query_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "AddStream",
+			Handler:       _Skizze_AddStream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
 	},
-	Streams: []grpc.StreamDesc{},
 }
 
 var fileDescriptor0 = []byte{
-	// 1037 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0xb4, 0x56, 0xed, 0x72, 0xdb, 0x44,
-	0x14, 0xad, 0x3f, 0xe2, 0x8f, 0xab, 0xd4, 0x71, 0x37, 0x29, 0x18, 0xb5, 0x1d, 0x3a, 0x0b, 0xc3,
-	0x78, 0x02, 0x93, 0x50, 0x37, 0x81, 0x81, 0xe9, 0x30, 0x63, 0x1c, 0x3b, 0x38, 0xc4, 0x21, 0xac,
-	0xc9, 0x6f, 0x46, 0xb5, 0x37, 0x8d, 0x26, 0x92, 0x6d, 0xb4, 0x32, 0xd3, 0xe4, 0x09, 0x78, 0x1b,
-	0x9e, 0x86, 0xf7, 0x61, 0x3f, 0x24, 0xed, 0xae, 0x6c, 0xd3, 0xc9, 0x0f, 0xfe, 0x69, 0xef, 0xde,
-	0x73, 0xee, 0xd5, 0xd9, 0xbd, 0x47, 0x82, 0xcf, 0x58, 0x34, 0x39, 0x9c, 0x7a, 0xb1, 0x17, 0xce,
-	0xa7, 0x34, 0x38, 0x5c, 0x44, 0xf3, 0x78, 0xfe, 0x76, 0x79, 0x7d, 0xc8, 0x6e, 0xfd, 0xfb, 0x7b,
-	0x7a, 0x20, 0xd7, 0xa8, 0x96, 0x86, 0x71, 0x15, 0xb6, 0xfa, 0xe1, 0x22, 0xbe, 0xc3, 0x01, 0x34,
-	0xc7, 0xb7, 0x34, 0x9e, 0xdc, 0x5c, 0x46, 0xf3, 0x05, 0x8d, 0x62, 0x9f, 0x32, 0xf4, 0x05, 0x34,
-	0x42, 0xef, 0xfd, 0xd5, 0xcc, 0xff, 0x63, 0x49, 0x87, 0x31, 0x0d, 0x59, 0xab, 0xf0, 0xb2, 0xd0,
-	0x2e, 0x91, 0x5c, 0x14, 0x3d, 0x87, 0x3a, 0x8d, 0xa2, 0x79, 0x44, 0xbc, 0x98, 0xb6, 0x8a, 0x3c,
-	0xa5, 0x48, 0x74, 0x00, 0x21, 0x28, 0x33, 0xff, 0x9e, 0xb6, 0x4a, 0x12, 0x2b, 0x9f, 0xf1, 0x08,
-	0x1c, 0x55, 0x6d, 0x1c, 0x8b, 0x14, 0x17, 0x6a, 0xd7, 0x7e, 0x10, 0x48, 0x7c, 0x41, 0xe2, 0xb3,
-	0x35, 0xc2, 0xb0, 0x1d, 0x78, 0x2c, 0x1e, 0xcf, 0xbc, 0x05, 0xbb, 0x99, 0xc7, 0x92, 0xbf, 0x44,
-	0xac, 0x18, 0x3e, 0x83, 0xca, 0xc9, 0x3c, 0xf4, 0xfc, 0x99, 0x28, 0x36, 0xf3, 0x42, 0xc1, 0x52,
-	0x6c, 0xd7, 0x89, 0x7c, 0x46, 0x5f, 0x41, 0x8d, 0xc9, 0x62, 0x94, 0x71, 0x74, 0xa9, 0xed, 0x74,
-	0x9a, 0x07, 0xa9, 0x00, 0x07, 0xaa, 0x0d, 0x92, 0x65, 0xe0, 0xbf, 0x0b, 0x50, 0x51, 0xc1, 0xb5,
-	0x64, 0x6d, 0x28, 0xc7, 0x77, 0x0b, 0xf1, 0x9a, 0xc5, 0x76, 0xa3, 0xb3, 0x97, 0x27, 0xfa, 0x8d,
-	0xef, 0x11, 0x99, 0x81, 0xbe, 0x07, 0x58, 0x64, 0x5a, 0xca, 0xb7, 0x77, 0x3a, 0x6e, 0x3e, 0x5f,
-	0xab, 0x4d, 0x8c, 0x6c, 0xf4, 0x25, 0x6c, 0x31, 0xa1, 0x4c, 0xab, 0x2c, 0x61, 0x4f, 0xf3, 0x30,
-	0x29, 0x1b, 0x51, 0x39, 0xf8, 0x07, 0x80, 0x11, 0x0d, 0xdf, 0xd2, 0x88, 0xdd, 0xf8, 0x0b, 0xb4,
-	0x07, 0x5b, 0x7f, 0x7a, 0xc1, 0x32, 0xed, 0x5a, 0x2d, 0x84, 0xc2, 0x3e, 0x53, 0x59, 0xb2, 0xf5,
-	0x1a, 0xc9, 0xd6, 0xf8, 0x5b, 0xa8, 0x0f, 0x22, 0xca, 0x4f, 0x73, 0x36, 0xb9, 0xdb, 0x00, 0xe7,
-	0xd1, 0xc9, 0x7c, 0x39, 0x8b, 0x25, 0xb6, 0x44, 0xd4, 0x02, 0x77, 0xa0, 0x4c, 0xbc, 0xd9, 0xed,
-	0x83, 0x30, 0x1f, 0xc3, 0xd3, 0x5e, 0x44, 0x79, 0xdb, 0xe9, 0xe1, 0x11, 0x51, 0x99, 0xc5, 0x38,
-	0x84, 0xdd, 0xfc, 0xc6, 0x22, 0xb8, 0x43, 0x5f, 0x43, 0x45, 0xbc, 0xe5, 0x92, 0x49, 0xf2, 0x46,
-	0xa7, 0x65, 0x48, 0x91, 0x24, 0x8e, 0xe5, 0x3e, 0x49, 0xf2, 0xd0, 0xe7, 0xf0, 0x58, 0x3d, 0x8d,
-	0x28, 0x63, 0xde, 0x3b, 0x75, 0x23, 0xeb, 0xc4, 0x0e, 0xe2, 0x3d, 0x40, 0xa7, 0x34, 0xce, 0x37,
-	0xf1, 0x57, 0x01, 0x9a, 0x56, 0xf8, 0x7f, 0x6c, 0x41, 0x8c, 0x4d, 0xec, 0x87, 0xbc, 0xac, 0x17,
-	0x2e, 0x92, 0xe9, 0xd0, 0x01, 0x7e, 0x2a, 0xce, 0xb9, 0xcf, 0xd2, 0xce, 0xb2, 0x7b, 0x57, 0xf8,
-	0xd0, 0xbd, 0xc3, 0xdf, 0x41, 0x5d, 0x01, 0x45, 0xef, 0xe6, 0xdd, 0x2f, 0x7c, 0xf0, 0xee, 0xb7,
-	0xa1, 0x29, 0xa0, 0x6a, 0x96, 0x98, 0x62, 0xe0, 0xc7, 0x28, 0x2e, 0xbe, 0x82, 0xf3, 0xc3, 0x95,
-	0x0b, 0xfc, 0x1e, 0xa0, 0x3b, 0x9d, 0xea, 0xe6, 0x2a, 0x53, 0x89, 0x91, 0xd3, 0x6b, 0xd5, 0x50,
-	0x5c, 0x24, 0xd9, 0x17, 0x99, 0xaa, 0x9a, 0x94, 0x64, 0x5d, 0x37, 0xc9, 0x3e, 0xfa, 0x08, 0x2a,
-	0xf2, 0x1e, 0x89, 0xd1, 0x11, 0x85, 0x93, 0x15, 0x06, 0xa8, 0xc9, 0xca, 0xbc, 0x37, 0x4c, 0x00,
-	0xf8, 0x69, 0xa5, 0x5d, 0x3c, 0xe8, 0x5d, 0x0d, 0xfe, 0xa2, 0xc5, 0x7f, 0x06, 0x4d, 0x3d, 0x4d,
-	0x84, 0xb2, 0x65, 0x10, 0xa3, 0x6f, 0xc0, 0x09, 0xb3, 0x58, 0x4a, 0x6e, 0x9c, 0x81, 0x01, 0x30,
-	0x13, 0xf1, 0x4f, 0xb0, 0x93, 0x4d, 0x56, 0x42, 0x75, 0x0c, 0xce, 0x75, 0x12, 0xf2, 0x33, 0x3f,
-	0xda, 0xd5, 0x54, 0x3a, 0xdf, 0xcc, 0xc3, 0xc7, 0xf0, 0xa4, 0xe7, 0x45, 0x53, 0x7f, 0xe6, 0x05,
-	0x7e, 0x9c, 0x72, 0xbd, 0x04, 0x67, 0xa2, 0x83, 0xf2, 0x6a, 0x94, 0x88, 0x19, 0xc2, 0x6f, 0xa0,
-	0x21, 0x26, 0xd4, 0x9f, 0xbd, 0x63, 0x09, 0x66, 0x1f, 0x6a, 0x51, 0x12, 0x49, 0xde, 0xa3, 0xa1,
-	0x8b, 0x8b, 0x5c, 0x92, 0xed, 0x73, 0x29, 0xc4, 0x8c, 0x98, 0x6a, 0x88, 0x0b, 0x71, 0x04, 0xd5,
-	0x48, 0x72, 0xa5, 0x04, 0xee, 0x5a, 0x21, 0x64, 0x0a, 0x49, 0x53, 0xb9, 0x14, 0x4f, 0x38, 0x97,
-	0xa1, 0x86, 0xa0, 0x7a, 0x9d, 0xa7, 0xfa, 0x64, 0x9d, 0x10, 0x39, 0xa6, 0x73, 0xd8, 0xe5, 0x4c,
-	0x96, 0x1a, 0x82, 0xeb, 0x38, 0xcf, 0xf5, 0x4c, 0x73, 0xad, 0x48, 0xa7, 0xd9, 0x06, 0x72, 0xe0,
-	0xb5, 0x48, 0x82, 0xaa, 0x93, 0xa7, 0x6a, 0xd9, 0x12, 0x69, 0x39, 0x33, 0x9e, 0xfd, 0x23, 0x00,
-	0x3d, 0x89, 0xa8, 0x06, 0xe5, 0x51, 0x7f, 0xf4, 0x63, 0xb3, 0x20, 0x9e, 0x06, 0xa4, 0xff, 0x6b,
-	0xb3, 0x28, 0x9e, 0x48, 0xf7, 0xe2, 0xe7, 0x66, 0x49, 0x3c, 0xf5, 0xba, 0xe4, 0xa4, 0x59, 0xde,
-	0x3f, 0x83, 0x86, 0x6d, 0x21, 0xc8, 0x81, 0xea, 0x65, 0xff, 0xe2, 0x64, 0x78, 0x71, 0xca, 0xc1,
-	0x3b, 0xe0, 0x0c, 0x2f, 0x7e, 0xbf, 0x24, 0xbf, 0x9c, 0x92, 0xfe, 0x78, 0xcc, 0x39, 0x1a, 0xbc,
-	0xca, 0x55, 0xaf, 0xc7, 0x17, 0x83, 0xab, 0x73, 0xce, 0x04, 0x50, 0x19, 0x74, 0x87, 0xe7, 0x7d,
-	0xce, 0xd5, 0xf9, 0xa7, 0x2a, 0x3e, 0x5c, 0xe2, 0x2b, 0x8f, 0x08, 0x34, 0x6c, 0x2f, 0x45, 0x9f,
-	0x1a, 0x62, 0xac, 0xb3, 0x5f, 0xf7, 0xc5, 0xe6, 0x04, 0x31, 0x69, 0x8f, 0xd0, 0x10, 0x1c, 0xc3,
-	0x19, 0xd1, 0x73, 0x9d, 0xbf, 0xea, 0xa3, 0xae, 0xbb, 0x61, 0x57, 0x51, 0x1d, 0x41, 0x59, 0xd8,
-	0x0c, 0x32, 0x3e, 0x6b, 0x86, 0xd5, 0xb9, 0xbb, 0xf9, 0xb0, 0x42, 0xbd, 0x82, 0xaa, 0x58, 0x76,
-	0x83, 0x00, 0xed, 0xe8, 0x0c, 0xf9, 0xf7, 0xb2, 0x09, 0xf2, 0x46, 0x79, 0x68, 0xe2, 0x67, 0xab,
-	0x30, 0xd7, 0x86, 0x99, 0xbe, 0x27, 0xdb, 0xdc, 0x56, 0x52, 0x24, 0xff, 0x16, 0x2b, 0xae, 0xe6,
-	0xae, 0x44, 0x38, 0xea, 0x35, 0x6c, 0x9f, 0xd0, 0x80, 0xfe, 0x07, 0x2a, 0xdf, 0x86, 0x7c, 0xb7,
-	0x3a, 0xd7, 0xe9, 0x41, 0x75, 0xb2, 0xee, 0x92, 0x9f, 0x95, 0x15, 0xaf, 0x73, 0x57, 0x22, 0x66,
-	0x77, 0x1b, 0x51, 0x1b, 0xbb, 0x7b, 0x50, 0x9d, 0x57, 0x50, 0xe2, 0x2e, 0x8d, 0x0c, 0x8f, 0xd4,
-	0x9f, 0x0b, 0x17, 0xe5, 0xa2, 0x4a, 0xee, 0x3e, 0x3c, 0xb6, 0xdc, 0xc6, 0x04, 0x6b, 0x97, 0x77,
-	0xed, 0x8b, 0x97, 0x33, 0x27, 0x4e, 0xd3, 0x83, 0x6d, 0xd3, 0x68, 0x36, 0xb0, 0x3c, 0xb3, 0xa2,
-	0xb6, 0x2d, 0x71, 0x92, 0x53, 0x68, 0xd8, 0x1e, 0xb3, 0x81, 0xe6, 0x85, 0x15, 0xcd, 0x7b, 0x12,
-	0x27, 0xea, 0xca, 0xa9, 0x49, 0x4d, 0x63, 0x03, 0x8b, 0x3d, 0x2d, 0x96, 0x17, 0xe1, 0x47, 0xff,
-	0x06, 0x00, 0x00, 0xff, 0xff, 0xc7, 0x64, 0x21, 0x77, 0xd2, 0x0b, 0x00, 0x00,
+	// 2210 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0xb4, 0x58, 0xcd, 0x72, 0xdb, 0xc8,
+	0x11, 0x16, 0x08, 0xfe, 0x36, 0x65, 0x9a, 0x1a, 0xc9, 0x5e, 0x84, 0x6b, 0x57, 0x58, 0x93, 0xad,
+	0x14, 0xcb, 0xd9, 0xd8, 0x5e, 0xd9, 0xde, 0x2d, 0xef, 0xca, 0xd9, 0xe2, 0x4a, 0xd4, 0x8f, 0xd7,
+	0x52, 0xa4, 0x61, 0x9c, 0xe4, 0x94, 0x14, 0x4c, 0x8c, 0x24, 0x44, 0xf8, 0xa1, 0x31, 0xa0, 0x6d,
+	0xf9, 0x9c, 0x4a, 0xe5, 0x94, 0x27, 0xc8, 0x2d, 0x0f, 0x90, 0x4b, 0xaa, 0x72, 0xcd, 0x1b, 0xe4,
+	0x98, 0x27, 0xc8, 0x4b, 0xe4, 0x94, 0x9a, 0x1f, 0x00, 0x03, 0x90, 0xa0, 0xa4, 0x54, 0xe5, 0x86,
+	0x6e, 0x74, 0xf7, 0x74, 0xf7, 0x7c, 0xdd, 0xd3, 0x33, 0xf0, 0x23, 0x16, 0x4d, 0x1e, 0x39, 0x76,
+	0x6c, 0xfb, 0xa1, 0x43, 0xbd, 0x47, 0xd3, 0x28, 0x8c, 0xc3, 0x37, 0xb3, 0xd3, 0x47, 0xec, 0xc2,
+	0xfd, 0xf8, 0x91, 0x3e, 0x14, 0x34, 0x6a, 0x26, 0x6c, 0xdc, 0x80, 0xda, 0xc8, 0x9f, 0xc6, 0x97,
+	0xf8, 0xcf, 0x15, 0xe8, 0x8e, 0x2f, 0x68, 0x3c, 0x39, 0x3f, 0x8e, 0xc2, 0x29, 0x8d, 0x62, 0x97,
+	0x32, 0xf4, 0x63, 0xe8, 0xf8, 0xf6, 0x87, 0xd7, 0x81, 0xfb, 0x76, 0x46, 0x0f, 0x62, 0xea, 0x33,
+	0xcb, 0xe8, 0x1b, 0x03, 0x93, 0x14, 0xb8, 0xe8, 0x1e, 0xb4, 0x68, 0x14, 0x85, 0x11, 0xb1, 0x63,
+	0x6a, 0x55, 0xfa, 0xc6, 0xa0, 0x42, 0x32, 0x06, 0x42, 0x50, 0x65, 0xee, 0x47, 0x6a, 0x99, 0x42,
+	0x57, 0x7c, 0x73, 0xcb, 0x6f, 0x66, 0x93, 0x0b, 0x1a, 0xef, 0xcc, 0x22, 0x3b, 0x76, 0xc3, 0xc0,
+	0xaa, 0x4a, 0xcb, 0x79, 0x2e, 0xea, 0x43, 0x5b, 0x72, 0xb6, 0xc3, 0x59, 0x10, 0x5b, 0x35, 0x21,
+	0xa4, 0xb3, 0xd0, 0x67, 0x70, 0x2b, 0x3e, 0x8f, 0x28, 0x3b, 0x0f, 0x3d, 0x67, 0xcc, 0x97, 0xa9,
+	0x0b, 0x99, 0x3c, 0x93, 0xdb, 0x99, 0x84, 0xfe, 0x34, 0xa2, 0x8c, 0xf1, 0xc5, 0x1a, 0xd2, 0x8e,
+	0xc6, 0xe2, 0x31, 0x38, 0xd4, 0xa3, 0xb1, 0xfd, 0xc6, 0xa3, 0x56, 0xb3, 0x6f, 0x0c, 0x9a, 0x24,
+	0x63, 0xe0, 0xbf, 0x1b, 0xd0, 0x96, 0xe9, 0x19, 0xc7, 0x3c, 0xa6, 0x1e, 0x34, 0x4f, 0x5d, 0xcf,
+	0x13, 0x01, 0x1b, 0x22, 0xe0, 0x94, 0x46, 0x18, 0x56, 0x3d, 0x9b, 0xc5, 0xe3, 0xc0, 0x9e, 0xb2,
+	0xf3, 0x30, 0x16, 0x09, 0x31, 0x49, 0x8e, 0x87, 0x36, 0xa0, 0x46, 0x3f, 0xd8, 0x93, 0x58, 0x24,
+	0xa5, 0x49, 0x24, 0xc1, 0xbd, 0x7c, 0x67, 0x7b, 0x33, 0xca, 0x86, 0x8e, 0x43, 0x1d, 0x95, 0x12,
+	0x9d, 0x85, 0xee, 0x42, 0xdd, 0xa7, 0x7e, 0x18, 0x5d, 0xaa, 0x54, 0x28, 0x0a, 0x59, 0xd0, 0x98,
+	0x44, 0xd4, 0x8e, 0xa9, 0xa3, 0xe2, 0x4f, 0x48, 0xfc, 0x12, 0xea, 0x3b, 0xa1, 0x6f, 0xbb, 0x01,
+	0xdf, 0x87, 0xc0, 0xf6, 0xb9, 0xbf, 0x95, 0x41, 0x8b, 0x88, 0x6f, 0xf4, 0x39, 0x34, 0x99, 0x08,
+	0x8b, 0x32, 0xab, 0xd2, 0x37, 0x07, 0xed, 0xcd, 0xee, 0xc3, 0x04, 0x1c, 0x0f, 0x65, 0xc0, 0x24,
+	0x95, 0xc0, 0x7f, 0x35, 0xa0, 0x2e, 0x99, 0x0b, 0x8d, 0x0d, 0xa0, 0x1a, 0x5f, 0x4e, 0x39, 0x02,
+	0x2a, 0x83, 0xce, 0xe6, 0x46, 0xd1, 0xd0, 0x2f, 0x2e, 0xa7, 0x94, 0x08, 0x09, 0xf4, 0x35, 0xc0,
+	0x34, 0x85, 0x99, 0xc8, 0x41, 0x7b, 0xb3, 0x57, 0x94, 0xcf, 0x80, 0x48, 0x34, 0x69, 0xf4, 0x13,
+	0xa8, 0x31, 0xbe, 0x07, 0x22, 0x3d, 0xed, 0xcd, 0x3b, 0x45, 0x35, 0xb1, 0x41, 0x44, 0xca, 0xe0,
+	0x9f, 0x01, 0x1c, 0x52, 0xff, 0x0d, 0x8d, 0xd8, 0xb9, 0x3b, 0xe5, 0x59, 0x17, 0xc9, 0x54, 0x5e,
+	0x4b, 0x82, 0xef, 0xa5, 0xcb, 0xa4, 0x94, 0x70, 0xbd, 0x49, 0x52, 0x1a, 0x4f, 0xa0, 0xb5, 0x1b,
+	0xd1, 0xb7, 0x33, 0x1a, 0x4c, 0x2e, 0x4b, 0xd4, 0x37, 0xa0, 0x36, 0x11, 0xe0, 0xe4, 0xba, 0x26,
+	0x91, 0x04, 0xe7, 0x7a, 0xe1, 0x7b, 0x1a, 0x29, 0xd4, 0x4b, 0x82, 0x73, 0x67, 0xd3, 0x29, 0x8d,
+	0xd4, 0xd6, 0x4a, 0x02, 0xef, 0x43, 0x95, 0xd8, 0xc1, 0xc5, 0x4d, 0xed, 0x8b, 0x0a, 0x4b, 0xec,
+	0x0b, 0x02, 0x6f, 0x41, 0xf3, 0x64, 0x66, 0x07, 0xb1, 0xeb, 0x89, 0xb0, 0xde, 0xaa, 0x6f, 0x61,
+	0xd0, 0x20, 0x29, 0x9d, 0xad, 0x54, 0x11, 0x3f, 0x24, 0xc1, 0xb5, 0xb7, 0x77, 0x76, 0x8f, 0x43,
+	0x57, 0xda, 0xcf, 0x7c, 0x31, 0xb4, 0x54, 0x9d, 0x46, 0xf6, 0x44, 0x14, 0xac, 0x54, 0x4d, 0x69,
+	0xfc, 0x09, 0xdc, 0xd9, 0x16, 0x98, 0x4b, 0x40, 0x4e, 0x78, 0xde, 0x58, 0x8c, 0x7d, 0x58, 0x2f,
+	0xfe, 0x98, 0x7a, 0x97, 0xe8, 0x31, 0xd4, 0xf9, 0x1e, 0xcd, 0x98, 0x58, 0xa2, 0xb3, 0x69, 0x69,
+	0x1b, 0xa9, 0x04, 0xc7, 0xe2, 0x3f, 0x51, 0x72, 0xbc, 0xd4, 0xe5, 0xd7, 0x21, 0x65, 0xcc, 0x3e,
+	0x93, 0xad, 0xa6, 0x45, 0xf2, 0x4c, 0xbc, 0x01, 0x68, 0x8f, 0xc6, 0x45, 0x27, 0xfe, 0x68, 0x40,
+	0x37, 0xc7, 0xfe, 0x3f, 0xba, 0xc0, 0x7b, 0x49, 0xec, 0xfa, 0x94, 0xc5, 0xb6, 0x3f, 0x55, 0x1b,
+	0x94, 0x31, 0x70, 0x1f, 0x3a, 0x89, 0xf5, 0x43, 0x3b, 0xba, 0xa0, 0x11, 0xea, 0x40, 0xc5, 0x75,
+	0x84, 0x0f, 0x26, 0xa9, 0xb8, 0x0e, 0xfe, 0x0a, 0xda, 0xaf, 0x5c, 0x96, 0xf8, 0x9e, 0xd6, 0x95,
+	0x71, 0x55, 0x5d, 0xe1, 0xe7, 0xd0, 0x92, 0x8a, 0x3c, 0x3a, 0xbd, 0xb6, 0x8d, 0x2b, 0x6b, 0x7b,
+	0x00, 0x5d, 0xae, 0x2a, 0x7b, 0x05, 0x93, 0x16, 0x36, 0xa0, 0xc6, 0x0b, 0x5b, 0xaa, 0xb7, 0x88,
+	0x24, 0x70, 0x00, 0xab, 0x87, 0x34, 0x3a, 0xa3, 0x89, 0x7b, 0x9b, 0xd0, 0x76, 0x28, 0x8b, 0xdd,
+	0x40, 0x36, 0x72, 0xee, 0xe5, 0xa2, 0xa5, 0x74, 0x21, 0xf4, 0x00, 0x1a, 0x2c, 0x9c, 0x45, 0x93,
+	0x25, 0x6d, 0x27, 0x11, 0xc0, 0xdf, 0xc0, 0xad, 0x5f, 0x51, 0xf7, 0xec, 0x3c, 0xa6, 0xce, 0x2f,
+	0x93, 0x8a, 0xb8, 0x6e, 0x9d, 0xe0, 0x7f, 0x19, 0x00, 0x43, 0xc7, 0xc9, 0x52, 0x59, 0x77, 0x44,
+	0x84, 0xa2, 0x6b, 0xe7, 0x96, 0x95, 0x91, 0x13, 0xf5, 0x9f, 0x4b, 0xca, 0xdc, 0x58, 0x95, 0xa2,
+	0xa4, 0x72, 0x50, 0xfd, 0xe7, 0x3d, 0x59, 0xb6, 0x68, 0xcb, 0x14, 0x69, 0x52, 0x54, 0x1e, 0x05,
+	0xd5, 0x02, 0x0a, 0xd0, 0xb7, 0xd0, 0x79, 0xaf, 0x47, 0xc5, 0xac, 0x9a, 0x48, 0xc4, 0x27, 0xd9,
+	0x3a, 0xb9, 0xa8, 0x49, 0x41, 0x1c, 0xbf, 0x80, 0xd6, 0x41, 0xc0, 0x78, 0x53, 0x0c, 0x83, 0x25,
+	0x9d, 0x4d, 0x88, 0x50, 0x27, 0xed, 0x6c, 0x8a, 0xc6, 0x7f, 0x33, 0xa0, 0x25, 0x12, 0xc3, 0x66,
+	0x5e, 0xac, 0x45, 0x5b, 0xb6, 0x7d, 0x49, 0xb4, 0x16, 0x34, 0xd8, 0x6c, 0x32, 0xa1, 0x8c, 0x29,
+	0x93, 0x09, 0x99, 0x6f, 0x49, 0x2d, 0xd5, 0x92, 0xd0, 0x13, 0x00, 0x37, 0x71, 0x93, 0x59, 0x55,
+	0x11, 0xe3, 0x7a, 0x66, 0x3d, 0x0d, 0x81, 0x68, 0x62, 0xe2, 0x38, 0x3b, 0xb7, 0x83, 0x33, 0xea,
+	0x88, 0x73, 0xae, 0x49, 0x12, 0x12, 0x3f, 0x87, 0xa6, 0xf0, 0x9a, 0xc3, 0xf3, 0xa7, 0xd0, 0x88,
+	0x84, 0xfb, 0x09, 0xbe, 0x35, 0xbb, 0x69, 0x68, 0x24, 0x91, 0xc1, 0xbf, 0x86, 0xce, 0xd0, 0x71,
+	0xc6, 0x71, 0x44, 0x6d, 0x7f, 0x24, 0x7c, 0xb3, 0xb8, 0x01, 0x01, 0x0c, 0x55, 0x7c, 0x09, 0xc9,
+	0xf7, 0x34, 0xb6, 0xa3, 0x33, 0x2a, 0xd1, 0xd4, 0x22, 0x8a, 0xd2, 0x63, 0xac, 0xa4, 0x31, 0xe2,
+	0x77, 0x9a, 0x65, 0xe9, 0x5a, 0x0f, 0x9a, 0xca, 0x14, 0x53, 0xa6, 0x53, 0x5a, 0xc3, 0x8b, 0x44,
+	0xaa, 0xa2, 0x78, 0x37, 0x12, 0xe6, 0x24, 0x8e, 0xda, 0x7a, 0x37, 0xca, 0xfb, 0x4d, 0x94, 0x1c,
+	0x3e, 0x81, 0x5b, 0x84, 0xfa, 0xe1, 0x3b, 0xaa, 0xc1, 0xfb, 0x9a, 0xdb, 0xa8, 0x3b, 0xa1, 0x81,
+	0x16, 0x3f, 0x87, 0x86, 0x30, 0x69, 0x7b, 0x25, 0x98, 0x12, 0x39, 0xe3, 0x6b, 0x26, 0x90, 0x4a,
+	0x48, 0xbc, 0x05, 0xed, 0xc4, 0x1b, 0xb9, 0x3b, 0xcd, 0x48, 0x5a, 0x4a, 0xb6, 0x67, 0x2d, 0xf3,
+	0x46, 0xad, 0x41, 0x52, 0x11, 0xfc, 0x0f, 0x03, 0x60, 0x8f, 0xa6, 0x3d, 0xef, 0x46, 0xcd, 0xab,
+	0x2c, 0x1a, 0xee, 0xac, 0x9c, 0x15, 0x99, 0x6a, 0xc3, 0x09, 0xc9, 0xe7, 0x97, 0xd3, 0x28, 0xf4,
+	0x55, 0x5d, 0x8a, 0x6f, 0xde, 0x86, 0xe3, 0x50, 0x0d, 0x56, 0x95, 0x38, 0xe4, 0x05, 0x9c, 0x9c,
+	0x98, 0xcc, 0xaa, 0xf7, 0xcd, 0x81, 0x41, 0x32, 0x06, 0xea, 0x82, 0x39, 0x71, 0x4e, 0xad, 0x86,
+	0xe0, 0xf3, 0x4f, 0xfc, 0x27, 0x03, 0xba, 0xd9, 0xb4, 0xa1, 0x2a, 0xeb, 0x4b, 0x68, 0xfb, 0x29,
+	0x2f, 0x89, 0x45, 0xeb, 0xe1, 0x9a, 0x82, 0x2e, 0x88, 0x3e, 0x87, 0xb5, 0x53, 0xdb, 0x63, 0xf4,
+	0x38, 0x64, 0x6e, 0xec, 0xbe, 0xa3, 0xe9, 0x6c, 0x6d, 0x90, 0xf9, 0x1f, 0x8b, 0xe7, 0x49, 0xfc,
+	0x1b, 0xb8, 0x9d, 0x4e, 0x2f, 0xca, 0x9d, 0x67, 0xd0, 0x3e, 0x55, 0x2c, 0x37, 0x6d, 0xbe, 0x5a,
+	0xdd, 0x64, 0xf2, 0xba, 0x5c, 0x89, 0xfd, 0xf7, 0xb0, 0xb6, 0x6d, 0x47, 0x8e, 0x1b, 0xd8, 0x9e,
+	0x1b, 0x27, 0x2b, 0xf0, 0x51, 0x3b, 0x63, 0x2a, 0xf4, 0xeb, 0xac, 0x6c, 0x36, 0xaa, 0x2c, 0x9c,
+	0x8d, 0x4c, 0x6d, 0x36, 0xca, 0x16, 0xae, 0xea, 0x0b, 0x6f, 0x41, 0x87, 0x4f, 0x4c, 0x6e, 0x70,
+	0xc6, 0xd4, 0xaa, 0x0f, 0xa0, 0x19, 0x29, 0x8e, 0xca, 0x71, 0x47, 0x43, 0x9b, 0x1d, 0x5c, 0x90,
+	0xf4, 0x3f, 0xfe, 0x8b, 0x01, 0xb7, 0x93, 0x31, 0x29, 0xd1, 0x4f, 0x4f, 0x0f, 0x43, 0x9f, 0xb2,
+	0xba, 0x60, 0xfa, 0x6e, 0xa0, 0xd2, 0xce, 0x3f, 0x05, 0xc7, 0xfe, 0x60, 0x99, 0x8a, 0x63, 0x7f,
+	0x40, 0x8f, 0x75, 0x94, 0xc8, 0xfe, 0x86, 0xb2, 0xa5, 0x93, 0x75, 0x74, 0xe4, 0x7c, 0x26, 0x91,
+	0x53, 0x2b, 0xca, 0x26, 0xc3, 0x97, 0x44, 0xd3, 0x4b, 0x31, 0xc7, 0xe8, 0x78, 0xe2, 0x55, 0xf5,
+	0xb4, 0xd8, 0xf3, 0x7a, 0x0b, 0xa1, 0x54, 0x68, 0x7d, 0xfb, 0xb0, 0xb6, 0x47, 0x63, 0x0d, 0x0b,
+	0xdc, 0xd4, 0x93, 0xa2, 0xa9, 0x1f, 0x2c, 0x82, 0x41, 0xc1, 0xd2, 0x2b, 0x58, 0xdf, 0xa3, 0x71,
+	0x6e, 0xd7, 0xb9, 0xad, 0x67, 0x45, 0x5b, 0x9f, 0x6a, 0x61, 0x15, 0x21, 0x92, 0x59, 0xdb, 0x15,
+	0x43, 0x59, 0xb6, 0x95, 0xdc, 0xd4, 0x66, 0xd1, 0x94, 0x95, 0xdf, 0xc8, 0x6c, 0xd3, 0x8b, 0xf1,
+	0x69, 0x7b, 0x7a, 0x55, 0x7c, 0x85, 0xed, 0xcf, 0x2c, 0x1d, 0xc2, 0xba, 0xe6, 0xef, 0x88, 0xc5,
+	0xae, 0xcf, 0xeb, 0xeb, 0x5a, 0xa0, 0x96, 0x27, 0x83, 0x1a, 0x3f, 0x04, 0x81, 0xff, 0x69, 0xc0,
+	0x5d, 0x3e, 0x76, 0x2e, 0x48, 0xd9, 0x13, 0xa8, 0xcd, 0x82, 0x6c, 0x60, 0xba, 0xbf, 0x30, 0x61,
+	0x89, 0x03, 0x44, 0xca, 0xa2, 0x21, 0xac, 0xba, 0x41, 0x4c, 0x23, 0x46, 0xb3, 0x21, 0xfc, 0x4a,
+	0xdd, 0x9c, 0x0a, 0x7a, 0x01, 0xe0, 0xb8, 0xa7, 0xa7, 0x34, 0xa2, 0xc1, 0x84, 0x5a, 0xe6, 0x75,
+	0x0c, 0x68, 0x0a, 0xf8, 0x5c, 0x8e, 0xd7, 0xae, 0xef, 0x7a, 0x76, 0x94, 0x06, 0x63, 0x41, 0xe3,
+	0x77, 0xf6, 0x84, 0xe7, 0x43, 0x5d, 0x18, 0x12, 0x92, 0xdf, 0x86, 0xe7, 0x3c, 0x36, 0x0b, 0x2e,
+	0x6d, 0x24, 0xa9, 0x30, 0x65, 0xee, 0x04, 0x81, 0x7f, 0x6f, 0x42, 0xfb, 0x64, 0x46, 0xa3, 0xcb,
+	0x1b, 0xcf, 0x28, 0x5b, 0x00, 0x59, 0x2b, 0x55, 0xf3, 0xdb, 0x92, 0x3a, 0xd9, 0x5f, 0x21, 0x9a,
+	0x3c, 0x7a, 0x0e, 0xad, 0xa4, 0xf5, 0x5d, 0xaa, 0xbb, 0x69, 0x79, 0x65, 0xec, 0xaf, 0x90, 0x4c,
+	0x1a, 0x7d, 0x9b, 0x87, 0x89, 0xbc, 0xa1, 0x2e, 0x2b, 0x85, 0xfd, 0x95, 0x3c, 0x8a, 0xbe, 0xd4,
+	0xda, 0x58, 0xad, 0x6f, 0x2c, 0x43, 0xff, 0xfe, 0x4a, 0xd6, 0xd2, 0xb8, 0xcf, 0x59, 0x13, 0x6a,
+	0xf4, 0x8d, 0xa5, 0x68, 0xe7, 0x3e, 0xa7, 0xd2, 0x19, 0x70, 0xeb, 0xda, 0xd8, 0xf6, 0x5d, 0x13,
+	0xea, 0xb2, 0x24, 0xb0, 0x0b, 0xeb, 0x72, 0x34, 0x56, 0x49, 0x5e, 0x30, 0x49, 0x57, 0xae, 0x3d,
+	0x49, 0x2f, 0xdd, 0x37, 0xfc, 0x07, 0x03, 0x90, 0xd8, 0x71, 0x65, 0xe1, 0xc6, 0x4b, 0x95, 0xcd,
+	0x01, 0xb9, 0x93, 0xdc, 0x2c, 0x39, 0xc9, 0xab, 0xd9, 0x49, 0xfe, 0xef, 0x0a, 0x74, 0x73, 0x8e,
+	0x70, 0x8c, 0x5f, 0xdf, 0x8d, 0x17, 0x79, 0x18, 0x54, 0xae, 0x84, 0x41, 0x1e, 0x04, 0x4f, 0x35,
+	0x10, 0x98, 0xcb, 0x41, 0xa0, 0x41, 0xe0, 0x9b, 0xfc, 0xc9, 0x5e, 0xbd, 0x02, 0xb8, 0xf9, 0xf3,
+	0x7d, 0x2b, 0x3f, 0xa5, 0xd4, 0xae, 0x2a, 0x99, 0xfc, 0xac, 0xf2, 0x55, 0x7e, 0x50, 0x5a, 0x8e,
+	0x3e, 0x2d, 0xf3, 0xf8, 0x05, 0x80, 0xaa, 0x70, 0x9e, 0xe0, 0x47, 0xc5, 0x86, 0x7d, 0x47, 0x37,
+	0x92, 0x36, 0x82, 0xb4, 0x59, 0x3f, 0xd8, 0x07, 0xc8, 0xae, 0xc0, 0xa8, 0x09, 0xd5, 0xc3, 0xd1,
+	0xe1, 0x77, 0x5d, 0x83, 0x7f, 0xed, 0x92, 0xd1, 0x49, 0xb7, 0xc2, 0xbf, 0xc8, 0xf0, 0xe8, 0xfb,
+	0xae, 0xc9, 0xbf, 0xb6, 0x87, 0x64, 0xa7, 0x5b, 0xe5, 0x5f, 0x27, 0xaf, 0x87, 0x47, 0xdd, 0x1a,
+	0xff, 0x1a, 0x1f, 0x1c, 0x1e, 0x74, 0xeb, 0x0f, 0x5e, 0x42, 0x27, 0x7f, 0xe3, 0x47, 0x6d, 0x68,
+	0x1c, 0x8f, 0x8e, 0x76, 0x0e, 0x8e, 0xf6, 0xba, 0x06, 0xba, 0x0d, 0xed, 0x83, 0xa3, 0xdf, 0x1e,
+	0x93, 0x9f, 0xef, 0x91, 0xd1, 0x78, 0xdc, 0xad, 0xa0, 0x0e, 0xc0, 0xf8, 0xf5, 0xf6, 0xf6, 0x68,
+	0x3c, 0xde, 0x7d, 0xfd, 0xaa, 0x6b, 0x22, 0x80, 0xfa, 0xee, 0xf0, 0xe0, 0xd5, 0x68, 0xa7, 0x5b,
+	0xdd, 0xfc, 0xcf, 0x2a, 0x7f, 0x25, 0xe3, 0xcf, 0xad, 0x88, 0x40, 0x27, 0xff, 0xf4, 0x81, 0x7e,
+	0xa8, 0xa1, 0x60, 0xd1, 0x6b, 0x49, 0xef, 0x7e, 0xb9, 0xc0, 0xd4, 0xbb, 0xc4, 0x2b, 0xe8, 0x00,
+	0xda, 0xda, 0x43, 0x06, 0xba, 0x97, 0xc9, 0xcf, 0x3f, 0x7b, 0xf4, 0x7a, 0x25, 0x7f, 0xa5, 0xa9,
+	0xa7, 0x50, 0xe5, 0x77, 0x7e, 0xa4, 0xe5, 0x59, 0x7b, 0x77, 0xe8, 0xad, 0x17, 0xd9, 0x52, 0xeb,
+	0x0b, 0x68, 0x70, 0x72, 0xe8, 0x79, 0xe8, 0x76, 0x26, 0x21, 0x9e, 0x91, 0xcb, 0x54, 0xb6, 0xe4,
+	0x83, 0x86, 0x7a, 0x5c, 0x98, 0x57, 0xeb, 0xe5, 0xd5, 0xf4, 0x47, 0x08, 0xe1, 0xe6, 0xaa, 0x4c,
+	0x85, 0xe4, 0xa3, 0xb9, 0xc2, 0xeb, 0xcd, 0x71, 0xf0, 0x0a, 0x7a, 0x02, 0xab, 0x3b, 0xd4, 0xa3,
+	0x4b, 0xb4, 0x8a, 0x6e, 0x88, 0xd8, 0x5a, 0x7b, 0x34, 0xbe, 0xd1, 0x3a, 0x07, 0xea, 0x94, 0x52,
+	0x4a, 0xf7, 0x0a, 0x98, 0xcd, 0xb5, 0xb2, 0x5e, 0xaf, 0xe4, 0xaf, 0x0c, 0x74, 0x17, 0xd6, 0xf8,
+	0x4d, 0x4f, 0x42, 0x3a, 0x54, 0x06, 0xef, 0x17, 0xd7, 0xcc, 0xf5, 0xe1, 0x85, 0x2e, 0x7d, 0x0f,
+	0x77, 0xe5, 0x4d, 0x4c, 0x8a, 0xee, 0x46, 0xa1, 0xff, 0xbf, 0x1b, 0x4b, 0xb3, 0x2f, 0x45, 0xd1,
+	0x5c, 0xfb, 0xee, 0xcd, 0x71, 0xf4, 0xec, 0x97, 0x6a, 0x95, 0x66, 0xff, 0x46, 0xeb, 0x7c, 0x01,
+	0xe6, 0xd0, 0x71, 0xd0, 0x46, 0xe1, 0xe6, 0x2f, 0xc3, 0x41, 0x05, 0xae, 0xcc, 0xf2, 0x10, 0x5a,
+	0xe9, 0x7d, 0xba, 0x44, 0x71, 0xd1, 0xd5, 0x5b, 0xa9, 0x0f, 0x8c, 0xc7, 0x06, 0xda, 0x84, 0x9a,
+	0x78, 0x02, 0x43, 0x77, 0xf5, 0x16, 0x99, 0xbd, 0x89, 0x2d, 0x0a, 0xee, 0x6b, 0xa8, 0xcb, 0x4d,
+	0x41, 0x9f, 0x14, 0xee, 0xc1, 0xa9, 0xd6, 0x9d, 0xf9, 0x1f, 0xd2, 0xe5, 0x11, 0xdc, 0xca, 0xdd,
+	0x05, 0x74, 0xb7, 0xb3, 0x4b, 0x73, 0x2f, 0xdf, 0x0b, 0x0a, 0x57, 0x07, 0xbc, 0x82, 0xb6, 0x61,
+	0x55, 0xbf, 0x06, 0x94, 0x58, 0xf9, 0x34, 0xc7, 0xcd, 0x5f, 0x1a, 0xf0, 0x0a, 0xda, 0x83, 0x4e,
+	0xfe, 0x06, 0x50, 0x62, 0xe6, 0x7e, 0x8e, 0x5b, 0x1c, 0x7f, 0xc5, 0x3e, 0xb4, 0xb5, 0xe1, 0xbf,
+	0xc4, 0x4a, 0xbe, 0x81, 0xe5, 0x6e, 0x0a, 0x69, 0x40, 0x27, 0xd9, 0x2c, 0x73, 0x8d, 0x80, 0xf2,
+	0xb7, 0x84, 0x34, 0xb9, 0xd9, 0x44, 0x7b, 0xad, 0xe4, 0x16, 0x06, 0x60, 0xbc, 0x82, 0x0e, 0x61,
+	0x6d, 0x6e, 0xd2, 0x2f, 0x31, 0xd5, 0xcf, 0x9b, 0x5a, 0x98, 0x9d, 0x67, 0x50, 0x13, 0x1d, 0xa2,
+	0xc4, 0xc4, 0xc6, 0xdc, 0xd1, 0x28, 0xd4, 0xfe, 0x3b, 0x00, 0xda, 0x22, 0x42, 0x34, 0x00, 0x1c,
+	0x00, 0x00,
 }
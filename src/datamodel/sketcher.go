@@ -1,7 +1,17 @@
 package datamodel
 
+import pb "datamodel/protobuf"
+
 // Sketcher ...
 type Sketcher interface {
 	Add([][]byte) (bool, error)
+	// AddCounts adds each value of the map the number of times it maps to
+	AddCounts(map[string]uint) (bool, error)
 	Get(interface{}) (interface{}, error)
+	Marshal() ([]byte, error)
+	Unmarshal([]byte) error
+	Merge(Sketcher) error
+	// State fills in the fill rate, stage and memory footprint of the sketch,
+	// the number of values added to it is already set
+	State(*pb.SketchState)
 }
@@ -2,12 +2,14 @@ package manager
 
 import (
 	"fmt"
+	"strings"
 	"sync"
 
 	"github.com/gogo/protobuf/proto"
 
 	"datamodel"
 	pb "datamodel/protobuf"
+	"sketches"
 )
 
 type domainManager struct {
@@ -24,49 +26,106 @@ func newDomainManager(info *infoManager, sketches *sketchManager) *domainManager
 	}
 }
 
-func (m *domainManager) create(id string, infos map[string]*datamodel.Info) error {
+// create creates the domain id holding a sketch for each of infos, at most one
+// per type. The sketches are named after the domain.
+func (m *domainManager) create(id string, infos []*datamodel.Info) error {
 	if _, ok := m.domains[id]; ok {
 		return fmt.Errorf(`Domain with name "%s" already exists`, id)
 	}
-
-	var err error
-	var ids []string
-	tmpInfos := make(map[string]*datamodel.Info)
-	tmpSketches := make(map[string]*datamodel.Info)
-	for id, info := range infos {
-		if err = m.info.create(info); err != nil {
-			break
+	if len(infos) == 0 {
+		return fmt.Errorf(`Domain "%s" needs at least one sketch`, id)
+	}
+	types := make(map[pb.SketchType]bool)
+	for _, info := range infos {
+		if !isValidType(info) {
+			return fmt.Errorf("Can not create sketch of type %s, invalid type.", info.Type)
 		}
-		tmpInfos[id] = info
-		if err = m.sketches.create(info); err != nil {
-			break
+		if types[info.GetType()] {
+			return fmt.Errorf(`Domain "%s" can only hold one sketch of type %s`, id, info.GetType())
 		}
-		tmpSketches[id] = info
-		ids = append(ids, info.ID())
+		types[info.GetType()] = true
 	}
 
-	if len(tmpInfos) != len(infos) {
-		for _, v := range tmpInfos {
-			if err := m.info.delete(v.ID()); err != nil {
-				// TODO: print out something
+	var ids []string
+	for _, info := range infos {
+		if err := m.createSketch(id, info); err != nil {
+			// Roll back the sketches created so far
+			for _, sketchID := range ids {
+				_ = m.sketches.delete(sketchID)
+				_ = m.info.delete(sketchID)
 			}
+			return err
 		}
+		ids = append(ids, info.ID())
 	}
-	if len(tmpSketches) != len(infos) {
-		for _, v := range tmpSketches {
-			if err := m.sketches.delete(v.ID()); err != nil {
-				// TODO: print out something
-			}
-		}
+	m.domains[id] = ids
+	return nil
+}
+
+// createSketch creates the sketch of info for the domain id
+func (m *domainManager) createSketch(id string, info *datamodel.Info) error {
+	info.Name = proto.String(id)
+	if err := m.info.create(info); err != nil {
+		return err
+	}
+	if err := m.sketches.create(info); err != nil {
+		_ = m.info.delete(info.ID())
+		return err
 	}
+	return nil
+}
 
-	if len(ids) < len(datamodel.GetTypes()) {
-		return fmt.Errorf("Not enough sketches")
+// addSketch creates the sketch of info and adds it to the domain id
+func (m *domainManager) addSketch(id string, info *datamodel.Info) error {
+	ids, ok := m.domains[id]
+	if !ok {
+		return fmt.Errorf(`Domain "%s" does not exists`, id)
 	}
-	m.domains[id] = ids
+	if !isValidType(info) {
+		return fmt.Errorf("Can not create sketch of type %s, invalid type.", info.Type)
+	}
+	info.Name = proto.String(id)
+	for _, sketchID := range ids {
+		if sketchID == info.ID() {
+			return fmt.Errorf(`Domain "%s" already holds a sketch of type %s`, id, info.GetType())
+		}
+	}
+	if err := m.createSketch(id, info); err != nil {
+		return err
+	}
+	m.domains[id] = append(ids, info.ID())
 	return nil
 }
 
+// removeSketch removes the sketch of type typ from the domain id and deletes it,
+// the last sketch of a domain can not be removed
+func (m *domainManager) removeSketch(id string, typ pb.SketchType) error {
+	ids, ok := m.domains[id]
+	if !ok {
+		return fmt.Errorf(`Domain "%s" does not exists`, id)
+	}
+	info := datamodel.NewEmptyInfo()
+	info.Name = proto.String(id)
+	info.Type = &typ
+	for i, sketchID := range ids {
+		if sketchID != info.ID() {
+			continue
+		}
+		if len(ids) == 1 {
+			return fmt.Errorf(`Can not remove the last sketch of domain "%s"`, id)
+		}
+		if err := m.sketches.delete(sketchID); err != nil {
+			return err
+		}
+		if err := m.info.delete(sketchID); err != nil {
+			return err
+		}
+		m.domains[id] = append(ids[:i:i], ids[i+1:]...)
+		return nil
+	}
+	return fmt.Errorf(`Domain "%s" holds no sketch of type %s`, id, typ)
+}
+
 // FIXME: maybe return a list of errors?
 func (m *domainManager) delete(id string) error {
 	var lastErr error
@@ -90,6 +149,34 @@ func (m *domainManager) delete(id string) error {
 }
 
 func (m *domainManager) add(id string, values []string) error {
+	return m.addToSketches(id, func(i int, sk string) error {
+		return m.sketches.add(sk, values)
+	})
+}
+
+// addWeightedAt adds values once and weighted values count times to all the
+// sketches of the domain id and returns the result of each of them
+func (m *domainManager) addWeightedAt(id string, values []string, weighted []*pb.WeightedValue,
+	timestamp int64) ([]*pb.AddResult, error) {
+	sketches, ok := m.domains[id]
+	if !ok {
+		return nil, fmt.Errorf(`Domain "%s" does not exists`, id)
+	}
+	results := make([]*pb.AddResult, len(sketches))
+	err := m.addToSketches(id, func(i int, sk string) error {
+		res, err := m.sketches.addWeightedAt(sk, values, weighted, timestamp)
+		results[i] = res
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// addToSketches calls add concurrently for each sketch of the domain id, the
+// errors of the sketches are returned at once
+func (m *domainManager) addToSketches(id string, add func(int, string) error) error {
 	sketches, ok := m.domains[id]
 
 	if !ok {
@@ -99,16 +186,24 @@ func (m *domainManager) add(id string, values []string) error {
 	var wg sync.WaitGroup
 	wg.Add(len(sketches))
 
-	for _, sketch := range sketches {
-		go func(sk string) {
-			if err := m.sketches.add(sk, values); err != nil {
-				logger.Errorf("%q\n", err)
-			}
+	errs := make([]error, len(sketches))
+	for i, sketch := range sketches {
+		go func(i int, sk string) {
+			errs[i] = add(i, sk)
 			wg.Done()
-		}(sketch)
+		}(i, sketch)
 	}
 
 	wg.Wait()
+	failed := []string{}
+	for i, err := range errs {
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s (%v)", sketches[i], err))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("Failed to add to sketches: %s", strings.Join(failed, ", "))
+	}
 	return nil
 }
 
@@ -122,7 +217,7 @@ func (m *domainManager) get(id string) (*pb.Domain, error) {
 	for _, id := range sketchIds {
 		s := m.info.get(id)
 		if s != nil {
-			sketches = append(sketches, s.Sketch)
+			sketches = append(sketches, s.Copy().Sketch)
 		}
 	}
 	domain := &pb.Domain{
@@ -131,3 +226,44 @@ func (m *domainManager) get(id string) (*pb.Domain, error) {
 	}
 	return domain, nil
 }
+
+// query queries all the sketches of the domain, the frequencies and memberships
+// of values are returned along with the cardinality and rankings, and the QUAN
+// sketch is queried with quantiles
+func (m *domainManager) query(id string, values []string, quantiles *sketches.QuantilesQuery) (*pb.QueryDomainReply, error) {
+	domain, err := m.get(id)
+	if err != nil {
+		return nil, err
+	}
+	reply := &pb.QueryDomainReply{Domain: domain}
+	for _, sketchID := range m.domains[id] {
+		info := m.info.get(sketchID)
+		// SIMI sketches are compared with GetSimilarity
+		if info == nil || info.GetType() == pb.SketchType_SIMI {
+			continue
+		}
+		var data interface{} = values
+		if info.GetType() == pb.SketchType_QUAN {
+			data = quantiles
+		}
+		res, err := m.sketches.get(sketchID, data)
+		if err != nil {
+			return nil, err
+		}
+		switch r := res.(type) {
+		case *pb.CardinalityResult:
+			reply.Cardinality = r
+		case *pb.RankingsResult:
+			reply.Rankings = r
+		case *pb.FrequencyResult:
+			reply.Frequencies = r
+		case *pb.MembershipResult:
+			reply.Memberships = r
+		case *pb.QuantilesResult:
+			reply.Quantiles = r
+		default:
+			return nil, fmt.Errorf("Unexpected result %T for sketch %s", res, sketchID)
+		}
+	}
+	return reply, nil
+}
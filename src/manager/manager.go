@@ -2,11 +2,16 @@ package manager
 
 import (
 	"fmt"
+	"math"
 	"sort"
 	"strconv"
+	"sync"
+	"time"
 
 	"datamodel"
 	pb "datamodel/protobuf"
+	"sketches"
+	"utils"
 
 	"github.com/njpatel/loggo"
 )
@@ -20,8 +25,22 @@ func isValidType(info *datamodel.Info) bool {
 	return len(datamodel.GetTypeString(info.GetType())) != 0
 }
 
+// setCreated records the creation time of a sketch unless it is known already
+func setCreated(info *datamodel.Info) {
+	if info.State == nil {
+		info.State = datamodel.NewEmptyState()
+	}
+	if info.State.GetCreated() == 0 {
+		info.State.Created = utils.Int64p(time.Now().Unix())
+	}
+}
+
 // Manager is responsible for manipulating the sketches and syncing to disk
 type Manager struct {
+	// lock guards the maps of the sub-managers, it is held for writing when
+	// creating or deleting sketches and domains and for reading otherwise.
+	// Sketches synchronize adding and getting values themselves.
+	lock     sync.RWMutex
 	infos    *infoManager
 	sketches *sketchManager
 	domains  *domainManager
@@ -44,9 +63,12 @@ func NewManager() *Manager {
 
 // CreateSketch ...
 func (m *Manager) CreateSketch(info *datamodel.Info) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
 	if !isValidType(info) {
 		return fmt.Errorf("Can not create sketch of type %s, invalid type.", info.Type)
 	}
+	setCreated(info)
 	if err := m.infos.create(info); err != nil {
 		return err
 	}
@@ -60,30 +82,106 @@ func (m *Manager) CreateSketch(info *datamodel.Info) error {
 	return nil
 }
 
-// CreateDomain ...
+// CreateDomain creates a domain holding a sketch of each type, all of them
+// with the properties of info
 func (m *Manager) CreateDomain(info *datamodel.Info) error {
-	infos := make(map[string]*datamodel.Info)
+	var infos []*datamodel.Info
 	for _, typ := range datamodel.GetTypesPb() {
 		styp := typ
 		tmpInfo := info.Copy()
 		tmpInfo.Type = &styp
-		infos[tmpInfo.ID()] = tmpInfo
+		infos = append(infos, tmpInfo)
+	}
+	return m.CreateDomainSketches(info.GetName(), infos)
+}
+
+// CreateDomainSketches creates the domain id holding a sketch for each of infos,
+// at most one per type
+func (m *Manager) CreateDomainSketches(id string, infos []*datamodel.Info) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for _, info := range infos {
+		setCreated(info)
 	}
-	return m.domains.create(info.GetName(), infos)
+	return m.domains.create(id, infos)
+}
+
+// AddSketchToDomain creates a sketch of the type of info in the domain id
+func (m *Manager) AddSketchToDomain(id string, info *datamodel.Info) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	setCreated(info)
+	return m.domains.addSketch(id, info)
+}
+
+// RemoveSketchFromDomain deletes the sketch of type typ of the domain id
+func (m *Manager) RemoveSketchFromDomain(id string, typ pb.SketchType) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.domains.removeSketch(id, typ)
 }
 
 // AddToSketch ...
 func (m *Manager) AddToSketch(id string, values []string) error {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
 	return m.sketches.add(id, values)
 }
 
+// AddToSketchAt adds values to the sketch at timestamp, only windowed sketches
+// make use of it
+func (m *Manager) AddToSketchAt(id string, values []string, timestamp int64) error {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.sketches.addAt(id, values, timestamp)
+}
+
 // AddToDomain ...
 func (m *Manager) AddToDomain(id string, values []string) error {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
 	return m.domains.add(id, values)
 }
 
+// AddWeightedToSketchAt adds values once and weighted values count times to the
+// sketch at timestamp and reports the outcome, CARD and MEMB sketches add
+// weighted values once
+func (m *Manager) AddWeightedToSketchAt(id string, values []string, weighted []*pb.WeightedValue,
+	timestamp int64) (*pb.AddResult, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.sketches.addWeightedAt(id, values, weighted, timestamp)
+}
+
+// AddWeightedToDomainAt adds values once and weighted values count times to the
+// sketches of a domain at timestamp and reports the outcome for each sketch
+func (m *Manager) AddWeightedToDomainAt(id string, values []string, weighted []*pb.WeightedValue,
+	timestamp int64) ([]*pb.AddResult, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.domains.addWeightedAt(id, values, weighted, timestamp)
+}
+
+// RemoveFromSketch removes values from the sketch id, only deletable MEMB
+// sketches support it
+func (m *Manager) RemoveFromSketch(id string, values []string) (*pb.RemoveReply, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.sketches.remove(id, values)
+}
+
+// MergeSketches merges the sketches sources into the sketch dest, all of them
+// must have compatible properties
+func (m *Manager) MergeSketches(dest string, sources []string) error {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.sketches.merge(m.infos, dest, sources)
+}
+
 // DeleteSketch ...
 func (m *Manager) DeleteSketch(id string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
 	if err := m.infos.delete(id); err != nil {
 		return err
 	}
@@ -92,6 +190,8 @@ func (m *Manager) DeleteSketch(id string) error {
 
 // DeleteDomain ...
 func (m *Manager) DeleteDomain(id string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
 	return m.domains.delete(id)
 }
 
@@ -114,6 +214,8 @@ func (slice tupleResult) Swap(i, j int) {
 
 // GetSketches return a list of sketch tuples [name, type]
 func (m *Manager) GetSketches() [][2]string {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
 	sketches := tupleResult{}
 	for _, v := range m.infos.info {
 		sketches = append(sketches,
@@ -126,6 +228,8 @@ func (m *Manager) GetSketches() [][2]string {
 
 // GetDomains return a list of sketch tuples [name, type]
 func (m *Manager) GetDomains() [][2]string {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
 	domains := tupleResult{}
 	for k, v := range m.domains.domains {
 		domains = append(domains, [2]string{k, strconv.Itoa(len(v))})
@@ -136,23 +240,145 @@ func (m *Manager) GetDomains() [][2]string {
 
 // GetSketch ...
 func (m *Manager) GetSketch(id string) (*datamodel.Info, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
 	info := m.infos.get(id)
 	if info == nil {
 		return nil, fmt.Errorf("No such sketch %s", id)
 	}
-	return info, nil
+	// Return a copy as the state of the stored info is updated concurrently
+	res := info.Copy()
+	if sketch, ok := m.sketches.sketches[id]; ok {
+		sketch.State(res.State)
+	}
+	return res, nil
 }
 
 // GetDomain ...
 func (m *Manager) GetDomain(id string) (*pb.Domain, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
 	return m.domains.get(id)
 }
 
+// QueryDomain queries all the sketches of a domain at once, each of them is
+// read under its own lock. The QUAN sketch is queried with quantiles.
+func (m *Manager) QueryDomain(id string, values []string, quantiles *sketches.QuantilesQuery) (*pb.QueryDomainReply, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.domains.query(id, values, quantiles)
+}
+
 // GetFromSketch ...
 func (m *Manager) GetFromSketch(id string, data interface{}) (interface{}, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
 	return m.sketches.get(id, data)
 }
 
-// Destroy ...
+// GetFromUnion queries the union of the sketches ids without changing them
+func (m *Manager) GetFromUnion(ids []string, data interface{}) (interface{}, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	union, err := m.sketches.union(m.infos, ids)
+	if err != nil {
+		return nil, err
+	}
+	return union.Get(queryData(data))
+}
+
+// maxSetSketches bounds the number of sketches of a set cardinality query, the
+// intersection is derived from the union of every subset of them
+const maxSetSketches = 8
+
+// GetSetCardinality estimates the cardinality of the union and intersection of
+// the CARD sketches ids, and of the first one minus the others. The
+// intersection is derived from the unions of the subsets of the sketches by
+// inclusion-exclusion, so its error grows with the number of sketches.
+func (m *Manager) GetSetCardinality(ids []string) (*pb.GetSetCardinalityReply, error) {
+	if len(ids) < 2 || len(ids) > maxSetSketches {
+		return nil, fmt.Errorf("Can not combine %d sketches, expected between 2 and %d", len(ids), maxSetSketches)
+	}
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	for _, id := range ids {
+		info := m.infos.get(id)
+		if info == nil {
+			return nil, fmt.Errorf(`Sketch "%s" does not exists`, id)
+		}
+		if info.GetType() != pb.SketchType_CARD {
+			return nil, fmt.Errorf(`Can not combine %s sketch "%s", only CARD sketches can be`, info.GetType(), id)
+		}
+	}
+
+	// unions[s] is the cardinality of the union of the sketches in the subset
+	// of ids s, whose bit i is set if it holds ids[i], and errs[s] its
+	// standard error
+	unions := make([]float64, 1<<uint(len(ids)))
+	errs := make([]float64, len(unions))
+	for s := 1; s < len(unions); s++ {
+		var subset []string
+		for i, id := range ids {
+			if s&(1<<uint(i)) != 0 {
+				subset = append(subset, id)
+			}
+		}
+		union, err := m.sketches.union(m.infos, subset)
+		if err != nil {
+			return nil, err
+		}
+		res, err := union.Get(nil)
+		if err != nil {
+			return nil, err
+		}
+		card := res.(*pb.CardinalityResult)
+		unions[s] = float64(card.GetCardinality())
+		errs[s] = float64(card.GetUpper()-card.GetLower()) / 2
+	}
+
+	all := len(unions) - 1
+	intersection, intersectionErr := 0.0, 0.0
+	smallest := math.Inf(1)
+	for s := 1; s <= all; s++ {
+		if utils.PopCount(uint64(s))%2 == 1 {
+			intersection += unions[s]
+		} else {
+			intersection -= unions[s]
+		}
+		intersectionErr += errs[s]
+		if utils.PopCount(uint64(s)) == 1 {
+			smallest = math.Min(smallest, unions[s])
+		}
+	}
+	difference := unions[all] - unions[all&^1]
+	return &pb.GetSetCardinalityReply{
+		Union:        cardinalityEstimate(unions[all], errs[all], unions[all]),
+		Intersection: cardinalityEstimate(intersection, intersectionErr, smallest),
+		Difference:   cardinalityEstimate(difference, errs[all]+errs[all&^1], unions[1]),
+	}, nil
+}
+
+// cardinalityEstimate rounds the estimate n, bounded by 0 and max, and its error
+func cardinalityEstimate(n, err, max float64) *pb.CardinalityEstimate {
+	n = math.Min(math.Max(n, 0), max)
+	return &pb.CardinalityEstimate{
+		Cardinality: utils.Int64p(int64(math.Floor(n + 0.5))),
+		Error:       utils.Int64p(int64(math.Ceil(err))),
+	}
+}
+
+// GetFromSketchWindow queries the buckets of a windowed sketch selected by window
+func (m *Manager) GetFromSketchWindow(id string, data interface{}, window *sketches.Window) (interface{}, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.sketches.getWindow(id, data, window)
+}
+
+// Destroy releases all sketches and domains
 func (m *Manager) Destroy() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.infos.info = make(map[string]*datamodel.Info)
+	m.sketches.sketches = make(map[string]*sketches.SketchProxy)
+	m.domains.domains = make(map[string][]string)
 }
@@ -1,7 +1,9 @@
 package manager
 
 import (
+	"bytes"
 	"fmt"
+	"strings"
 	"testing"
 
 	"config"
@@ -384,3 +386,247 @@ func TestCreateDeleteDomain(t *testing.T) {
 		t.Error("Expected [[dc freq]], got", sketches[1][0], sketches[1][1])
 	}
 }
+
+func TestAddToDomainMissingSketch(t *testing.T) {
+	config.Reset()
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	m := NewManager()
+	info := datamodel.NewEmptyInfo()
+	info.Properties.MaxUniqueItems = utils.Int64p(10000)
+	info.Properties.Size = utils.Int64p(10000)
+	info.Name = utils.Stringp("marvel")
+	if err := m.CreateDomain(info); err != nil {
+		t.Fatal("Expected no errors, got", err)
+	}
+	if err := m.DeleteSketch("marvel.FREQ"); err != nil {
+		t.Fatal("Expected no errors, got", err)
+	}
+
+	if err := m.AddToDomain("marvel", []string{"hulk"}); err == nil {
+		t.Error("Expected an error adding to a missing sketch")
+	} else if !strings.Contains(err.Error(), "marvel.FREQ") {
+		t.Error("Expected the error to name marvel.FREQ, got", err)
+	}
+	if _, err := m.AddWeightedToDomainAt("marvel", []string{"hulk"}, nil, 0); err == nil {
+		t.Error("Expected an error adding to a missing sketch")
+	} else if !strings.Contains(err.Error(), "marvel.FREQ") {
+		t.Error("Expected the error to name marvel.FREQ, got", err)
+	}
+}
+
+func TestQueryDomain(t *testing.T) {
+	config.Reset()
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	m := NewManager()
+	info := datamodel.NewEmptyInfo()
+	info.Properties.MaxUniqueItems = utils.Int64p(1000)
+	info.Properties.Size = utils.Int64p(4)
+	info.Name = utils.Stringp("marvel")
+	if err := m.CreateDomain(info); err != nil {
+		t.Fatal("Expected no errors, got", err)
+	}
+	values := []string{"thor", "hulk", "hulk", "hulk", "loki", "loki"}
+	if err := m.AddToDomain("marvel", values); err != nil {
+		t.Error("Expected no errors, got", err)
+	}
+
+	res, err := m.QueryDomain("marvel", []string{"hulk", "batman"}, nil)
+	if err != nil {
+		t.Fatal("Expected no errors, got", err)
+	}
+	if n := len(res.GetDomain().GetSketches()); n != 4 {
+		t.Error("Expected 4 sketches, got", n)
+	}
+	if v := res.GetCardinality().GetCardinality(); v != 3 {
+		t.Error("Expected cardinality 3, got", v)
+	}
+	if v := res.GetRankings().GetRankings(); len(v) != 2 || v[0].GetValue() != "hulk" || v[1].GetValue() != "loki" {
+		t.Error("Expected [hulk loki], got", v)
+	}
+	if v := res.GetFrequencies().GetFrequencies(); len(v) != 2 || v[0].GetCount() != 3 || v[1].GetCount() != 0 {
+		t.Error("Expected [3 0], got", v)
+	}
+	if v := res.GetMemberships().GetMemberships(); len(v) != 2 || !v[0].GetIsMember() || v[1].GetIsMember() {
+		t.Error("Expected [true false], got", v)
+	}
+
+	if _, err := m.QueryDomain("dc", nil, nil); err == nil {
+		t.Error("Expected an error querying a missing domain")
+	}
+}
+
+func TestSnapshotSaveLoad(t *testing.T) {
+	config.Reset()
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	m := NewManager()
+	info := datamodel.NewEmptyInfo()
+	info.Properties.MaxUniqueItems = utils.Int64p(100)
+	info.Properties.Size = utils.Int64p(10)
+	info.Name = utils.Stringp("marvel")
+	if err := m.CreateDomain(info); err != nil {
+		t.Error("Expected no errors, got", err)
+	}
+
+	// A sketch still in its threshold stage
+	info2 := datamodel.NewEmptyInfo()
+	typ := pb.SketchType_FREQ
+	info2.Properties.MaxUniqueItems = utils.Int64p(10000)
+	info2.Name = utils.Stringp("dc")
+	info2.Type = &typ
+	if err := m.CreateSketch(info2); err != nil {
+		t.Error("Expected no errors, got", err)
+	}
+
+	values := []string{"thor"}
+	for i := 0; i < 10; i++ {
+		values = append(values, "hulk")
+	}
+	for i := 0; i < 20; i++ {
+		values = append(values, fmt.Sprintf("hero-%d", i))
+	}
+	if err := m.AddToDomain("marvel", values); err != nil {
+		t.Error("Expected no errors, got", err)
+	}
+	if err := m.AddToSketch(info2.ID(), []string{"batman", "batman", "joker"}); err != nil {
+		t.Error("Expected no errors, got", err)
+	}
+
+	buf := &bytes.Buffer{}
+	saved, err := m.Save(buf, 1337)
+	if err != nil {
+		t.Fatal("Expected no errors, got", err)
+	} else if len(saved) != 5 {
+		t.Error("Expected 5 sketches saved, got", saved)
+	}
+
+	// Only the saved sketches are stamped
+	info3 := datamodel.NewEmptyInfo()
+	info3.Properties.MaxUniqueItems = utils.Int64p(10000)
+	info3.Name = utils.Stringp("x-men")
+	info3.Type = &typ
+	if err := m.CreateSketch(info3); err != nil {
+		t.Error("Expected no errors, got", err)
+	}
+	m.SetLastSnapshot(saved, 1337)
+	if res, err := m.GetSketch(info2.ID()); err != nil {
+		t.Error("Expected no errors, got", err)
+	} else if v := res.State.GetLastSnapshot(); v != 1337 {
+		t.Error("Expected last snapshot 1337, got", v)
+	}
+	if res, err := m.GetSketch(info3.ID()); err != nil {
+		t.Error("Expected no errors, got", err)
+	} else if v := res.State.GetLastSnapshot(); v != 0 {
+		t.Error("Expected no last snapshot, got", v)
+	}
+
+	m = NewManager()
+	if err := m.Load(buf); err != nil {
+		t.Fatal("Expected no errors, got", err)
+	}
+
+	if sketches := m.GetSketches(); len(sketches) != 5 {
+		t.Error("Expected 5 sketches, got", len(sketches))
+	}
+	if domains := m.GetDomains(); len(domains) != 1 || domains[0][0] != "marvel" {
+		t.Error("Expected [[marvel 4]], got", domains)
+	}
+
+	id := fmt.Sprintf("marvel.%s", pb.SketchType_CARD)
+	if res, err := m.GetFromSketch(id, nil); err != nil {
+		t.Error("Expected no errors, got", err)
+	} else if v := res.(*pb.CardinalityResult).GetCardinality(); v != 22 {
+		t.Error("Expected cardinality 22, got", v)
+	}
+
+	id = fmt.Sprintf("marvel.%s", pb.SketchType_MEMB)
+	if res, err := m.GetFromSketch(id, []string{"thor", "batman"}); err != nil {
+		t.Error("Expected no errors, got", err)
+	} else if v := res.(*pb.MembershipResult).GetMemberships(); !v[0].GetIsMember() || v[1].GetIsMember() {
+		t.Error("Expected [true false], got", v)
+	}
+
+	id = fmt.Sprintf("marvel.%s", pb.SketchType_RANK)
+	if res, err := m.GetFromSketch(id, nil); err != nil {
+		t.Error("Expected no errors, got", err)
+	} else if v := res.(*pb.RankingsResult).GetRankings()[0]; v.GetValue() != "hulk" {
+		t.Error("Expected hulk to rank first, got", v)
+	}
+
+	if res, err := m.GetFromSketch(info2.ID(), []string{"batman"}); err != nil {
+		t.Error("Expected no errors, got", err)
+	} else if v := res.(*pb.FrequencyResult).GetFrequencies()[0].GetCount(); v != 2 {
+		t.Error("Expected batman: 2, got", v)
+	}
+
+	if res, err := m.GetSketch(info2.ID()); err != nil {
+		t.Error("Expected no errors, got", err)
+	} else if v := res.State.GetLastSnapshot(); v != 1337 {
+		t.Error("Expected last snapshot 1337, got", v)
+	} else if v := res.State.GetValuesAdded(); v != 3 {
+		t.Error("Expected 3 values added, got", v)
+	} else if !res.State.GetExact() {
+		t.Error("Expected sketch to be exact")
+	} else if res.State.GetCreated() == 0 {
+		t.Error("Expected creation time to be restored")
+	}
+}
+
+func TestSnapshotProperties(t *testing.T) {
+	config.Reset()
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	m := NewManager()
+	info := datamodel.NewEmptyInfo()
+	typ := pb.SketchType_MEMB
+	info.Properties.MaxUniqueItems = utils.Int64p(1000)
+	info.Properties.ThresholdSize = utils.Int64p(-1)
+	info.Properties.Deletable = utils.Boolp(true)
+	info.Name = utils.Stringp("online")
+	info.Type = &typ
+	if err := m.CreateSketch(info); err != nil {
+		t.Fatal("Expected no errors, got", err)
+	}
+	if err := m.AddToSketch(info.ID(), []string{"hulk", "thor"}); err != nil {
+		t.Error("Expected no errors, got", err)
+	}
+	quan := datamodel.NewEmptyInfo()
+	quanTyp := pb.SketchType_QUAN
+	quan.Properties.Compression = utils.Int64p(200)
+	quan.Name = utils.Stringp("latencies")
+	quan.Type = &quanTyp
+	if err := m.CreateSketch(quan); err != nil {
+		t.Fatal("Expected no errors, got", err)
+	}
+
+	buf := &bytes.Buffer{}
+	if _, err := m.Save(buf, 1337); err != nil {
+		t.Fatal("Expected no errors, got", err)
+	}
+	m = NewManager()
+	if err := m.Load(buf); err != nil {
+		t.Fatal("Expected no errors, got", err)
+	}
+
+	if res, err := m.GetSketch(info.ID()); err != nil {
+		t.Error("Expected no errors, got", err)
+	} else if !res.Properties.GetDeletable() {
+		t.Error("Expected the sketch to stay deletable")
+	}
+	if res, err := m.RemoveFromSketch(info.ID(), []string{"hulk"}); err != nil {
+		t.Error("Expected no errors, got", err)
+	} else if !res.GetRemovals()[0].GetRemoved() {
+		t.Error("Expected hulk to be removed, got", res)
+	}
+	if res, err := m.GetSketch(quan.ID()); err != nil {
+		t.Error("Expected no errors, got", err)
+	} else if v := res.Properties.GetCompression(); v != 200 {
+		t.Error("Expected compression 200, got", v)
+	}
+}
@@ -2,8 +2,12 @@ package manager
 
 import (
 	"fmt"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
 
 	"datamodel"
+	pb "datamodel/protobuf"
 	"sketches"
 )
 
@@ -27,7 +31,20 @@ func (m *sketchManager) create(info *datamodel.Info) error {
 	return nil
 }
 
+func (m *sketchManager) load(info *datamodel.Info, data []byte) error {
+	sketch, err := sketches.LoadSketch(info, data)
+	if err != nil {
+		return err
+	}
+	m.sketches[info.ID()] = sketch
+	return nil
+}
+
 func (m *sketchManager) add(id string, values []string) error {
+	return m.addAt(id, values, time.Now().Unix())
+}
+
+func (m *sketchManager) addAt(id string, values []string, timestamp int64) error {
 	sketch, ok := m.sketches[id]
 	if !ok {
 		return fmt.Errorf(`Sketch "%s" does not exists`, id)
@@ -41,11 +58,175 @@ func (m *sketchManager) add(id string, values []string) error {
 	for i, v := range values {
 		byts[i] = []byte(v)
 	}
-	// FIXME: return if adding was successful or not
-	_, err := sketch.Add(byts)
+	_, err := sketch.AddAt(byts, timestamp)
 	return err
 }
 
+// addWeightedAt adds values once and weighted values count times to the sketch
+// id at timestamp, failing to add is reported in the result. MEMB sketches
+// report whether each value was new to them.
+func (m *sketchManager) addWeightedAt(id string, values []string, weighted []*pb.WeightedValue,
+	timestamp int64) (*pb.AddResult, error) {
+	sketch, ok := m.sketches[id]
+	if !ok {
+		return nil, fmt.Errorf(`Sketch "%s" does not exists`, id)
+	}
+	result := &pb.AddResult{
+		Sketch:  &pb.Sketch{Name: sketch.Name, Type: sketch.Type},
+		Success: proto.Bool(true),
+	}
+	if sketch.Locked() {
+		return result, nil
+	}
+
+	var err error
+	changed := false
+	if sketch.GetType() == pb.SketchType_MEMB {
+		// Counts do not matter to memberships, weighted values are added once
+		byts := make([][]byte, 0, len(values)+len(weighted))
+		for _, v := range values {
+			byts = append(byts, []byte(v))
+		}
+		for _, v := range weighted {
+			byts = append(byts, []byte(v.GetValue()))
+		}
+		var inserted []bool
+		if inserted, err = sketch.InsertAt(byts, timestamp); err == nil {
+			for i, v := range byts {
+				result.Insertions = append(result.Insertions, &pb.Insertion{
+					Value:    proto.String(string(v)),
+					Inserted: proto.Bool(inserted[i]),
+				})
+				changed = changed || inserted[i]
+			}
+		}
+	} else {
+		byts := make([][]byte, len(values))
+		for i, v := range values {
+			byts[i] = []byte(v)
+		}
+		changed, err = sketch.AddAt(byts, timestamp)
+		if err == nil && len(weighted) > 0 {
+			counts := make(map[string]uint)
+			for _, v := range weighted {
+				counts[v.GetValue()] += uint(v.GetCount())
+			}
+			var c bool
+			c, err = sketch.AddCountsAt(counts, timestamp)
+			changed = changed || c
+		}
+	}
+	result.Changed = proto.Bool(changed)
+	// Sketches may report values as not added without failing, e.g. FREQ
+	// sketches only increment their log counters with some probability
+	if err != nil {
+		result.Success = proto.Bool(false)
+		result.Error = proto.String(err.Error())
+	}
+	return result, nil
+}
+
+func (m *sketchManager) merge(infos *infoManager, dest string, sources []string) error {
+	sketch, ok := m.sketches[dest]
+	if !ok {
+		return fmt.Errorf(`Sketch "%s" does not exists`, dest)
+	}
+	info := infos.get(dest)
+	srcs := make([]*sketches.SketchProxy, len(sources))
+	for i, id := range sources {
+		src, ok := m.sketches[id]
+		if !ok {
+			return fmt.Errorf(`Sketch "%s" does not exists`, id)
+		}
+		if id == dest {
+			return fmt.Errorf(`Can not merge sketch "%s" into itself`, id)
+		}
+		if !mergeable(info, infos.get(id)) {
+			return fmt.Errorf(`Can not merge sketch "%s" into "%s", incompatible properties`, id, dest)
+		}
+		srcs[i] = src
+	}
+	return sketch.Merge(srcs)
+}
+
+// remove removes values from the sketch id and reports whether each of them
+// was held by it
+func (m *sketchManager) remove(id string, values []string) (*pb.RemoveReply, error) {
+	sketch, ok := m.sketches[id]
+	if !ok {
+		return nil, fmt.Errorf(`Sketch "%s" does not exists`, id)
+	}
+	byts := make([][]byte, len(values), len(values))
+	for i, v := range values {
+		byts[i] = []byte(v)
+	}
+	removed, err := sketch.Remove(byts)
+	if err != nil {
+		return nil, err
+	}
+	reply := &pb.RemoveReply{Removals: make([]*pb.Removal, len(values))}
+	for i, v := range values {
+		reply.Removals[i] = &pb.Removal{Value: proto.String(v), Removed: proto.Bool(removed[i])}
+	}
+	return reply, nil
+}
+
+// union returns a new sketch holding the values of the sketches ids, which
+// must have compatible properties and are left unchanged
+func (m *sketchManager) union(infos *infoManager, ids []string) (*sketches.SketchProxy, error) {
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("Can not build the union of no sketches")
+	}
+	first, ok := m.sketches[ids[0]]
+	if !ok {
+		return nil, fmt.Errorf(`Sketch "%s" does not exists`, ids[0])
+	}
+	info := infos.get(ids[0])
+	srcs := make([]*sketches.SketchProxy, len(ids)-1)
+	for i, id := range ids[1:] {
+		src, ok := m.sketches[id]
+		if !ok {
+			return nil, fmt.Errorf(`Sketch "%s" does not exists`, id)
+		}
+		if !mergeable(info, infos.get(id)) {
+			return nil, fmt.Errorf(`Can not merge sketch "%s" into "%s", incompatible properties`, id, ids[0])
+		}
+		srcs[i] = src
+	}
+	data, err := first.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	union, err := sketches.LoadSketch(info, data)
+	if err != nil {
+		return nil, err
+	}
+	return union, union.Merge(srcs)
+}
+
+// mergeable returns whether a sketch with info src can be merged into one with
+// info dest
+func mergeable(dest, src *datamodel.Info) bool {
+	if dest.GetType() != src.GetType() {
+		return false
+	}
+	if dest.GetProperties().GetBucketDuration() != src.GetProperties().GetBucketDuration() ||
+		dest.GetProperties().GetBucketCount() != src.GetProperties().GetBucketCount() {
+		return false
+	}
+	if dest.GetProperties().GetDeletable() != src.GetProperties().GetDeletable() ||
+		dest.GetProperties().GetErrorRate() != src.GetProperties().GetErrorRate() {
+		return false
+	}
+	switch dest.GetType() {
+	case pb.SketchType_MEMB, pb.SketchType_FREQ:
+		return dest.GetProperties().GetMaxUniqueItems() == src.GetProperties().GetMaxUniqueItems()
+	case pb.SketchType_RANK:
+		return dest.GetProperties().GetSize() == src.GetProperties().GetSize()
+	}
+	return true
+}
+
 func (m *sketchManager) delete(id string) error {
 	if _, ok := m.sketches[id]; !ok {
 		return fmt.Errorf(`Sketch "%s" does not exists`, id)
@@ -55,17 +236,34 @@ func (m *sketchManager) delete(id string) error {
 }
 
 func (m *sketchManager) get(id string, data interface{}) (interface{}, error) {
+	return m.getWindow(id, data, nil)
+}
+
+func (m *sketchManager) getWindow(id string, data interface{}, window *sketches.Window) (interface{}, error) {
+	v, ok := m.sketches[id]
+	if !ok {
+		return nil, fmt.Errorf("No such key %s", id)
+	}
+	if window != nil {
+		return v.GetWindow(queryData(data), window)
+	}
+	return v.Get(queryData(data))
+}
+
+// queryData converts the values sketches are queried with to bytes, other
+// queries such as a *sketches.QuantilesQuery are passed as is
+func queryData(data interface{}) interface{} {
 	var values []string
-	if data != nil {
-		values = data.([]string)
+	switch d := data.(type) {
+	case nil:
+	case []string:
+		values = d
+	default:
+		return data
 	}
 	byts := make([][]byte, len(values), len(values))
 	for i, v := range values {
 		byts[i] = []byte(v)
 	}
-	v, ok := m.sketches[id]
-	if !ok {
-		return nil, fmt.Errorf("No such key %s", id)
-	}
-	return v.Get(byts)
+	return byts
 }
@@ -0,0 +1,160 @@
+package manager
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/gogo/protobuf/proto"
+
+	"datamodel"
+	pb "datamodel/protobuf"
+	"utils"
+)
+
+// Record kinds of a snapshot
+const (
+	sketchRecord = byte(1)
+	domainRecord = byte(2)
+)
+
+func writeRecord(w io.Writer, kind byte, parts ...[]byte) error {
+	buf := make([]byte, binary.MaxVarintLen64)
+	if _, err := w.Write([]byte{kind}); err != nil {
+		return err
+	}
+	for _, part := range parts {
+		n := binary.PutUvarint(buf, uint64(len(part)))
+		if _, err := w.Write(buf[:n]); err != nil {
+			return err
+		}
+		if _, err := w.Write(part); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readPart(r *bufio.Reader) ([]byte, error) {
+	size, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	part := make([]byte, size)
+	if _, err := io.ReadFull(r, part); err != nil {
+		return nil, err
+	}
+	return part, nil
+}
+
+// Save writes a point-in-time copy of all sketches and domains to w, the
+// written sketches have their LastSnapshot set to timestamp. It returns the
+// ids of the written sketches.
+func (m *Manager) Save(w io.Writer, timestamp int64) ([]string, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	ids := make([]string, 0, len(m.infos.info))
+	for id, info := range m.infos.info {
+		sketch, ok := m.sketches.sketches[id]
+		if !ok {
+			return nil, fmt.Errorf(`Sketch "%s" does not exists`, id)
+		}
+		data, err := sketch.Marshal()
+		if err != nil {
+			return nil, err
+		}
+		tmp := info.Copy()
+		sketch.State(tmp.State)
+		tmp.State.LastSnapshot = utils.Int64p(timestamp)
+		raw, err := proto.Marshal(tmp.Sketch)
+		if err != nil {
+			return nil, err
+		}
+		if err := writeRecord(w, sketchRecord, raw, data); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	for name := range m.domains.domains {
+		dom, err := m.domains.get(name)
+		if err != nil {
+			return nil, err
+		}
+		raw, err := proto.Marshal(dom)
+		if err != nil {
+			return nil, err
+		}
+		if err := writeRecord(w, domainRecord, raw); err != nil {
+			return nil, err
+		}
+	}
+	return ids, nil
+}
+
+// Load restores the sketches and domains of a snapshot written by Save
+func (m *Manager) Load(r io.Reader) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	rdr := bufio.NewReader(r)
+	for {
+		kind, err := rdr.ReadByte()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		raw, err := readPart(rdr)
+		if err != nil {
+			return err
+		}
+		switch kind {
+		case sketchRecord:
+			data, err := readPart(rdr)
+			if err != nil {
+				return err
+			}
+			sketch := &pb.Sketch{}
+			if err := proto.Unmarshal(raw, sketch); err != nil {
+				return err
+			}
+			info := &datamodel.Info{Sketch: sketch}
+			if err := m.infos.create(info); err != nil {
+				return err
+			}
+			if err := m.sketches.load(info, data); err != nil {
+				_ = m.infos.delete(info.ID())
+				return err
+			}
+		case domainRecord:
+			dom := &pb.Domain{}
+			if err := proto.Unmarshal(raw, dom); err != nil {
+				return err
+			}
+			ids := make([]string, len(dom.GetSketches()))
+			for i, sketch := range dom.GetSketches() {
+				ids[i] = (&datamodel.Info{Sketch: sketch}).ID()
+			}
+			m.domains.domains[dom.GetName()] = ids
+		default:
+			return fmt.Errorf("Invalid snapshot record %d", kind)
+		}
+	}
+}
+
+// SetLastSnapshot updates the state of the sketches ids after a successful
+// snapshot, sketches that were deleted since are skipped
+func (m *Manager) SetLastSnapshot(ids []string, timestamp int64) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for _, id := range ids {
+		info := m.infos.get(id)
+		if info == nil {
+			continue
+		}
+		if info.State == nil {
+			info.State = datamodel.NewEmptyState()
+		}
+		info.State.LastSnapshot = utils.Int64p(timestamp)
+	}
+}
@@ -1,35 +1,98 @@
 package server
 
 import (
+	"fmt"
+
+	"github.com/gogo/protobuf/proto"
+
 	"datamodel"
 	pb "datamodel/protobuf"
-
+	"sketches"
 	"storage"
 
 	"golang.org/x/net/context"
 )
 
-func (s *serverStruct) createDomain(ctx context.Context, in *pb.Domain) (*pb.Domain, error) {
+// domainSketchInfo returns the info of a sketch of the domain name, RANK
+// sketches are given a default size
+func domainSketchInfo(name string, sketch *pb.Sketch) *datamodel.Info {
 	info := datamodel.NewEmptyInfo()
-	info.Name = in.Name
-	// FIXME: A Domain's info should have an array of properties for each Sketch (or just an array
-	// of Sketches, like what the proto has). This is just a hack to choose the first Sketch and
-	// use it's info for now
-	info.Properties.MaxUniqueItems = in.GetSketches()[0].GetProperties().MaxUniqueItems
-	info.Properties.Size = in.GetSketches()[0].GetProperties().Size
-	if info.Properties.Size == nil || *info.Properties.Size == 0 {
-		var defaultSize int64 = 100
-		info.Properties.Size = &defaultSize
-	}
-	// FIXME: We should be passing a pb.Domain and not a datamodel.Info to manager.CreateDomain
-	err := s.manager.CreateDomain(info)
+	info.Name = proto.String(name)
+	info.Type = sketch.Type
+	if sketch.Properties != nil {
+		info.Properties = proto.Clone(sketch.Properties).(*pb.SketchProperties)
+	}
+	if info.Properties.GetSize() == 0 {
+		info.Properties.Size = proto.Int64(defaultDomainSize)
+	}
+	return info
+}
+
+// legacyDomainSketches returns the sketches of a domain created the way AOFs
+// written before domains took typed sketches hold it: one of each default
+// type, with the properties of the first sketch. Such domains have sketches
+// without a type, or of a repeated type all with the same properties as older
+// clients sent. Other domains can't repeat a type.
+func legacyDomainSketches(in *pb.Domain) ([]*pb.Sketch, error) {
+	if len(in.GetSketches()) == 0 {
+		return nil, nil
+	}
+	props := in.GetSketches()[0].GetProperties()
+	untyped, repeated, perType := false, false, false
+	seen := make(map[pb.SketchType]bool)
+	for _, sketch := range in.GetSketches() {
+		if sketch.Type == nil {
+			untyped = true
+			continue
+		}
+		if seen[sketch.GetType()] {
+			repeated = true
+		}
+		seen[sketch.GetType()] = true
+		if !proto.Equal(sketch.GetProperties(), props) {
+			perType = true
+		}
+	}
+	if !untyped && !repeated {
+		return in.GetSketches(), nil
+	}
+	if !untyped && perType {
+		return nil, fmt.Errorf("Domain %s has more than one sketch of a type", in.GetName())
+	}
+	types := datamodel.GetTypesPb()
+	defaults := make([]*pb.Sketch, len(types))
+	for i := range types {
+		defaults[i] = &pb.Sketch{Name: in.Name, Type: &types[i], Properties: props}
+	}
+	return defaults, nil
+}
+
+func (s *serverStruct) createDomain(ctx context.Context, in *pb.Domain) (*pb.Domain, error) {
+	domSketches, err := legacyDomainSketches(in)
 	if err != nil {
 		return nil, err
 	}
-	return in, nil
+	infos := make([]*datamodel.Info, len(domSketches))
+	for i, sketch := range domSketches {
+		infos[i] = domainSketchInfo(in.GetName(), sketch)
+	}
+	if err := s.manager.CreateDomainSketches(in.GetName(), infos); err != nil {
+		return nil, err
+	}
+	// Reply with the properties the sketches were actually created with
+	return s.manager.GetDomain(in.GetName())
 }
 
+// defaultDomainSize is the size of the sketches of a domain created without one
+const defaultDomainSize = 100
+
 func (s *serverStruct) CreateDomain(ctx context.Context, in *pb.Domain) (*pb.Domain, error) {
+	// Keep domains repeating a type out of the AOF
+	if _, err := legacyDomainSketches(in); err != nil {
+		return nil, err
+	}
+	s.lock.RLock()
+	defer s.lock.RUnlock()
 	if err := s.storage.Append(storage.CreateDom, in); err != nil {
 		return nil, err
 	}
@@ -53,6 +116,8 @@ func (s *serverStruct) deleteDomain(ctx context.Context, in *pb.Domain) (*pb.Emp
 }
 
 func (s *serverStruct) DeleteDomain(ctx context.Context, in *pb.Domain) (*pb.Empty, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
 	if err := s.storage.Append(storage.DeleteDom, in); err != nil {
 		return nil, err
 	}
@@ -62,3 +127,42 @@ func (s *serverStruct) DeleteDomain(ctx context.Context, in *pb.Domain) (*pb.Emp
 func (s *serverStruct) GetDomain(ctx context.Context, in *pb.Domain) (*pb.Domain, error) {
 	return s.manager.GetDomain(in.GetName())
 }
+
+func (s *serverStruct) QueryDomain(ctx context.Context, in *pb.QueryDomainRequest) (*pb.QueryDomainReply, error) {
+	quantiles := &sketches.QuantilesQuery{Quantiles: in.GetQuantiles(), CDF: in.GetCdf()}
+	return s.manager.QueryDomain(in.GetDomain().GetName(), in.GetValues(), quantiles)
+}
+
+func (s *serverStruct) addSketchToDomain(ctx context.Context, in *pb.DomainSketchRequest) (*pb.Domain, error) {
+	name := in.GetDomain().GetName()
+	if err := s.manager.AddSketchToDomain(name, domainSketchInfo(name, in.GetSketch())); err != nil {
+		return nil, err
+	}
+	return s.manager.GetDomain(name)
+}
+
+func (s *serverStruct) AddSketchToDomain(ctx context.Context, in *pb.DomainSketchRequest) (*pb.Domain, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if err := s.storage.Append(storage.AddSketchToDom, in); err != nil {
+		return nil, err
+	}
+	return s.addSketchToDomain(ctx, in)
+}
+
+func (s *serverStruct) removeSketchFromDomain(ctx context.Context, in *pb.DomainSketchRequest) (*pb.Domain, error) {
+	name := in.GetDomain().GetName()
+	if err := s.manager.RemoveSketchFromDomain(name, in.GetSketch().GetType()); err != nil {
+		return nil, err
+	}
+	return s.manager.GetDomain(name)
+}
+
+func (s *serverStruct) RemoveSketchFromDomain(ctx context.Context, in *pb.DomainSketchRequest) (*pb.Domain, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if err := s.storage.Append(storage.RemoveSketchFromDom, in); err != nil {
+		return nil, err
+	}
+	return s.removeSketchFromDomain(ctx, in)
+}
@@ -0,0 +1,192 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"golang.org/x/net/context"
+
+	"config"
+	pb "datamodel/protobuf"
+	"manager"
+	"testutils"
+)
+
+func TestCreateDomainWithoutSketches(t *testing.T) {
+	config.Reset()
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	client, conn := setupClient()
+	defer tearDownClient(conn)
+
+	in := &pb.Domain{Name: proto.String("marvel")}
+	if _, err := client.CreateDomain(context.Background(), in); err == nil {
+		t.Error("Expected error creating a domain without sketches")
+	}
+}
+
+func TestCreateLegacyDomain(t *testing.T) {
+	config.Reset()
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	client, conn := setupClient()
+	defer tearDownClient(conn)
+
+	// Older clients sent sketches of a repeated type, they create one sketch
+	// of each default type like domains without sketch types
+	cardTyp := pb.SketchType_CARD
+	props := &pb.SketchProperties{MaxUniqueItems: proto.Int64(1000), Size: proto.Int64(4)}
+	marvel := &pb.Domain{Name: proto.String("marvel")}
+	for i := 0; i < 4; i++ {
+		marvel.Sketches = append(marvel.Sketches, &pb.Sketch{Name: proto.String(""), Type: &cardTyp, Properties: props})
+	}
+	dc := &pb.Domain{
+		Name:     proto.String("dc"),
+		Sketches: []*pb.Sketch{{Name: proto.String("dc"), Properties: props}},
+	}
+	for i, in := range []*pb.Domain{marvel, dc} {
+		var res *pb.Domain
+		var err error
+		if i == 0 {
+			res, err = client.CreateDomain(context.Background(), in)
+		} else {
+			// Sketch types are required on the wire, only the AOF holds these
+			res, err = server.createDomain(context.Background(), in)
+		}
+		if err != nil {
+			t.Fatal("Did not expect error, got", err)
+		}
+		if len(res.GetSketches()) != 4 {
+			t.Fatalf("Expected 4 sketches, got %v", res.GetSketches())
+		}
+		for _, sketch := range res.GetSketches() {
+			if sketch.GetType() == pb.SketchType_RANK && sketch.GetProperties().GetSize() != 4 {
+				t.Errorf("Expected RANK size 4, got %d", sketch.GetProperties().GetSize())
+			}
+			if sketch.GetType() == pb.SketchType_MEMB && sketch.GetProperties().GetMaxUniqueItems() != 1000 {
+				t.Errorf("Expected MEMB maxUniqueItems 1000, got %d", sketch.GetProperties().GetMaxUniqueItems())
+			}
+		}
+	}
+}
+
+func TestCreateDomainRepeatedType(t *testing.T) {
+	config.Reset()
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	client, conn := setupClient()
+	defer tearDownClient(conn)
+
+	// Sketches of a repeated type with properties of their own are not from
+	// an older client
+	cardTyp := pb.SketchType_CARD
+	name := proto.String("marvel")
+	in := &pb.Domain{
+		Name: name,
+		Sketches: []*pb.Sketch{
+			{Name: name, Type: &cardTyp, Properties: &pb.SketchProperties{ErrorRate: proto.Float32(0.05)}},
+			{Name: name, Type: &cardTyp, Properties: &pb.SketchProperties{ErrorRate: proto.Float32(0.01)}},
+		},
+	}
+	if _, err := client.CreateDomain(context.Background(), in); err == nil {
+		t.Error("Expected error creating a domain with two CARD sketches")
+	}
+	if domains, err := client.ListDomains(context.Background(), &pb.Empty{}); err != nil {
+		t.Error("Did not expect error, got", err)
+	} else if len(domains.GetNames()) != 0 {
+		t.Error("Expected no domains, got", domains.GetNames())
+	}
+}
+
+func TestCustomDomainSketches(t *testing.T) {
+	config.Reset()
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	client, conn := setupClient()
+	defer tearDownClient(conn)
+
+	cardTyp := pb.SketchType_CARD
+	rankTyp := pb.SketchType_RANK
+	membTyp := pb.SketchType_MEMB
+	name := proto.String("marvel")
+	dom := &pb.Domain{
+		Name: name,
+		Sketches: []*pb.Sketch{
+			{Name: name, Type: &cardTyp, Properties: &pb.SketchProperties{ErrorRate: proto.Float32(0.05)}},
+			{Name: name, Type: &rankTyp, Properties: &pb.SketchProperties{Size: proto.Int64(4)}},
+		},
+	}
+	res, err := client.CreateDomain(context.Background(), dom)
+	if err != nil {
+		t.Fatal("Did not expect error, got", err)
+	}
+	if len(res.GetSketches()) != 2 {
+		t.Fatalf("Expected 2 sketches, got %v", res.GetSketches())
+	}
+
+	// Sketches of the same type can not be added twice
+	dup := &pb.DomainSketchRequest{Domain: dom, Sketch: &pb.Sketch{Name: name, Type: &cardTyp}}
+	if _, err := client.AddSketchToDomain(context.Background(), dup); err == nil {
+		t.Error("Expected error adding a second CARD sketch")
+	}
+	memb := &pb.DomainSketchRequest{
+		Domain: dom,
+		Sketch: &pb.Sketch{
+			Name:       name,
+			Type:       &membTyp,
+			Properties: &pb.SketchProperties{MaxUniqueItems: proto.Int64(1000)},
+		},
+	}
+	if res, err := client.AddSketchToDomain(context.Background(), memb); err != nil {
+		t.Error("Did not expect error, got", err)
+	} else if len(res.GetSketches()) != 3 {
+		t.Errorf("Expected 3 sketches, got %v", res.GetSketches())
+	}
+	remove := &pb.DomainSketchRequest{Domain: dom, Sketch: &pb.Sketch{Name: name, Type: &cardTyp}}
+	if res, err := client.RemoveSketchFromDomain(context.Background(), remove); err != nil {
+		t.Error("Did not expect error, got", err)
+	} else if len(res.GetSketches()) != 2 {
+		t.Errorf("Expected 2 sketches, got %v", res.GetSketches())
+	}
+
+	addReq := &pb.AddRequest{
+		Domain: dom,
+		Values: []string{"hulk", "hulk", "thor"},
+	}
+	if _, err := client.Add(context.Background(), addReq); err != nil {
+		t.Error("Did not expect error, got", err)
+	}
+
+	check := func() {
+		req := &pb.QueryDomainRequest{Domain: dom, Values: []string{"hulk", "loki"}}
+		res, err := client.QueryDomain(context.Background(), req)
+		if err != nil {
+			t.Fatal("Did not expect error, got", err)
+		}
+		if res.Cardinality != nil || res.Frequencies != nil {
+			t.Errorf("Expected no cardinality nor frequencies, got %v", res)
+		}
+		if v := res.GetRankings().GetRankings(); len(v) != 2 || v[0].GetValue() != "hulk" {
+			t.Errorf("Expected [hulk thor], got %v", v)
+		}
+		if v := res.GetMemberships().GetMemberships(); len(v) != 2 || !v[0].GetIsMember() || v[1].GetIsMember() {
+			t.Errorf("Expected [true false], got %v", v)
+		}
+		for _, sketch := range res.GetDomain().GetSketches() {
+			if sketch.GetType() == pb.SketchType_RANK && sketch.GetProperties().GetSize() != 4 {
+				t.Error("Expected RANK sketch of size 4, got", sketch.GetProperties().GetSize())
+			}
+		}
+	}
+	check()
+
+	Stop()
+	go Run(manager.NewManager(), "127.0.0.1", 7777, config.DataDir)
+	time.Sleep(time.Millisecond * 50)
+	check()
+}
@@ -1,15 +1,195 @@
 package server
 
 import (
-	pb "datamodel/protobuf"
-	"errors"
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
 
 	"golang.org/x/net/context"
+
+	pb "datamodel/protobuf"
+	"storage"
+	"utils"
 )
 
+const snapshotFile = "skizze.snap"
+
+// snapshotState tracks the progress of the last requested snapshot
+type snapshotState struct {
+	sync.Mutex
+	writing   sync.Mutex // held while a snapshot is being written
+	status    pb.SnapshotStatus
+	message   string
+	timestamp int64
+	requested bool
+}
+
+func (s *snapshotState) set(status pb.SnapshotStatus, message string) {
+	s.Lock()
+	defer s.Unlock()
+	s.status = status
+	s.message = message
+}
+
+func (s *snapshotState) running() bool {
+	return s.requested &&
+		(s.status == pb.SnapshotStatus_PENDING || s.status == pb.SnapshotStatus_IN_PROGRESS)
+}
+
+// snapshotMarker returns the AOF entry recording the position of a snapshot
+func snapshotMarker(id int64) *pb.SnapshotMarker {
+	return &pb.SnapshotMarker{Id: utils.Int64p(id)}
+}
+
+// snapshot writes a point-in-time copy of the manager into the data directory
+// and compacts the AOF to only hold the operations following it
+func (s *serverStruct) snapshot(timestamp int64) error {
+	s.snapshotState.writing.Lock()
+	defer s.snapshotState.writing.Unlock()
+
+	path := filepath.Join(s.datadir, snapshotFile)
+	tmp := path + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = file.Close()
+		_ = os.Remove(tmp)
+	}()
+
+	// Block all writes while the state is being serialized so that the
+	// snapshot matches the position of its marker in the AOF exactly
+	id := time.Now().UnixNano()
+	buf := &bytes.Buffer{}
+	var saved []string
+	s.lock.Lock()
+	err = s.storage.Append(storage.Snapshot, snapshotMarker(id))
+	if err == nil {
+		saved, err = s.manager.Save(buf, timestamp)
+	}
+	s.lock.Unlock()
+	if err != nil {
+		return err
+	}
+	// The marker must be on disk before the snapshot replaces the previous
+	// one, or a crash would leave a snapshot the AOF can't be replayed onto
+	if err := s.storage.Flush(); err != nil {
+		return err
+	}
+
+	wtr := bufio.NewWriter(file)
+	if err := binary.Write(wtr, binary.BigEndian, []int64{timestamp, id}); err != nil {
+		return err
+	}
+	if _, err := wtr.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	if err := wtr.Flush(); err != nil {
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+	// Sketches created since the state was serialized are not in the snapshot
+	s.manager.SetLastSnapshot(saved, timestamp)
+
+	// The snapshot is safely on disk, a failed compaction only leaves the
+	// AOF longer than needed
+	if err := s.storage.Compact(storage.Snapshot, snapshotMarker(id)); err != nil {
+		logger.Errorf("an error has occurred while compacting the AOF: %s", err.Error())
+	}
+	return nil
+}
+
+// loadSnapshot restores the last snapshot from the data directory if any and
+// returns the AOF marker following which operations need to be replayed
+func (s *serverStruct) loadSnapshot() (*pb.SnapshotMarker, error) {
+	file, err := os.Open(filepath.Join(s.datadir, snapshotFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	rdr := bufio.NewReader(file)
+	header := make([]int64, 2)
+	if err := binary.Read(rdr, binary.BigEndian, header); err == io.EOF {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	// The sketches were saved with their LastSnapshot set
+	if err := s.manager.Load(rdr); err != nil {
+		return nil, err
+	}
+	s.snapshotState.status = pb.SnapshotStatus_SUCCESSFUL
+	s.snapshotState.timestamp = header[0]
+	s.snapshotState.requested = true
+	logger.Infof("Loaded snapshot from %s", time.Unix(header[0], 0))
+	return snapshotMarker(header[1]), nil
+}
+
 func (s *serverStruct) CreateSnapshot(ctx context.Context, in *pb.CreateSnapshotRequest) (*pb.CreateSnapshotReply, error) {
-	//err := s.manager.Save()
-	// FIXME: return a snapshot ID
-	status := pb.SnapshotStatus_FAILED
-	return &pb.CreateSnapshotReply{Status: &status}, errors.New("Snapshots not supported (yet)!")
+	s.snapshotState.Lock()
+	defer s.snapshotState.Unlock()
+	if s.snapshotState.running() {
+		status := s.snapshotState.status
+		return &pb.CreateSnapshotReply{
+			Status:        &status,
+			StatusMessage: utils.Stringp("A snapshot is already in progress"),
+		}, nil
+	}
+
+	timestamp := time.Now().Unix()
+	s.snapshotState.status = pb.SnapshotStatus_PENDING
+	s.snapshotState.message = ""
+	s.snapshotState.timestamp = timestamp
+	s.snapshotState.requested = true
+
+	go func() {
+		s.snapshotState.set(pb.SnapshotStatus_IN_PROGRESS, "")
+		if err := s.snapshot(timestamp); err != nil {
+			logger.Errorf("an error has occurred while creating a snapshot: %s", err.Error())
+			s.snapshotState.set(pb.SnapshotStatus_FAILED, err.Error())
+			return
+		}
+		s.snapshotState.set(pb.SnapshotStatus_SUCCESSFUL, "")
+	}()
+
+	status := pb.SnapshotStatus_PENDING
+	return &pb.CreateSnapshotReply{Status: &status}, nil
+}
+
+func (s *serverStruct) GetSnapshot(ctx context.Context, in *pb.GetSnapshotRequest) (*pb.GetSnapshotReply, error) {
+	s.snapshotState.Lock()
+	defer s.snapshotState.Unlock()
+	if !s.snapshotState.requested {
+		status := pb.SnapshotStatus_FAILED
+		return &pb.GetSnapshotReply{
+			Status:        &status,
+			StatusMessage: utils.Stringp("No snapshot has been created"),
+		}, nil
+	}
+	status := s.snapshotState.status
+	reply := &pb.GetSnapshotReply{
+		Status:    &status,
+		Timestamp: utils.Int64p(s.snapshotState.timestamp),
+	}
+	if len(s.snapshotState.message) > 0 {
+		reply.StatusMessage = utils.Stringp(s.snapshotState.message)
+	}
+	return reply, nil
 }
@@ -0,0 +1,158 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/context"
+
+	pb "datamodel/protobuf"
+)
+
+// httpPrefix is the path of the JSON endpoints, it is followed by the name of
+// the RPC, e.g. POST /v1/CreateSketch
+const httpPrefix = "/v1/"
+
+// httpMethod calls an unary RPC of the Skizze service
+type httpMethod struct {
+	request reflect.Type // the request message type, not a pointer
+	call    reflect.Value
+}
+
+// httpHandler serves the RPCs of s as JSON endpoints, requests and replies are
+// the JSON encoding of the protobuf messages. AddStream takes and returns
+// newline delimited messages.
+type httpHandler struct {
+	server  *serverStruct
+	methods map[string]httpMethod
+}
+
+func newHTTPHandler(s *serverStruct) *httpHandler {
+	h := &httpHandler{
+		server:  s,
+		methods: make(map[string]httpMethod),
+	}
+	service := reflect.TypeOf((*pb.SkizzeServer)(nil)).Elem()
+	ctxType := reflect.TypeOf((*context.Context)(nil)).Elem()
+	for i := 0; i < service.NumMethod(); i++ {
+		m := service.Method(i)
+		// Streaming RPCs only take the stream
+		if m.Type.NumIn() != 2 || m.Type.In(0) != ctxType {
+			continue
+		}
+		h.methods[m.Name] = httpMethod{
+			request: m.Type.In(1).Elem(),
+			call:    reflect.ValueOf(s).MethodByName(m.Name),
+		}
+	}
+	return h
+}
+
+func (h *httpHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" && r.Method != "POST" {
+		httpError(w, http.StatusMethodNotAllowed, fmt.Errorf("Method %s not allowed", r.Method))
+		return
+	}
+	name := strings.TrimPrefix(r.URL.Path, httpPrefix)
+	if name == "AddStream" {
+		h.addStream(w, r)
+		return
+	}
+	method, ok := h.methods[name]
+	if !ok || !strings.HasPrefix(r.URL.Path, httpPrefix) {
+		httpError(w, http.StatusNotFound, fmt.Errorf("No such method %s", r.URL.Path))
+		return
+	}
+
+	in := reflect.New(method.request).Interface().(proto.Message)
+	if err := jsonpb.Unmarshal(r.Body, in); err != nil && err != io.EOF {
+		httpError(w, http.StatusBadRequest, err)
+		return
+	}
+	out := method.call.Call([]reflect.Value{reflect.ValueOf(r.Context()), reflect.ValueOf(in)})
+	if err, _ := out[1].Interface().(error); err != nil {
+		httpError(w, httpStatus(err), err)
+		return
+	}
+	if err := writeJSON(w, out[0].Interface().(proto.Message)); err != nil {
+		logger.Errorf("an error has occurred while writing a reply: %s", err.Error())
+	}
+}
+
+// addStream applies the add requests of the body and writes the acks as they
+// are sent
+func (h *httpHandler) addStream(w http.ResponseWriter, r *http.Request) {
+	// Acks are written while the body is still being read
+	_ = http.NewResponseController(w).EnableFullDuplex()
+	dec := json.NewDecoder(r.Body)
+	recv := func() (*pb.AddRequest, error) {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, err
+		}
+		in := &pb.AddRequest{}
+		return in, jsonpb.Unmarshal(bytes.NewReader(raw), in)
+	}
+	started := false
+	send := func(ack *pb.AddStreamReply) error {
+		started = true
+		if err := writeJSON(w, ack); err != nil {
+			return err
+		}
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+		return nil
+	}
+	if err := h.server.addStream(r.Context(), recv, send); err != nil {
+		if started {
+			// The status was sent with the first ack already
+			logger.Errorf("an error has occurred while streaming adds: %s", err.Error())
+			return
+		}
+		httpError(w, http.StatusBadRequest, err)
+	}
+}
+
+// httpStatus maps the errors of the RPCs to HTTP status codes
+func httpStatus(err error) int {
+	var pathErr *os.PathError
+	var syscallErr *os.SyscallError
+	if errors.As(err, &pathErr) || errors.As(err, &syscallErr) {
+		return http.StatusInternalServerError
+	}
+	msg := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(msg, "does not exist"), strings.Contains(msg, "no such"),
+		strings.Contains(msg, "could not find"), strings.Contains(msg, "holds no"):
+		return http.StatusNotFound
+	case strings.Contains(msg, "already"):
+		return http.StatusConflict
+	}
+	return http.StatusBadRequest
+}
+
+func writeJSON(w http.ResponseWriter, msg proto.Message) error {
+	w.Header().Set("Content-Type", "application/json")
+	m := jsonpb.Marshaler{}
+	if err := m.Marshal(w, msg); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func httpError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"config"
+	pb "datamodel/protobuf"
+	"testutils"
+
+	"github.com/golang/protobuf/jsonpb"
+)
+
+func postJSON(t *testing.T, method, body string) (*http.Response, string) {
+	res, err := http.Post("http://127.0.0.1:7778/v1/"+method, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal("Did not expect error, got", err)
+	}
+	defer func() { _ = res.Body.Close() }()
+	reply, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal("Did not expect error, got", err)
+	}
+	return res, string(reply)
+}
+
+func TestHTTP(t *testing.T) {
+	config.Reset()
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+	config.HTTPPort = 7778
+	defer func() { config.HTTPPort = 0 }()
+
+	_, conn := setupClient()
+	defer tearDownClient(conn)
+
+	sketch := `{"name": "heroes", "type": "FREQ", "properties": {"maxUniqueItems": 1000}}`
+	if res, body := postJSON(t, "CreateSketch", sketch); res.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", res.StatusCode, body)
+	}
+	if res, body := postJSON(t, "CreateSketch", sketch); res.StatusCode != http.StatusConflict {
+		t.Errorf("Expected status 409 creating a sketch twice, got %d: %s", res.StatusCode, body)
+	}
+
+	add := `{"sketch": {"name": "heroes", "type": "FREQ"}, "values": ["hulk", "thor", "hulk"]}`
+	if res, body := postJSON(t, "Add", add); res.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200, got %d: %s", res.StatusCode, body)
+	}
+
+	get := `{"sketches": [{"name": "heroes", "type": "FREQ"}], "values": ["hulk"]}`
+	res, body := postJSON(t, "GetFrequency", get)
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", res.StatusCode, body)
+	}
+	reply := &pb.GetFrequencyReply{}
+	if err := jsonpb.UnmarshalString(body, reply); err != nil {
+		t.Fatal("Did not expect error, got", err)
+	}
+	if c := reply.GetResults()[0].GetFrequencies()[0].GetCount(); c != 2 {
+		t.Error("Expected hulk == 2, got", c)
+	}
+
+	if res, body := postJSON(t, "GetSketch", `{"name": "villains", "type": "FREQ"}`); res.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status 404 for a missing sketch, got %d: %s", res.StatusCode, body)
+	}
+	if res, body := postJSON(t, "GetSketch", `{"name": `); res.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for invalid JSON, got %d: %s", res.StatusCode, body)
+	}
+	if res, body := postJSON(t, "Destroy", `{}`); res.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown method, got %d: %s", res.StatusCode, body)
+	}
+
+	// Newline delimited add requests, the final ack closes the response
+	res, body = postJSON(t, "AddStream", strings.Repeat(add+"\n", 3))
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", res.StatusCode, body)
+	}
+	ack := &pb.AddStreamReply{}
+	if err := jsonpb.UnmarshalString(body, ack); err != nil {
+		t.Fatal("Did not expect error, got", err)
+	}
+	if ack.GetRequests() != 3 || ack.GetValues() != 9 {
+		t.Errorf("Expected 3 requests and 9 values, got %v", ack)
+	}
+}
@@ -0,0 +1,197 @@
+package server
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Limits of the commands read from RESP clients
+const (
+	maxRESPArgs     = 1024 * 1024
+	maxRESPBulkSize = 512 * 1024 * 1024
+)
+
+var errRESPProtocol = errors.New("Protocol error")
+
+// respServer serves the probabilistic commands of the Redis protocol (RESP),
+// see resp_commands.go
+type respServer struct {
+	server *serverStruct
+	lis    net.Listener
+	lock   sync.Mutex
+	conns  map[net.Conn]struct{}
+	wg     sync.WaitGroup
+	create sync.Mutex // serializes the creation of missing sketches by commands
+}
+
+// runRESP serves the RESP front end on host:port in the background
+func (s *serverStruct) runRESP(host string, port int) {
+	lis, err := net.Listen("tcp", fmt.Sprintf("%s:%d", host, port))
+	if err != nil {
+		logger.Criticalf("failed to listen: %v", err)
+		return
+	}
+	s.resp = &respServer{
+		server: s,
+		lis:    lis,
+		conns:  make(map[net.Conn]struct{}),
+	}
+	s.resp.wg.Add(1)
+	go s.resp.serve()
+}
+
+func (r *respServer) serve() {
+	defer r.wg.Done()
+	for {
+		conn, err := r.lis.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				logger.Errorf("an error has occurred while serving RESP: %s", err.Error())
+			}
+			return
+		}
+		r.lock.Lock()
+		r.conns[conn] = struct{}{}
+		r.lock.Unlock()
+		r.wg.Add(1)
+		go r.handle(conn)
+	}
+}
+
+// handle runs the commands of a connection until the client quits or closes it
+func (r *respServer) handle(conn net.Conn) {
+	defer r.wg.Done()
+	defer func() {
+		r.lock.Lock()
+		delete(r.conns, conn)
+		r.lock.Unlock()
+		_ = conn.Close()
+	}()
+	reader := &respReader{bufio.NewReader(conn)}
+	w := &respWriter{bufio.NewWriter(conn)}
+	for {
+		args, err := reader.readCommand()
+		if err != nil {
+			if err == errRESPProtocol {
+				w.error(err)
+				_ = w.Flush()
+			}
+			return
+		}
+		if len(args) == 0 {
+			continue
+		}
+		quit := r.server.runRESPCommand(w, args)
+		// Pipelined commands are replied to at once
+		if reader.Buffered() == 0 || quit {
+			if err := w.Flush(); err != nil || quit {
+				return
+			}
+		}
+	}
+}
+
+// stop closes the listener and the open connections and waits for the
+// commands being run
+func (r *respServer) stop() {
+	_ = r.lis.Close()
+	r.lock.Lock()
+	for conn := range r.conns {
+		_ = conn.Close()
+	}
+	r.lock.Unlock()
+	r.wg.Wait()
+}
+
+// respReader reads the commands sent by RESP clients
+type respReader struct {
+	*bufio.Reader
+}
+
+// readCommand reads either an array of bulk strings or an inline command as
+// typed in a telnet session
+func (r *respReader) readCommand() ([]string, error) {
+	line, err := r.readLine()
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "*") {
+		return strings.Fields(line), nil
+	}
+	n, err := strconv.Atoi(line[1:])
+	if err != nil || n > maxRESPArgs {
+		return nil, errRESPProtocol
+	}
+	if n <= 0 {
+		return nil, nil
+	}
+	args := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		line, err := r.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(line, "$") {
+			return nil, errRESPProtocol
+		}
+		size, err := strconv.Atoi(line[1:])
+		if err != nil || size < 0 || size > maxRESPBulkSize {
+			return nil, errRESPProtocol
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		if buf[size] != '\r' || buf[size+1] != '\n' {
+			return nil, errRESPProtocol
+		}
+		args = append(args, string(buf[:size]))
+	}
+	return args, nil
+}
+
+func (r *respReader) readLine() (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// respWriter writes the replies to RESP clients, they are buffered until
+// flushed
+type respWriter struct {
+	*bufio.Writer
+}
+
+func (w *respWriter) simple(s string) {
+	_, _ = w.WriteString("+" + s + "\r\n")
+}
+
+func (w *respWriter) error(err error) {
+	msg := strings.NewReplacer("\r", " ", "\n", " ").Replace(err.Error())
+	_, _ = w.WriteString("-ERR " + msg + "\r\n")
+}
+
+func (w *respWriter) integer(n int64) {
+	_, _ = w.WriteString(":" + strconv.FormatInt(n, 10) + "\r\n")
+}
+
+func (w *respWriter) bulk(s string) {
+	_, _ = w.WriteString("$" + strconv.Itoa(len(s)) + "\r\n" + s + "\r\n")
+}
+
+func (w *respWriter) null() {
+	_, _ = w.WriteString("$-1\r\n")
+}
+
+// array starts an array of n elements, they are written next
+func (w *respWriter) array(n int) {
+	_, _ = w.WriteString("*" + strconv.Itoa(n) + "\r\n")
+}
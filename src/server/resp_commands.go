@@ -0,0 +1,417 @@
+package server
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gogo/protobuf/proto"
+	"golang.org/x/net/context"
+
+	"config"
+	"datamodel"
+	pb "datamodel/protobuf"
+)
+
+// respCommand runs a RESP command, its args exclude the command name. The
+// reply is written to w unless an error is returned, which is then replied.
+type respCommand struct {
+	minArgs int
+	maxArgs int // -1 for no limit
+	run     func(s *serverStruct, w *respWriter, args []string) error
+}
+
+// respCommands maps the commands of HyperLogLog, RedisBloom Bloom filters,
+// count-min sketches and top-k to CARD, MEMB, FREQ and RANK sketches named
+// after the keys. Keys of different types are different sketches.
+var respCommands = map[string]respCommand{
+	"PING":       {0, 1, respPing},
+	"COMMAND":    {0, -1, respCommandInfo},
+	"PFADD":      {1, -1, respPFAdd},
+	"PFCOUNT":    {1, -1, respPFCount},
+	"PFMERGE":    {1, -1, respPFMerge},
+	"BF.ADD":     {2, 2, respBFAdd},
+	"BF.MADD":    {2, -1, respBFMAdd},
+	"BF.EXISTS":  {2, 2, respBFExists},
+	"BF.MEXISTS": {2, -1, respBFMExists},
+	"CMS.INCRBY": {3, -1, respCMSIncrBy},
+	"CMS.QUERY":  {2, -1, respCMSQuery},
+	"TOPK.ADD":   {2, -1, respTopKAdd},
+	"TOPK.LIST":  {1, 2, respTopKList},
+}
+
+// runRESPCommand runs the command args and writes its reply, it returns
+// whether the client quit
+func (s *serverStruct) runRESPCommand(w *respWriter, args []string) bool {
+	name := strings.ToUpper(args[0])
+	if name == "QUIT" {
+		w.simple("OK")
+		return true
+	}
+	cmd, ok := respCommands[name]
+	if !ok {
+		w.error(fmt.Errorf("unknown command '%s'", args[0]))
+		return false
+	}
+	if n := len(args) - 1; n < cmd.minArgs || (cmd.maxArgs >= 0 && n > cmd.maxArgs) {
+		w.error(fmt.Errorf("wrong number of arguments for '%s' command", strings.ToLower(args[0])))
+		return false
+	}
+	if err := cmd.run(s, w, args[1:]); err != nil {
+		w.error(err)
+	}
+	return false
+}
+
+// respProperties returns the properties of the sketches created by RESP
+// commands
+func respProperties(typ pb.SketchType) *pb.SketchProperties {
+	props := &pb.SketchProperties{}
+	switch typ {
+	case pb.SketchType_MEMB, pb.SketchType_FREQ:
+		props.MaxUniqueItems = proto.Int64(config.RESPMaxUniqueItems)
+	case pb.SketchType_RANK:
+		props.Size = proto.Int64(config.RESPRankSize)
+	}
+	return props
+}
+
+// respSketch returns the sketch of type typ named after key, or nil if it does
+// not exist. With create set a missing sketch is created with the RESP
+// properties and created is true.
+func (s *serverStruct) respSketch(key string, typ pb.SketchType, create bool) (sketch *pb.Sketch, created bool, err error) {
+	sketch = &pb.Sketch{Name: proto.String(key), Type: &typ}
+	id := (&datamodel.Info{Sketch: sketch}).ID()
+	if _, err := s.manager.GetSketch(id); err == nil {
+		return sketch, false, nil
+	}
+	if !create {
+		return nil, false, nil
+	}
+	// Another client may have just created it, check again once no other
+	// client can create it so that it is created and appended to the AOF once
+	s.resp.create.Lock()
+	defer s.resp.create.Unlock()
+	if _, err := s.manager.GetSketch(id); err == nil {
+		return sketch, false, nil
+	}
+	in := &pb.Sketch{Name: proto.String(key), Type: &typ, Properties: respProperties(typ)}
+	if _, err := s.CreateSketch(context.Background(), in); err != nil {
+		return nil, false, err
+	}
+	return sketch, true, nil
+}
+
+// respSketches returns the existing sketches of type typ named after keys
+func (s *serverStruct) respSketches(keys []string, typ pb.SketchType) ([]*pb.Sketch, error) {
+	var sketches []*pb.Sketch
+	for _, key := range keys {
+		sketch, _, err := s.respSketch(key, typ, false)
+		if err != nil {
+			return nil, err
+		}
+		if sketch != nil {
+			sketches = append(sketches, sketch)
+		}
+	}
+	return sketches, nil
+}
+
+func (s *serverStruct) respAdd(in *pb.AddRequest) (*pb.AddResult, error) {
+	reply, err := s.Add(context.Background(), in)
+	if err != nil {
+		return nil, err
+	}
+	return reply.GetResults()[0], nil
+}
+
+// respCardinality returns the cardinality of the union of the CARD sketches
+func (s *serverStruct) respCardinality(sketches []*pb.Sketch) (int64, error) {
+	ids := make([]string, len(sketches))
+	for i, sketch := range sketches {
+		ids[i] = (&datamodel.Info{Sketch: sketch}).ID()
+	}
+	var res interface{}
+	var err error
+	if len(ids) == 1 {
+		res, err = s.manager.GetFromSketch(ids[0], nil)
+	} else {
+		res, err = s.manager.GetFromUnion(ids, nil)
+	}
+	if err != nil {
+		return 0, err
+	}
+	card, ok := res.(*pb.CardinalityResult)
+	if !ok {
+		return 0, fmt.Errorf("Unexpected result %T for sketches %v", res, ids)
+	}
+	return card.GetCardinality(), nil
+}
+
+// respMemberships returns whether the values are members of the MEMB sketch
+// key, none of them are if it does not exist
+func (s *serverStruct) respMemberships(key string, values []string) ([]bool, error) {
+	members := make([]bool, len(values))
+	sketch, _, err := s.respSketch(key, pb.SketchType_MEMB, false)
+	if err != nil || sketch == nil {
+		return members, err
+	}
+	in := &pb.GetRequest{Sketches: []*pb.Sketch{sketch}, Values: values}
+	reply, err := s.GetMembership(context.Background(), in)
+	if err != nil {
+		return nil, err
+	}
+	for i, m := range reply.GetResults()[0].GetMemberships() {
+		members[i] = m.GetIsMember()
+	}
+	return members, nil
+}
+
+// respInsert adds the values to the MEMB sketch key and returns whether each
+// of them was new to it
+func (s *serverStruct) respInsert(key string, values []string) ([]bool, error) {
+	sketch, _, err := s.respSketch(key, pb.SketchType_MEMB, true)
+	if err != nil {
+		return nil, err
+	}
+	res, err := s.respAdd(&pb.AddRequest{Sketch: sketch, Values: values})
+	if err != nil {
+		return nil, err
+	}
+	inserted := make([]bool, len(values))
+	for i, ins := range res.GetInsertions() {
+		if i < len(inserted) {
+			inserted[i] = ins.GetInserted()
+		}
+	}
+	return inserted, nil
+}
+
+// respFrequencies returns the counts of the values in the FREQ sketch key
+func (s *serverStruct) respFrequencies(key string, values []string) ([]int64, error) {
+	typ := pb.SketchType_FREQ
+	in := &pb.GetRequest{
+		Sketches: []*pb.Sketch{{Name: proto.String(key), Type: &typ}},
+		Values:   values,
+	}
+	reply, err := s.GetFrequency(context.Background(), in)
+	if err != nil {
+		return nil, err
+	}
+	counts := make([]int64, len(values))
+	for i, f := range reply.GetResults()[0].GetFrequencies() {
+		counts[i] = f.GetCount()
+	}
+	return counts, nil
+}
+
+func boolInt(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func respPing(s *serverStruct, w *respWriter, args []string) error {
+	if len(args) == 1 {
+		w.bulk(args[0])
+		return nil
+	}
+	w.simple("PONG")
+	return nil
+}
+
+// respCommandInfo replies to COMMAND, sent by redis-cli on start, with no
+// command documentation
+func respCommandInfo(s *serverStruct, w *respWriter, args []string) error {
+	w.array(0)
+	return nil
+}
+
+// respPFAdd replies 1 if the sketch was created or its cardinality changed
+func respPFAdd(s *serverStruct, w *respWriter, args []string) error {
+	sketch, created, err := s.respSketch(args[0], pb.SketchType_CARD, true)
+	if err != nil {
+		return err
+	}
+	changed := created
+	if len(args) > 1 {
+		res, err := s.respAdd(&pb.AddRequest{Sketch: sketch, Values: args[1:]})
+		if err != nil {
+			return err
+		}
+		changed = changed || res.GetChanged()
+	}
+	w.integer(boolInt(changed))
+	return nil
+}
+
+// respPFCount replies the cardinality of the union of the keys, missing keys
+// are empty
+func respPFCount(s *serverStruct, w *respWriter, args []string) error {
+	sketches, err := s.respSketches(args, pb.SketchType_CARD)
+	if err != nil {
+		return err
+	}
+	card := int64(0)
+	if len(sketches) > 0 {
+		if card, err = s.respCardinality(sketches); err != nil {
+			return err
+		}
+	}
+	w.integer(card)
+	return nil
+}
+
+// respPFMerge merges the source keys into the destination key, missing source
+// keys are empty
+func respPFMerge(s *serverStruct, w *respWriter, args []string) error {
+	dest, _, err := s.respSketch(args[0], pb.SketchType_CARD, true)
+	if err != nil {
+		return err
+	}
+	var keys []string
+	for _, key := range args[1:] {
+		if key != args[0] {
+			keys = append(keys, key)
+		}
+	}
+	sources, err := s.respSketches(keys, pb.SketchType_CARD)
+	if err != nil {
+		return err
+	}
+	if len(sources) > 0 {
+		in := &pb.MergeRequest{Destination: dest, Sources: sources}
+		if _, err := s.Merge(context.Background(), in); err != nil {
+			return err
+		}
+	}
+	w.simple("OK")
+	return nil
+}
+
+func respBFAdd(s *serverStruct, w *respWriter, args []string) error {
+	inserted, err := s.respInsert(args[0], args[1:])
+	if err != nil {
+		return err
+	}
+	w.integer(boolInt(inserted[0]))
+	return nil
+}
+
+func respBFMAdd(s *serverStruct, w *respWriter, args []string) error {
+	inserted, err := s.respInsert(args[0], args[1:])
+	if err != nil {
+		return err
+	}
+	w.array(len(inserted))
+	for _, b := range inserted {
+		w.integer(boolInt(b))
+	}
+	return nil
+}
+
+func respBFExists(s *serverStruct, w *respWriter, args []string) error {
+	members, err := s.respMemberships(args[0], args[1:])
+	if err != nil {
+		return err
+	}
+	w.integer(boolInt(members[0]))
+	return nil
+}
+
+func respBFMExists(s *serverStruct, w *respWriter, args []string) error {
+	members, err := s.respMemberships(args[0], args[1:])
+	if err != nil {
+		return err
+	}
+	w.array(len(members))
+	for _, b := range members {
+		w.integer(boolInt(b))
+	}
+	return nil
+}
+
+// respCMSIncrBy takes pairs of items and increments and replies the counts of
+// the items once incremented
+func respCMSIncrBy(s *serverStruct, w *respWriter, args []string) error {
+	pairs := args[1:]
+	if len(pairs)%2 != 0 {
+		return fmt.Errorf("wrong number of arguments for 'cms.incrby' command")
+	}
+	items := make([]string, 0, len(pairs)/2)
+	weighted := make([]*pb.WeightedValue, 0, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		incr, err := strconv.ParseInt(pairs[i+1], 10, 64)
+		if err != nil || incr <= 0 {
+			return fmt.Errorf("Invalid increment %s for item %s", pairs[i+1], pairs[i])
+		}
+		items = append(items, pairs[i])
+		weighted = append(weighted, &pb.WeightedValue{Value: proto.String(pairs[i]), Count: proto.Int64(incr)})
+	}
+	sketch, _, err := s.respSketch(args[0], pb.SketchType_FREQ, true)
+	if err != nil {
+		return err
+	}
+	if _, err := s.respAdd(&pb.AddRequest{Sketch: sketch, WeightedValues: weighted}); err != nil {
+		return err
+	}
+	return respCMSQuery(s, w, append([]string{args[0]}, items...))
+}
+
+func respCMSQuery(s *serverStruct, w *respWriter, args []string) error {
+	counts, err := s.respFrequencies(args[0], args[1:])
+	if err != nil {
+		return err
+	}
+	w.array(len(counts))
+	for _, c := range counts {
+		w.integer(c)
+	}
+	return nil
+}
+
+// respTopKAdd replies a nil item expelled from the top-k list for each item
+// added as expelled items are not tracked
+func respTopKAdd(s *serverStruct, w *respWriter, args []string) error {
+	sketch, _, err := s.respSketch(args[0], pb.SketchType_RANK, true)
+	if err != nil {
+		return err
+	}
+	if _, err := s.respAdd(&pb.AddRequest{Sketch: sketch, Values: args[1:]}); err != nil {
+		return err
+	}
+	w.array(len(args) - 1)
+	for range args[1:] {
+		w.null()
+	}
+	return nil
+}
+
+// respTopKList replies the items of the top-k list, followed by their counts
+// with WITHCOUNT
+func respTopKList(s *serverStruct, w *respWriter, args []string) error {
+	withCount := len(args) == 2
+	if withCount && strings.ToUpper(args[1]) != "WITHCOUNT" {
+		return fmt.Errorf("syntax error")
+	}
+	typ := pb.SketchType_RANK
+	in := &pb.GetRequest{Sketches: []*pb.Sketch{{Name: proto.String(args[0]), Type: &typ}}}
+	reply, err := s.GetRankings(context.Background(), in)
+	if err != nil {
+		return err
+	}
+	rankings := reply.GetResults()[0].GetRankings()
+	if withCount {
+		w.array(2 * len(rankings))
+	} else {
+		w.array(len(rankings))
+	}
+	for _, r := range rankings {
+		w.bulk(r.GetValue())
+		if withCount {
+			w.integer(r.GetCount())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"config"
+	"testutils"
+)
+
+// respClient sends commands as arrays of bulk strings and reads the replies,
+// errors are returned as error values and null bulk strings as nil
+type respClient struct {
+	t    *testing.T
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func (c *respClient) do(args ...string) interface{} {
+	cmd := "*" + strconv.Itoa(len(args)) + "\r\n"
+	for _, arg := range args {
+		cmd += "$" + strconv.Itoa(len(arg)) + "\r\n" + arg + "\r\n"
+	}
+	if _, err := c.conn.Write([]byte(cmd)); err != nil {
+		c.t.Fatal("Did not expect error, got", err)
+	}
+	return c.read()
+}
+
+func (c *respClient) read() interface{} {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		c.t.Fatal("Did not expect error, got", err)
+	}
+	line = line[:len(line)-2]
+	switch line[0] {
+	case '+':
+		return line[1:]
+	case '-':
+		return fmt.Errorf("%s", line[1:])
+	case ':':
+		n, _ := strconv.ParseInt(line[1:], 10, 64)
+		return n
+	case '$':
+		n, _ := strconv.Atoi(line[1:])
+		if n < 0 {
+			return nil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(c.r, buf); err != nil {
+			c.t.Fatal("Did not expect error, got", err)
+		}
+		return string(buf[:n])
+	case '*':
+		n, _ := strconv.Atoi(line[1:])
+		res := make([]interface{}, n)
+		for i := range res {
+			res[i] = c.read()
+		}
+		return res
+	}
+	c.t.Fatalf("Unexpected reply %q", line)
+	return nil
+}
+
+func TestRESP(t *testing.T) {
+	config.Reset()
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+	config.RESPPort = 7779
+	defer func() { config.RESPPort = 0 }()
+
+	_, conn := setupClient()
+	defer tearDownClient(conn)
+
+	c, err := net.Dial("tcp", "127.0.0.1:7779")
+	if err != nil {
+		t.Fatal("Did not expect error, got", err)
+	}
+	defer func() { _ = c.Close() }()
+	client := &respClient{t, c, bufio.NewReader(c)}
+
+	expect := func(res interface{}, expected interface{}) {
+		if !reflect.DeepEqual(res, expected) {
+			t.Errorf("Expected %#v, got %#v", expected, res)
+		}
+	}
+
+	expect(client.do("PING"), "PONG")
+
+	expect(client.do("PFADD", "heroes", "hulk", "thor", "hulk"), int64(1))
+	expect(client.do("PFADD", "heroes", "thor"), int64(0))
+	expect(client.do("PFADD", "villains", "loki", "thanos"), int64(1))
+	expect(client.do("PFCOUNT", "heroes"), int64(2))
+	expect(client.do("PFCOUNT", "heroes", "villains", "missing"), int64(4))
+	expect(client.do("PFCOUNT", "missing"), int64(0))
+	expect(client.do("PFMERGE", "all", "heroes", "villains"), "OK")
+	expect(client.do("PFCOUNT", "all"), int64(4))
+	// The CARD sketches went through the AOF like the RPCs
+	if _, err := server.manager.GetSketch("all.CARD"); err != nil {
+		t.Error("Did not expect error, got", err)
+	}
+
+	expect(client.do("BF.ADD", "heroes", "hulk"), int64(1))
+	expect(client.do("BF.ADD", "heroes", "hulk"), int64(0))
+	expect(client.do("BF.MADD", "heroes", "thor", "hulk"), []interface{}{int64(1), int64(0)})
+	expect(client.do("BF.EXISTS", "heroes", "thor"), int64(1))
+	expect(client.do("BF.MEXISTS", "heroes", "thor", "loki"), []interface{}{int64(1), int64(0)})
+	expect(client.do("BF.EXISTS", "missing", "thor"), int64(0))
+
+	expect(client.do("CMS.INCRBY", "heroes", "hulk", "3", "thor", "1"), []interface{}{int64(3), int64(1)})
+	expect(client.do("CMS.INCRBY", "heroes", "hulk", "2"), []interface{}{int64(5)})
+	expect(client.do("CMS.QUERY", "heroes", "hulk", "loki"), []interface{}{int64(5), int64(0)})
+	if _, ok := client.do("CMS.INCRBY", "heroes", "hulk", "-1").(error); !ok {
+		t.Error("Expected error for a negative increment")
+	}
+	if _, ok := client.do("CMS.QUERY", "missing", "hulk").(error); !ok {
+		t.Error("Expected error querying a missing key")
+	}
+
+	expect(client.do("TOPK.ADD", "heroes", "hulk", "thor", "hulk"), []interface{}{nil, nil, nil})
+	list := client.do("TOPK.LIST", "heroes", "WITHCOUNT").([]interface{})
+	if len(list) < 2 || list[0] != "hulk" || list[1] != int64(2) {
+		t.Errorf("Expected hulk first with count 2, got %v", list)
+	}
+
+	if _, ok := client.do("BF.ADD", "heroes").(error); !ok {
+		t.Error("Expected error for a missing argument")
+	}
+	if _, ok := client.do("SET", "heroes", "hulk").(error); !ok {
+		t.Error("Expected error for an unknown command")
+	}
+	expect(client.do("QUIT"), "OK")
+}
+
+func TestRESPCreateOnce(t *testing.T) {
+	config.Reset()
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+	config.RESPPort = 7779
+	defer func() { config.RESPPort = 0 }()
+
+	_, conn := setupClient()
+	defer tearDownClient(conn)
+
+	// Clients racing to create a missing key create it once
+	replies := make(chan interface{})
+	for i := 0; i < 10; i++ {
+		go func() {
+			c, err := net.Dial("tcp", "127.0.0.1:7779")
+			if err != nil {
+				replies <- err
+				return
+			}
+			defer func() { _ = c.Close() }()
+			client := &respClient{t, c, bufio.NewReader(c)}
+			replies <- client.do("PFADD", "heroes")
+		}()
+	}
+	created := 0
+	for i := 0; i < 10; i++ {
+		switch res := <-replies; res {
+		case int64(1):
+			created++
+		case int64(0):
+		default:
+			t.Error("Expected 0 or 1, got", res)
+		}
+	}
+	if created != 1 {
+		t.Errorf("Expected the key to be created once, got %d", created)
+	}
+}
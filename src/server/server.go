@@ -3,12 +3,16 @@ package server
 import (
 	"fmt"
 	"net"
+	"net/http"
 	"path/filepath"
 	"runtime"
+	"sync"
+	"time"
 
 	"github.com/golang/protobuf/proto"
 	"google.golang.org/grpc"
 
+	"config"
 	pb "datamodel/protobuf"
 	"manager"
 	"storage"
@@ -18,9 +22,14 @@ import (
 )
 
 type serverStruct struct {
-	manager *manager.Manager
-	g       *grpc.Server
-	storage *storage.AOF
+	manager       *manager.Manager
+	g             *grpc.Server
+	http          *http.Server // nil unless the HTTP/JSON API is enabled
+	resp          *respServer  // nil unless the RESP front end is enabled
+	storage       *storage.AOF
+	datadir       string
+	lock          sync.RWMutex // held for writing while taking a snapshot
+	snapshotState snapshotState
 }
 
 var server *serverStruct
@@ -38,13 +47,43 @@ func Run(manager *manager.Manager, host string, port int, datadir string) {
 	}
 	g := grpc.NewServer()
 
-	server = &serverStruct{manager, g, aof}
+	server = &serverStruct{
+		manager: manager,
+		g:       g,
+		storage: aof,
+		datadir: datadir,
+	}
 	pb.RegisterSkizzeServer(g, server)
-	server.replay()
+	marker, err := server.loadSnapshot()
+	utils.PanicOnError(err)
+	server.replay(marker)
 	aof.Run()
+	if config.HTTPPort != 0 {
+		server.runHTTP(config.HTTPHost, config.HTTPPort)
+	}
+	if config.RESPPort != 0 {
+		server.runRESP(config.RESPHost, config.RESPPort)
+	}
 	_ = g.Serve(lis)
 }
 
+// runHTTP serves the HTTP/JSON API on host:port in the background
+func (s *serverStruct) runHTTP(host string, port int) {
+	lis, err := net.Listen("tcp", fmt.Sprintf("%s:%d", host, port))
+	if err != nil {
+		logger.Criticalf("failed to listen: %v", err)
+		return
+	}
+	mux := http.NewServeMux()
+	mux.Handle(httpPrefix, newHTTPHandler(s))
+	s.http = &http.Server{Handler: mux}
+	go func() {
+		if err := s.http.Serve(lis); err != nil && err != http.ErrServerClosed {
+			logger.Errorf("an error has occurred while serving HTTP: %s", err.Error())
+		}
+	}()
+}
+
 func unmarshalSketch(e *storage.Entry) *pb.Sketch {
 	sketch := &pb.Sketch{}
 	err := proto.Unmarshal(e.RawMsg(), sketch)
@@ -59,8 +98,18 @@ func unmarshalDom(e *storage.Entry) *pb.Domain {
 	return dom
 }
 
-func (server *serverStruct) replay() {
+func unmarshalMarker(e *storage.Entry) *pb.SnapshotMarker {
+	marker := &pb.SnapshotMarker{}
+	err := proto.Unmarshal(e.RawMsg(), marker)
+	utils.PanicOnError(err)
+	return marker
+}
+
+// replay applies the AOF entries following the marker of the loaded snapshot,
+// or all of them if no snapshot was loaded or its marker is missing
+func (server *serverStruct) replay(marker *pb.SnapshotMarker) {
 	logger.Infof("Replaying ...")
+	var skipped []*storage.Entry
 	for {
 		e, err := server.storage.Read()
 		if err != nil && err.Error() == "EOF" {
@@ -68,42 +117,120 @@ func (server *serverStruct) replay() {
 		} else {
 			utils.PanicOnError(err)
 		}
-
-		switch e.OpType() {
-		case storage.Add:
-			req := &pb.AddRequest{}
-			err = proto.Unmarshal(e.RawMsg(), req)
-			utils.PanicOnError(err)
-			if _, err := server.add(context.Background(), req); err != nil {
-				logger.Errorf("an error has occurred while replaying: %s", err.Error())
-			}
-		case storage.CreateSketch:
-			sketch := unmarshalSketch(e)
-			if _, err := server.createSketch(context.Background(), sketch); err != nil {
-				logger.Errorf("an error has occurred while replaying: %s", err.Error())
-			}
-		case storage.DeleteSketch:
-			sketch := unmarshalSketch(e)
-			if _, err := server.deleteSketch(context.Background(), sketch); err != nil {
-				logger.Errorf("an error has occurred while replaying: %s", err.Error())
+		if marker != nil {
+			if e.OpType() == storage.Snapshot && proto.Equal(marker, unmarshalMarker(e)) {
+				marker, skipped = nil, nil
+			} else {
+				skipped = append(skipped, e)
 			}
-		case storage.CreateDom:
-			dom := unmarshalDom(e)
-			if _, err := server.createDomain(context.Background(), dom); err != nil {
-				logger.Errorf("an error has occurred while replaying: %s", err.Error())
-			}
-		case storage.DeleteDom:
-			dom := unmarshalDom(e)
-			if _, err := server.deleteDomain(context.Background(), dom); err != nil {
-				logger.Errorf("an error has occurred while replaying: %s", err.Error())
-			}
-		default:
 			continue
 		}
+		server.apply(e)
+	}
+	if marker != nil {
+		logger.Warningf("Snapshot marker not found in AOF, replaying all operations")
+		for _, e := range skipped {
+			server.apply(e)
+		}
+	}
+}
+
+// apply replays a single AOF entry
+func (server *serverStruct) apply(e *storage.Entry) {
+	var err error
+	switch e.OpType() {
+	case storage.Add:
+		req := &pb.AddRequest{}
+		err = proto.Unmarshal(e.RawMsg(), req)
+		utils.PanicOnError(err)
+		if _, err := server.add(context.Background(), req); err != nil {
+			logger.Errorf("an error has occurred while replaying: %s", err.Error())
+		}
+	case storage.Merge:
+		req := &pb.MergeRequest{}
+		err = proto.Unmarshal(e.RawMsg(), req)
+		utils.PanicOnError(err)
+		if _, err := server.merge(context.Background(), req); err != nil {
+			logger.Errorf("an error has occurred while replaying: %s", err.Error())
+		}
+	case storage.Remove:
+		req := &pb.RemoveRequest{}
+		err = proto.Unmarshal(e.RawMsg(), req)
+		utils.PanicOnError(err)
+		if _, err := server.remove(context.Background(), req); err != nil {
+			logger.Errorf("an error has occurred while replaying: %s", err.Error())
+		}
+	case storage.CreateSketch:
+		sketch := unmarshalSketch(e)
+		if _, err := server.createSketch(context.Background(), sketch); err != nil {
+			logger.Errorf("an error has occurred while replaying: %s", err.Error())
+		}
+	case storage.DeleteSketch:
+		sketch := unmarshalSketch(e)
+		if _, err := server.deleteSketch(context.Background(), sketch); err != nil {
+			logger.Errorf("an error has occurred while replaying: %s", err.Error())
+		}
+	case storage.CreateDom:
+		dom := unmarshalDom(e)
+		if _, err := server.createDomain(context.Background(), dom); err != nil {
+			logger.Errorf("an error has occurred while replaying: %s", err.Error())
+		}
+	case storage.DeleteDom:
+		dom := unmarshalDom(e)
+		if _, err := server.deleteDomain(context.Background(), dom); err != nil {
+			logger.Errorf("an error has occurred while replaying: %s", err.Error())
+		}
+	case storage.AddSketchToDom:
+		req := &pb.DomainSketchRequest{}
+		err = proto.Unmarshal(e.RawMsg(), req)
+		utils.PanicOnError(err)
+		if _, err := server.addSketchToDomain(context.Background(), req); err != nil {
+			logger.Errorf("an error has occurred while replaying: %s", err.Error())
+		}
+	case storage.RemoveSketchFromDom:
+		req := &pb.DomainSketchRequest{}
+		err = proto.Unmarshal(e.RawMsg(), req)
+		utils.PanicOnError(err)
+		if _, err := server.removeSketchFromDomain(context.Background(), req); err != nil {
+			logger.Errorf("an error has occurred while replaying: %s", err.Error())
+		}
 	}
 }
 
 // Stop ...
 func Stop() {
-	server.g.Stop()
+	if err := Shutdown(false); err != nil {
+		logger.Errorf("an error has occurred while stopping: %s", err.Error())
+	}
+}
+
+// Shutdown stops accepting RPCs, waits for the pending ones and syncs the AOF
+// to disk before stopping it, optionally taking a final snapshot
+func Shutdown(snapshot bool) error {
+	if server == nil {
+		return nil
+	}
+	if server.http != nil {
+		if err := server.http.Shutdown(context.Background()); err != nil {
+			return err
+		}
+	}
+	if server.resp != nil {
+		server.resp.stop()
+	}
+	server.g.GracefulStop()
+	if !server.storage.Running() {
+		// Still replaying the AOF, there is nothing new to sync or snapshot
+		logger.Warningf("Shutting down before the AOF was replayed")
+		return nil
+	}
+	if err := server.storage.Flush(); err != nil {
+		return err
+	}
+	if snapshot {
+		if err := server.snapshot(time.Now().Unix()); err != nil {
+			return err
+		}
+	}
+	return server.storage.Stop()
 }
@@ -1,11 +1,17 @@
 package server
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
 	"datamodel"
 	pb "datamodel/protobuf"
+	"sketches"
 	"storage"
 
-	"github.com/gogo/protobuf/proto"
+	"github.com/golang/protobuf/proto"
 	"github.com/njpatel/loggo"
 	"golang.org/x/net/context"
 )
@@ -13,101 +19,437 @@ import (
 var logger = loggo.GetLogger("server")
 
 func (s *serverStruct) createSketch(ctx context.Context, in *pb.Sketch) (*pb.Sketch, error) {
-	info := &datamodel.Info{Sketch: in}
+	info := &datamodel.Info{Sketch: proto.Clone(in).(*pb.Sketch)}
 	if err := s.manager.CreateSketch(info); err != nil {
 		return nil, err
 	}
-	return in, nil
+	// Reply with the properties the sketch was actually created with
+	info, err := s.manager.GetSketch(info.ID())
+	if err != nil {
+		return nil, err
+	}
+	return info.Sketch, nil
 }
 
 func (s *serverStruct) CreateSketch(ctx context.Context, in *pb.Sketch) (*pb.Sketch, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	// The state is maintained by the server, the creation time is recorded
+	// in the AOF to be restored on replay
+	in.State = &pb.SketchState{Created: proto.Int64(time.Now().Unix())}
 	if err := s.storage.Append(storage.CreateSketch, in); err != nil {
 		return nil, err
 	}
 	return s.createSketch(ctx, in)
 }
 
+// checkWeightedValues returns an error if a weighted value has no positive count
+func checkWeightedValues(in *pb.AddRequest) error {
+	for _, v := range in.GetWeightedValues() {
+		if v.GetCount() <= 0 {
+			return fmt.Errorf("Invalid count %d for value %s", v.GetCount(), v.GetValue())
+		}
+	}
+	return nil
+}
+
 func (s *serverStruct) add(ctx context.Context, in *pb.AddRequest) (*pb.AddReply, error) {
-	info := datamodel.NewEmptyInfo()
+	if err := checkWeightedValues(in); err != nil {
+		return nil, err
+	}
+	reply := &pb.AddReply{}
 	// FIXME: use domain or sketch directly and stop casting to Info
 	if dom := in.GetDomain(); dom != nil {
-		info.Name = dom.Name
-		err := s.manager.AddToDomain(info.GetName(), in.GetValues())
+		results, err := s.manager.AddWeightedToDomainAt(dom.GetName(), in.GetValues(),
+			in.GetWeightedValues(), in.GetTimestamp())
 		if err != nil {
 			return nil, err
 		}
+		reply.Results = results
 	} else if sketch := in.GetSketch(); sketch != nil {
 		info := &datamodel.Info{Sketch: sketch}
-		err := s.manager.AddToSketch(info.ID(), in.GetValues())
+		result, err := s.manager.AddWeightedToSketchAt(info.ID(), in.GetValues(),
+			in.GetWeightedValues(), in.GetTimestamp())
 		if err != nil {
 			return nil, err
 		}
+		if result.Error != nil {
+			return nil, errors.New(result.GetError())
+		}
+		reply.Results = []*pb.AddResult{result}
 	}
-	return &pb.AddReply{}, nil
+	return reply, nil
 }
 
 func (s *serverStruct) Add(ctx context.Context, in *pb.AddRequest) (*pb.AddReply, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	// Replaying must add values to the buckets of windowed sketches they
+	// were added to in the first place
+	if in.Timestamp == nil {
+		in.Timestamp = proto.Int64(time.Now().Unix())
+	}
+	// Keep invalid requests out of the AOF
+	if err := checkWeightedValues(in); err != nil {
+		return nil, err
+	}
 	if err := s.storage.Append(storage.Add, in); err != nil {
 		return nil, err
 	}
 	return s.add(ctx, in)
 }
 
-func (s *serverStruct) GetMembership(ctx context.Context, in *pb.GetRequest) (*pb.GetMembershipReply, error) {
-	reply := &pb.GetMembershipReply{}
+// addStreamAckInterval is the maximum number of requests of an add stream
+// applied at once, each batch of requests is acked once applied
+const addStreamAckInterval = 100
+
+// addStreamAckPeriod is the maximum time requests of an add stream wait to be
+// applied and acked
+const addStreamAckPeriod = time.Second
+
+// AddStream applies a stream of add requests in order, in batches of up to
+// addStreamAckInterval requests received within addStreamAckPeriod. Failing
+// requests are reported in the acks instead of closing the stream. Requests
+// that were not acked when the stream breaks are dropped, the client can send
+// them again without adding their values twice.
+func (s *serverStruct) AddStream(stream pb.Skizze_AddStreamServer) error {
+	return s.addStream(stream.Context(), stream.Recv, stream.Send)
+}
+
+// received is a request read from an add stream, or the error ending it
+type received struct {
+	in  *pb.AddRequest
+	err error
+}
+
+// addStream applies the add requests returned by recv until io.EOF and sends
+// the acks with send
+func (s *serverStruct) addStream(ctx context.Context, recv func() (*pb.AddRequest, error),
+	send func(*pb.AddStreamReply) error) error {
+	// Read ahead so that a batch can be applied once the ack period is over
+	// while its last request is still being received
+	reqs := make(chan received, addStreamAckInterval)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			in, err := recv()
+			select {
+			case reqs <- received{in, err}:
+			case <-done:
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
 
+	var requests, values int64
+	var batch []*pb.AddRequest
+	ack := func() error {
+		var errs []*pb.AddStreamError
+		for i, err := range s.addBatch(ctx, batch) {
+			in := batch[i]
+			if err != nil {
+				errs = append(errs, &pb.AddStreamError{
+					Request: proto.Int64(requests),
+					Target:  proto.String(addTarget(in)),
+					Error:   proto.String(err.Error()),
+				})
+			} else {
+				values += int64(len(in.GetValues()))
+				for _, v := range in.GetWeightedValues() {
+					values += v.GetCount()
+				}
+			}
+			requests++
+		}
+		batch = batch[:0]
+		return send(&pb.AddStreamReply{
+			Requests: proto.Int64(requests),
+			Values:   proto.Int64(values),
+			Errors:   errs,
+		})
+	}
+
+	ticker := time.NewTicker(addStreamAckPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case r := <-reqs:
+			if r.err == io.EOF {
+				return ack()
+			}
+			if r.err != nil {
+				// The batch can't be acked anymore, drop it
+				return r.err
+			}
+			// Values are added at the time they are received
+			if r.in.Timestamp == nil {
+				r.in.Timestamp = proto.Int64(time.Now().Unix())
+			}
+			batch = append(batch, r.in)
+			if len(batch) == addStreamAckInterval {
+				if err := ack(); err != nil {
+					return err
+				}
+			}
+		case <-ticker.C:
+			if len(batch) == 0 {
+				continue
+			}
+			if err := ack(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// addBatch applies the add requests of batch in order like Add does, they are
+// appended to the AOF at once. It returns the error of each of the requests.
+func (s *serverStruct) addBatch(ctx context.Context, batch []*pb.AddRequest) []error {
+	errs := make([]error, len(batch))
+	msgs := make([]proto.Message, 0, len(batch))
+	for i, in := range batch {
+		// Keep invalid requests out of the AOF
+		if errs[i] = checkWeightedValues(in); errs[i] == nil {
+			msgs = append(msgs, in)
+		}
+	}
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if err := s.storage.AppendAll(storage.Add, msgs); err != nil {
+		for i := range errs {
+			if errs[i] == nil {
+				errs[i] = err
+			}
+		}
+		return errs
+	}
+	for i, in := range batch {
+		if errs[i] == nil {
+			_, errs[i] = s.add(ctx, in)
+		}
+	}
+	return errs
+}
+
+// addTarget returns the name of the domain or the id of the sketch in adds to
+func addTarget(in *pb.AddRequest) string {
+	if dom := in.GetDomain(); dom != nil {
+		return dom.GetName()
+	}
+	if sketch := in.GetSketch(); sketch != nil {
+		return (&datamodel.Info{Sketch: sketch}).ID()
+	}
+	return ""
+}
+
+func (s *serverStruct) merge(ctx context.Context, in *pb.MergeRequest) (*pb.Empty, error) {
+	dest := &datamodel.Info{Sketch: in.GetDestination()}
+	sources := make([]string, len(in.GetSources()))
+	for i, sketch := range in.GetSources() {
+		sources[i] = (&datamodel.Info{Sketch: sketch}).ID()
+	}
+	return &pb.Empty{}, s.manager.MergeSketches(dest.ID(), sources)
+}
+
+func (s *serverStruct) Merge(ctx context.Context, in *pb.MergeRequest) (*pb.Empty, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if err := s.storage.Append(storage.Merge, in); err != nil {
+		return nil, err
+	}
+	return s.merge(ctx, in)
+}
+
+func (s *serverStruct) remove(ctx context.Context, in *pb.RemoveRequest) (*pb.RemoveReply, error) {
+	info := &datamodel.Info{Sketch: in.GetSketch()}
+	return s.manager.RemoveFromSketch(info.ID(), in.GetValues())
+}
+
+func (s *serverStruct) Remove(ctx context.Context, in *pb.RemoveRequest) (*pb.RemoveReply, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if err := s.storage.Append(storage.Remove, in); err != nil {
+		return nil, err
+	}
+	return s.remove(ctx, in)
+}
+
+// getFromSketch queries the sketch of info, windowed sketches are restricted to
+// the requested buckets
+func (s *serverStruct) getFromSketch(info *datamodel.Info, in *pb.GetRequest) (interface{}, error) {
+	var data interface{} = in.GetValues()
+	if info.GetType() == pb.SketchType_QUAN {
+		data = &sketches.QuantilesQuery{Quantiles: in.GetQuantiles(), CDF: in.GetCdf()}
+	}
+	return s.getWindowed(info.ID(), data, in)
+}
+
+// getWindowed queries the sketch id with data, restricted to the buckets
+// requested by in if it is windowed
+func (s *serverStruct) getWindowed(id string, data interface{}, in *pb.GetRequest) (interface{}, error) {
+	if in.Buckets == nil && in.From == nil && in.To == nil {
+		return s.manager.GetFromSketch(id, data)
+	}
+	window := &sketches.Window{
+		Buckets: in.GetBuckets(),
+		From:    in.GetFrom(),
+		To:      in.GetTo(),
+	}
+	return s.manager.GetFromSketchWindow(id, data, window)
+}
+
+// querySketch queries a single sketch, failures are reported in the result
+func (s *serverStruct) querySketch(sketch *pb.Sketch, in *pb.GetRequest) *pb.QueryResult {
+	result := &pb.QueryResult{Sketch: sketch}
+	info := &datamodel.Info{Sketch: sketch}
+	res, err := s.getFromSketch(info, in)
+	if err != nil {
+		result.Error = proto.String(err.Error())
+		return result
+	}
+	switch r := res.(type) {
+	case *pb.MembershipResult:
+		result.Result = &pb.QueryResult_Membership{Membership: r}
+	case *pb.FrequencyResult:
+		result.Result = &pb.QueryResult_Frequency{Frequency: r}
+	case *pb.CardinalityResult:
+		result.Result = &pb.QueryResult_Cardinality{Cardinality: r}
+	case *pb.RankingsResult:
+		result.Result = &pb.QueryResult_Rankings{Rankings: r}
+	case *pb.QuantilesResult:
+		result.Result = &pb.QueryResult_Quantiles{Quantiles: r}
+	default:
+		result.Error = proto.String(fmt.Sprintf("Unexpected result %T for sketch %s", res, info.ID()))
+	}
+	return result
+}
+
+// Query queries sketches of any type, a sketch that can not be queried gets an
+// error in its result instead of failing the whole request
+func (s *serverStruct) Query(ctx context.Context, in *pb.GetRequest) (*pb.QueryReply, error) {
+	reply := &pb.QueryReply{
+		Results: make([]*pb.QueryResult, len(in.GetSketches())),
+	}
+	for i, sketch := range in.GetSketches() {
+		reply.Results[i] = s.querySketch(sketch, in)
+	}
+	return reply, nil
+}
+
+// query runs in through Query for the typed RPCs, the first failing sketch
+// fails the request and sketches of another type than typ are rejected
+func (s *serverStruct) query(ctx context.Context, in *pb.GetRequest, typ pb.SketchType) ([]*pb.QueryResult, error) {
 	for _, sketch := range in.GetSketches() {
-		info := &datamodel.Info{Sketch: sketch}
-		res, err := s.manager.GetFromSketch(info.ID(), in.GetValues())
-		if err != nil {
-			return nil, err
+		if sketch.GetType() != typ {
+			return nil, fmt.Errorf("Can not query %s sketch %s for %s results",
+				sketch.GetType(), sketch.GetName(), typ)
 		}
-		reply.Results = append(reply.Results, res.(*pb.MembershipResult))
+	}
+	reply, err := s.Query(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	for _, res := range reply.GetResults() {
+		if res.Error != nil {
+			return nil, errors.New(res.GetError())
+		}
+	}
+	return reply.GetResults(), nil
+}
+
+func (s *serverStruct) GetMembership(ctx context.Context, in *pb.GetRequest) (*pb.GetMembershipReply, error) {
+	results, err := s.query(ctx, in, pb.SketchType_MEMB)
+	if err != nil {
+		return nil, err
+	}
+	reply := &pb.GetMembershipReply{}
+	for _, res := range results {
+		reply.Results = append(reply.Results, res.GetMembership())
 	}
 	return reply, nil
 }
 
 func (s *serverStruct) GetFrequency(ctx context.Context, in *pb.GetRequest) (*pb.GetFrequencyReply, error) {
+	results, err := s.query(ctx, in, pb.SketchType_FREQ)
+	if err != nil {
+		return nil, err
+	}
 	reply := &pb.GetFrequencyReply{}
-
-	for _, sketch := range in.GetSketches() {
-		info := &datamodel.Info{Sketch: sketch}
-		res, err := s.manager.GetFromSketch(info.ID(), in.GetValues())
-		if err != nil {
-			return nil, err
-		}
-		reply.Results = append(reply.Results, res.(*pb.FrequencyResult))
+	for _, res := range results {
+		reply.Results = append(reply.Results, res.GetFrequency())
 	}
 	return reply, nil
 }
 
 func (s *serverStruct) GetCardinality(ctx context.Context, in *pb.GetRequest) (*pb.GetCardinalityReply, error) {
+	results, err := s.query(ctx, in, pb.SketchType_CARD)
+	if err != nil {
+		return nil, err
+	}
 	reply := &pb.GetCardinalityReply{}
-
-	for _, sketch := range in.GetSketches() {
-		info := &datamodel.Info{Sketch: sketch}
-		res, err := s.manager.GetFromSketch(info.ID(), in.GetValues())
-		if err != nil {
-			return nil, err
-		}
-		reply.Results = append(reply.Results, res.(*pb.CardinalityResult))
+	for _, res := range results {
+		reply.Results = append(reply.Results, res.GetCardinality())
 	}
 	return reply, nil
 }
 
 func (s *serverStruct) GetRankings(ctx context.Context, in *pb.GetRequest) (*pb.GetRankingsReply, error) {
+	results, err := s.query(ctx, in, pb.SketchType_RANK)
+	if err != nil {
+		return nil, err
+	}
 	reply := &pb.GetRankingsReply{}
+	for _, res := range results {
+		reply.Results = append(reply.Results, res.GetRankings())
+	}
+	return reply, nil
+}
 
-	for _, sketch := range in.GetSketches() {
-		info := &datamodel.Info{Sketch: sketch}
+func (s *serverStruct) GetQuantiles(ctx context.Context, in *pb.GetRequest) (*pb.GetQuantilesReply, error) {
+	results, err := s.query(ctx, in, pb.SketchType_QUAN)
+	if err != nil {
+		return nil, err
+	}
+	reply := &pb.GetQuantilesReply{}
+	for _, res := range results {
+		reply.Results = append(reply.Results, res.GetQuantiles())
+	}
+	return reply, nil
+}
 
-		res, err := s.manager.GetFromSketch(info.ID(), in.GetValues())
+// GetSimilarity compares the sets of values added to the SIMI sketches of in,
+// the SIMI sketch of a domain is named after it
+func (s *serverStruct) GetSimilarity(ctx context.Context, in *pb.GetRequest) (*pb.GetSimilarityReply, error) {
+	signatures := make([]*sketches.Signature, len(in.GetSketches()))
+	for i, sketch := range in.GetSketches() {
+		if sketch.GetType() != pb.SketchType_SIMI {
+			return nil, fmt.Errorf("Can not compare %s sketch %s, only SIMI sketches can be",
+				sketch.GetType(), sketch.GetName())
+		}
+		info := &datamodel.Info{Sketch: sketch}
+		res, err := s.getWindowed(info.ID(), &sketches.SignatureQuery{}, in)
 		if err != nil {
 			return nil, err
 		}
-		reply.Results = append(reply.Results, res.(*pb.RankingsResult))
+		signatures[i] = res.(*sketches.Signature)
 	}
-	return reply, nil
+	return sketches.Similarity(signatures)
+}
+
+// GetSetCardinality estimates the cardinality of the union and intersection of
+// the CARD sketches of in, and of the first one minus the others
+func (s *serverStruct) GetSetCardinality(ctx context.Context, in *pb.GetRequest) (*pb.GetSetCardinalityReply, error) {
+	ids := make([]string, len(in.GetSketches()))
+	for i, sketch := range in.GetSketches() {
+		info := &datamodel.Info{Sketch: sketch}
+		ids[i] = info.ID()
+	}
+	return s.manager.GetSetCardinality(ids)
 }
 
 func (s *serverStruct) deleteSketch(ctx context.Context, in *pb.Sketch) (*pb.Empty, error) {
@@ -116,6 +458,8 @@ func (s *serverStruct) deleteSketch(ctx context.Context, in *pb.Sketch) (*pb.Emp
 }
 
 func (s *serverStruct) DeleteSketch(ctx context.Context, in *pb.Sketch) (*pb.Empty, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
 	if err := s.storage.Append(storage.DeleteSketch, in); err != nil {
 		logger.Errorf("an error has occurred while deleting a sketch: %s", err.Error())
 	}
@@ -136,6 +480,10 @@ func (s *serverStruct) ListAll(ctx context.Context, in *pb.Empty) (*pb.ListReply
 			typ = pb.SketchType_CARD
 		case datamodel.Bloom:
 			typ = pb.SketchType_MEMB
+		case datamodel.TDigest:
+			typ = pb.SketchType_QUAN
+		case datamodel.MinHash:
+			typ = pb.SketchType_SIMI
 		default:
 			continue
 		}
@@ -145,10 +493,12 @@ func (s *serverStruct) ListAll(ctx context.Context, in *pb.Empty) (*pb.ListReply
 }
 
 func (s *serverStruct) GetSketch(ctx context.Context, in *pb.Sketch) (*pb.Sketch, error) {
-	var err error
 	info := &datamodel.Info{Sketch: in}
-	info, err = s.manager.GetSketch(info.ID())
-	return in, err
+	info, err := s.manager.GetSketch(info.ID())
+	if err != nil {
+		return nil, err
+	}
+	return info.Sketch, nil
 }
 
 func (s *serverStruct) List(ctx context.Context, in *pb.ListRequest) (*pb.ListReply, error) {
@@ -165,6 +515,10 @@ func (s *serverStruct) List(ctx context.Context, in *pb.ListRequest) (*pb.ListRe
 			typ = pb.SketchType_CARD
 		case datamodel.Bloom:
 			typ = pb.SketchType_MEMB
+		case datamodel.TDigest:
+			typ = pb.SketchType_QUAN
+		case datamodel.MinHash:
+			typ = pb.SketchType_SIMI
 		default:
 			continue
 		}
@@ -1,13 +1,17 @@
 package server
 
 import (
+	"errors"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/gogo/protobuf/proto"
 	"golang.org/x/net/context"
 
 	"config"
 	pb "datamodel/protobuf"
+	"manager"
 	"testutils"
 )
 
@@ -355,3 +359,935 @@ func TestAddGetRankSketch(t *testing.T) {
 		}
 	}
 }
+
+func TestMergeSketches(t *testing.T) {
+	config.Reset()
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	client, conn := setupClient()
+	defer tearDownClient(conn)
+
+	typ := pb.SketchType_CARD
+	sketches := []*pb.Sketch{}
+	for i, name := range []string{"all", "marvel", "dc"} {
+		in := &pb.Sketch{
+			Name: proto.String(name),
+			Type: &typ,
+		}
+		if _, err := client.CreateSketch(context.Background(), in); err != nil {
+			t.Error("Did not expect error, got", err)
+		}
+		addReq := &pb.AddRequest{
+			Sketch: in,
+			Values: []string{"a", "b", string('c' + rune(i))},
+		}
+		if _, err := client.Add(context.Background(), addReq); err != nil {
+			t.Error("Did not expect error, got", err)
+		}
+		sketches = append(sketches, in)
+	}
+
+	mergeReq := &pb.MergeRequest{
+		Destination: sketches[0],
+		Sources:     sketches[1:],
+	}
+	if _, err := client.Merge(context.Background(), mergeReq); err != nil {
+		t.Error("Did not expect error, got", err)
+	}
+
+	getReq := &pb.GetRequest{
+		Sketches: sketches,
+	}
+	if res, err := client.GetCardinality(context.Background(), getReq); err != nil {
+		t.Error("Did not expect error, got", err)
+	} else if res.GetResults()[0].GetCardinality() != 5 {
+		t.Error("Expected cardinality 5, got", res.GetResults()[0].GetCardinality())
+	} else if res.GetResults()[1].GetCardinality() != 3 {
+		t.Error("Expected sources to be unchanged, got", res.GetResults()[1].GetCardinality())
+	}
+
+	// Merges are replayed from the AOF
+	Stop()
+	go Run(manager.NewManager(), "127.0.0.1", 7777, config.DataDir)
+	time.Sleep(time.Millisecond * 50)
+
+	if res, err := client.GetCardinality(context.Background(), getReq); err != nil {
+		t.Error("Did not expect error, got", err)
+	} else if res.GetResults()[0].GetCardinality() != 5 {
+		t.Error("Expected cardinality 5 after restart, got", res.GetResults()[0].GetCardinality())
+	}
+}
+
+func TestMergeIncompatibleSketches(t *testing.T) {
+	config.Reset()
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	client, conn := setupClient()
+	defer tearDownClient(conn)
+
+	membTyp := pb.SketchType_MEMB
+	cardTyp := pb.SketchType_CARD
+	dest := &pb.Sketch{
+		Name:       proto.String("all"),
+		Type:       &membTyp,
+		Properties: &pb.SketchProperties{MaxUniqueItems: proto.Int64(1000)},
+	}
+	small := &pb.Sketch{
+		Name:       proto.String("small"),
+		Type:       &membTyp,
+		Properties: &pb.SketchProperties{MaxUniqueItems: proto.Int64(10)},
+	}
+	precise := &pb.Sketch{
+		Name: proto.String("precise"),
+		Type: &membTyp,
+		Properties: &pb.SketchProperties{
+			MaxUniqueItems: proto.Int64(1000),
+			ErrorRate:      proto.Float32(0.001),
+		},
+	}
+	card := &pb.Sketch{
+		Name: proto.String("all"),
+		Type: &cardTyp,
+	}
+	rankTyp := pb.SketchType_RANK
+	top := &pb.Sketch{
+		Name:       proto.String("all"),
+		Type:       &rankTyp,
+		Properties: &pb.SketchProperties{Size: proto.Int64(10)},
+	}
+	wide := &pb.Sketch{
+		Name:       proto.String("wide"),
+		Type:       &rankTyp,
+		Properties: &pb.SketchProperties{Size: proto.Int64(20)},
+	}
+	for _, in := range []*pb.Sketch{dest, small, precise, card, top, wide} {
+		if _, err := client.CreateSketch(context.Background(), in); err != nil {
+			t.Error("Did not expect error, got", err)
+		}
+	}
+
+	for _, req := range []*pb.MergeRequest{
+		{Destination: dest, Sources: []*pb.Sketch{small}},
+		{Destination: dest, Sources: []*pb.Sketch{precise}},
+		{Destination: dest, Sources: []*pb.Sketch{card}},
+		{Destination: dest, Sources: []*pb.Sketch{dest}},
+		{Destination: top, Sources: []*pb.Sketch{wide}},
+	} {
+		if _, err := client.Merge(context.Background(), req); err == nil {
+			t.Errorf("Expected error merging %v into %v, got %v", req.GetSources(), req.GetDestination(), err)
+		}
+	}
+}
+
+func TestWindowedSketch(t *testing.T) {
+	config.Reset()
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	client, conn := setupClient()
+	defer tearDownClient(conn)
+
+	typ := pb.SketchType_CARD
+	in := &pb.Sketch{
+		Name: proto.String("users"),
+		Type: &typ,
+		Properties: &pb.SketchProperties{
+			BucketDuration: proto.Int64(3600),
+			BucketCount:    proto.Int64(24),
+		},
+	}
+	if _, err := client.CreateSketch(context.Background(), in); err != nil {
+		t.Error("Did not expect error, got", err)
+	}
+
+	now := time.Now().Unix()
+	for _, addReq := range []*pb.AddRequest{
+		{Sketch: in, Values: []string{"a", "b"}, Timestamp: proto.Int64(now - 7200)},
+		{Sketch: in, Values: []string{"c"}},
+	} {
+		if _, err := client.Add(context.Background(), addReq); err != nil {
+			t.Error("Did not expect error, got", err)
+		}
+	}
+	addReq := &pb.AddRequest{Sketch: in, Values: []string{"d"}, Timestamp: proto.Int64(now + 7200)}
+	if _, err := client.Add(context.Background(), addReq); err == nil {
+		t.Error("Expected error adding values in the future, got", err)
+	}
+
+	getAll := &pb.GetRequest{Sketches: []*pb.Sketch{in}}
+	getLast := &pb.GetRequest{Sketches: []*pb.Sketch{in}, Buckets: proto.Int64(1)}
+	check := func() {
+		if res, err := client.GetCardinality(context.Background(), getAll); err != nil {
+			t.Error("Did not expect error, got", err)
+		} else if res.GetResults()[0].GetCardinality() != 3 {
+			t.Error("Expected cardinality 3, got", res.GetResults()[0].GetCardinality())
+		}
+		if res, err := client.GetCardinality(context.Background(), getLast); err != nil {
+			t.Error("Did not expect error, got", err)
+		} else if res.GetResults()[0].GetCardinality() != 1 {
+			t.Error("Expected cardinality 1 in the last bucket, got", res.GetResults()[0].GetCardinality())
+		}
+	}
+	check()
+
+	// Values are replayed into the buckets they were added to
+	Stop()
+	go Run(manager.NewManager(), "127.0.0.1", 7777, config.DataDir)
+	time.Sleep(time.Millisecond * 50)
+	check()
+}
+
+func TestCreateSketchErrorRate(t *testing.T) {
+	config.Reset()
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	client, conn := setupClient()
+	defer tearDownClient(conn)
+
+	typ := pb.SketchType_FREQ
+	in := &pb.Sketch{
+		Name: proto.String("yoyo"),
+		Type: &typ,
+		Properties: &pb.SketchProperties{
+			MaxUniqueItems: proto.Int64(1000),
+			ErrorRate:      proto.Float32(0.001),
+		},
+	}
+	if res, err := client.CreateSketch(context.Background(), in); err != nil {
+		t.Error("Did not expect error, got", err)
+	} else if rate := res.GetProperties().GetErrorRate(); rate != 0.001 {
+		t.Error("Expected error rate 0.001, got", rate)
+	}
+
+	// The default rate is reported when none was requested
+	typ = pb.SketchType_MEMB
+	in.Properties.ErrorRate = nil
+	if _, err := client.CreateSketch(context.Background(), in); err != nil {
+		t.Error("Did not expect error, got", err)
+	}
+	if res, err := client.GetSketch(context.Background(), in); err != nil {
+		t.Error("Did not expect error, got", err)
+	} else if rate := res.GetProperties().GetErrorRate(); rate != 0.01 {
+		t.Error("Expected error rate 0.01, got", rate)
+	}
+
+	typ = pb.SketchType_CARD
+	in.Properties.ErrorRate = proto.Float32(1.5)
+	if _, err := client.CreateSketch(context.Background(), in); err == nil {
+		t.Error("Expected error, got", err)
+	}
+}
+
+func TestGetSketchState(t *testing.T) {
+	config.Reset()
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	client, conn := setupClient()
+	defer tearDownClient(conn)
+
+	typ := pb.SketchType_MEMB
+	in := &pb.Sketch{
+		Name: proto.String("yoyo"),
+		Type: &typ,
+		Properties: &pb.SketchProperties{
+			MaxUniqueItems: proto.Int64(1000),
+		},
+	}
+	start := time.Now().Unix()
+	if _, err := client.CreateSketch(context.Background(), in); err != nil {
+		t.Error("Did not expect error, got", err)
+	}
+	addReq := &pb.AddRequest{
+		Sketch: in,
+		Values: []string{"a", "b", "c", "a"},
+	}
+	if _, err := client.Add(context.Background(), addReq); err != nil {
+		t.Error("Did not expect error, got", err)
+	}
+
+	var created int64
+	check := func() {
+		res, err := client.GetSketch(context.Background(), in)
+		if err != nil {
+			t.Fatal("Did not expect error, got", err)
+		}
+		state := res.GetState()
+		if state.GetValuesAdded() != 4 {
+			t.Error("Expected 4 values added, got", state.GetValuesAdded())
+		}
+		if !state.GetExact() {
+			t.Error("Expected sketch to be exact")
+		}
+		if state.GetMemory() <= 0 {
+			t.Error("Expected memory footprint, got", state.GetMemory())
+		}
+		if state.GetCreated() < start || (created != 0 && state.GetCreated() != created) {
+			t.Error("Expected creation time to be kept, got", state.GetCreated())
+		}
+		created = state.GetCreated()
+	}
+	check()
+
+	Stop()
+	go Run(manager.NewManager(), "127.0.0.1", 7777, config.DataDir)
+	time.Sleep(time.Millisecond * 50)
+	check()
+}
+
+func TestQueryMixedSketches(t *testing.T) {
+	config.Reset()
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	client, conn := setupClient()
+	defer tearDownClient(conn)
+
+	membTyp := pb.SketchType_MEMB
+	freqTyp := pb.SketchType_FREQ
+	cardTyp := pb.SketchType_CARD
+	memb := &pb.Sketch{
+		Name:       proto.String("heroes"),
+		Type:       &membTyp,
+		Properties: &pb.SketchProperties{MaxUniqueItems: proto.Int64(1000)},
+	}
+	freq := &pb.Sketch{
+		Name:       proto.String("heroes"),
+		Type:       &freqTyp,
+		Properties: &pb.SketchProperties{MaxUniqueItems: proto.Int64(1000)},
+	}
+	missing := &pb.Sketch{
+		Name: proto.String("villains"),
+		Type: &cardTyp,
+	}
+	for _, in := range []*pb.Sketch{memb, freq} {
+		if _, err := client.CreateSketch(context.Background(), in); err != nil {
+			t.Error("Did not expect error, got", err)
+		}
+		addReq := &pb.AddRequest{
+			Sketch: in,
+			Values: []string{"hulk", "hulk", "thor"},
+		}
+		if _, err := client.Add(context.Background(), addReq); err != nil {
+			t.Error("Did not expect error, got", err)
+		}
+	}
+
+	getReq := &pb.GetRequest{
+		Sketches: []*pb.Sketch{memb, freq, missing},
+		Values:   []string{"hulk", "loki"},
+	}
+	res, err := client.Query(context.Background(), getReq)
+	if err != nil {
+		t.Fatal("Did not expect error, got", err)
+	}
+	if len(res.GetResults()) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(res.GetResults()))
+	}
+	if m := res.GetResults()[0].GetMembership(); m == nil {
+		t.Errorf("Expected a membership result, got %v", res.GetResults()[0])
+	} else if !m.GetMemberships()[0].GetIsMember() || m.GetMemberships()[1].GetIsMember() {
+		t.Errorf("Expected hulk to be a member and loki not, got %v", m.GetMemberships())
+	}
+	if f := res.GetResults()[1].GetFrequency(); f == nil {
+		t.Errorf("Expected a frequency result, got %v", res.GetResults()[1])
+	} else if f.GetFrequencies()[0].GetCount() != 2 || f.GetFrequencies()[1].GetCount() != 0 {
+		t.Errorf("Expected hulk == 2 and loki == 0, got %v", f.GetFrequencies())
+	}
+	if r := res.GetResults()[2]; r.GetResult() != nil || r.GetError() == "" {
+		t.Errorf("Expected an error for a missing sketch, got %v", r)
+	}
+
+	// Typed queries reject sketches of another type instead of crashing
+	getReq.Sketches = []*pb.Sketch{freq}
+	if _, err := client.GetMembership(context.Background(), getReq); err == nil {
+		t.Error("Expected error querying memberships of a FREQ sketch")
+	}
+	if res, err := client.GetFrequency(context.Background(), getReq); err != nil {
+		t.Error("Did not expect error, got", err)
+	} else if res.GetResults()[0].GetFrequencies()[0].GetCount() != 2 {
+		t.Errorf("Expected hulk == 2, got %v", res.GetResults()[0])
+	}
+}
+
+func TestWeightedAdd(t *testing.T) {
+	config.Reset()
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	client, conn := setupClient()
+	defer tearDownClient(conn)
+
+	name := proto.String("marvel")
+	cardTyp := pb.SketchType_CARD
+	freqTyp := pb.SketchType_FREQ
+	rankTyp := pb.SketchType_RANK
+	dom := &pb.Domain{
+		Name: name,
+		Sketches: []*pb.Sketch{
+			{Name: name, Type: &cardTyp},
+			{Name: name, Type: &freqTyp, Properties: &pb.SketchProperties{MaxUniqueItems: proto.Int64(1000)}},
+			{Name: name, Type: &rankTyp, Properties: &pb.SketchProperties{Size: proto.Int64(4)}},
+		},
+	}
+	if _, err := client.CreateDomain(context.Background(), dom); err != nil {
+		t.Fatal("Did not expect error, got", err)
+	}
+
+	addReq := &pb.AddRequest{
+		Domain: dom,
+		Values: []string{"thor"},
+		WeightedValues: []*pb.WeightedValue{
+			{Value: proto.String("hulk"), Count: proto.Int64(1000)},
+			{Value: proto.String("thor"), Count: proto.Int64(4)},
+		},
+	}
+	if _, err := client.Add(context.Background(), addReq); err != nil {
+		t.Error("Did not expect error, got", err)
+	}
+	invalid := &pb.AddRequest{
+		Domain: dom,
+		WeightedValues: []*pb.WeightedValue{
+			{Value: proto.String("loki"), Count: proto.Int64(0)},
+		},
+	}
+	if _, err := client.Add(context.Background(), invalid); err == nil {
+		t.Error("Expected error adding a value 0 times")
+	}
+
+	check := func() {
+		req := &pb.QueryDomainRequest{Domain: dom, Values: []string{"hulk", "thor", "loki"}}
+		res, err := client.QueryDomain(context.Background(), req)
+		if err != nil {
+			t.Fatal("Did not expect error, got", err)
+		}
+		if v := res.GetCardinality().GetCardinality(); v != 2 {
+			t.Error("Expected cardinality 2, got", v)
+		}
+		freqs := res.GetFrequencies().GetFrequencies()
+		if freqs[0].GetCount() != 1000 || freqs[1].GetCount() != 5 || freqs[2].GetCount() != 0 {
+			t.Errorf("Expected [1000 5 0], got %v", freqs)
+		}
+		if v := res.GetRankings().GetRankings(); len(v) != 2 || v[0].GetValue() != "hulk" || v[0].GetCount() != 1000 {
+			t.Errorf("Expected hulk == 1000 first, got %v", v)
+		}
+	}
+	check()
+
+	Stop()
+	go Run(manager.NewManager(), "127.0.0.1", 7777, config.DataDir)
+	time.Sleep(time.Millisecond * 50)
+	check()
+}
+
+func TestAddStream(t *testing.T) {
+	config.Reset()
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	client, conn := setupClient()
+	defer tearDownClient(conn)
+
+	typ := pb.SketchType_FREQ
+	in := &pb.Sketch{
+		Name:       proto.String("heroes"),
+		Type:       &typ,
+		Properties: &pb.SketchProperties{MaxUniqueItems: proto.Int64(1000)},
+	}
+	if _, err := client.CreateSketch(context.Background(), in); err != nil {
+		t.Fatal("Did not expect error, got", err)
+	}
+	missing := &pb.Sketch{
+		Name: proto.String("villains"),
+		Type: &typ,
+	}
+
+	stream, err := client.AddStream(context.Background())
+	if err != nil {
+		t.Fatal("Did not expect error, got", err)
+	}
+	// Read the acks while sending
+	acks := make(chan *pb.AddStreamReply, 10)
+	go func() {
+		defer close(acks)
+		for {
+			ack, err := stream.Recv()
+			if err != nil {
+				return
+			}
+			acks <- ack
+		}
+	}()
+	for i := 0; i < 250; i++ {
+		req := &pb.AddRequest{Sketch: in, Values: []string{"hulk", "thor"}}
+		if i == 150 {
+			req.Sketch = missing
+		}
+		if err := stream.Send(req); err != nil {
+			t.Fatal("Did not expect error, got", err)
+		}
+	}
+	if err := stream.CloseSend(); err != nil {
+		t.Error("Did not expect error, got", err)
+	}
+
+	var replies []*pb.AddStreamReply
+	for ack := range acks {
+		replies = append(replies, ack)
+	}
+	if len(replies) != 3 {
+		t.Fatalf("Expected 3 acks, got %v", replies)
+	}
+	if last := replies[2]; last.GetRequests() != 250 || last.GetValues() != 498 {
+		t.Errorf("Expected 250 requests and 498 values, got %v", last)
+	}
+	if errs := replies[1].GetErrors(); len(errs) != 1 || errs[0].GetRequest() != 150 ||
+		errs[0].GetTarget() != "villains.FREQ" {
+		t.Errorf("Expected an error for request 150, got %v", errs)
+	}
+
+	getReq := &pb.GetRequest{
+		Sketches: []*pb.Sketch{in},
+		Values:   []string{"hulk"},
+	}
+	if res, err := client.GetFrequency(context.Background(), getReq); err != nil {
+		t.Error("Did not expect error, got", err)
+	} else if c := res.GetResults()[0].GetFrequencies()[0].GetCount(); c != 249 {
+		t.Error("Expected hulk == 249, got", c)
+	}
+}
+
+func TestAddStreamAckPeriod(t *testing.T) {
+	config.Reset()
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	client, conn := setupClient()
+	defer tearDownClient(conn)
+
+	typ := pb.SketchType_CARD
+	in := &pb.Sketch{Name: proto.String("heroes"), Type: &typ}
+	if _, err := client.CreateSketch(context.Background(), in); err != nil {
+		t.Fatal("Did not expect error, got", err)
+	}
+
+	stream, err := client.AddStream(context.Background())
+	if err != nil {
+		t.Fatal("Did not expect error, got", err)
+	}
+	for i := 0; i < 5; i++ {
+		if err := stream.Send(&pb.AddRequest{Sketch: in, Values: []string{"hulk"}}); err != nil {
+			t.Fatal("Did not expect error, got", err)
+		}
+	}
+	// Fewer requests than an ack interval are acked once the period is over
+	// while the stream is still open
+	acked := make(chan *pb.AddStreamReply, 1)
+	go func() {
+		if ack, err := stream.Recv(); err == nil {
+			acked <- ack
+		}
+	}()
+	select {
+	case ack := <-acked:
+		if ack.GetRequests() != 5 || ack.GetValues() != 5 {
+			t.Errorf("Expected 5 requests and 5 values, got %v", ack)
+		}
+	case <-time.After(3 * addStreamAckPeriod):
+		t.Fatal("Expected an ack before the stream is closed")
+	}
+	if err := stream.CloseSend(); err != nil {
+		t.Error("Did not expect error, got", err)
+	}
+	if ack, err := stream.Recv(); err != nil {
+		t.Error("Did not expect error, got", err)
+	} else if ack.GetRequests() != 5 {
+		t.Errorf("Expected 5 requests, got %v", ack)
+	}
+}
+
+func TestAddStreamBroken(t *testing.T) {
+	config.Reset()
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	client, conn := setupClient()
+	defer tearDownClient(conn)
+
+	typ := pb.SketchType_CARD
+	in := &pb.Sketch{Name: proto.String("heroes"), Type: &typ}
+	if _, err := client.CreateSketch(context.Background(), in); err != nil {
+		t.Fatal("Did not expect error, got", err)
+	}
+
+	// The requests received before the stream breaks are never acked
+	sent := 0
+	recv := func() (*pb.AddRequest, error) {
+		if sent == 3 {
+			return nil, errors.New("broken stream")
+		}
+		sent++
+		return &pb.AddRequest{Sketch: in, Values: []string{strconv.Itoa(sent)}}, nil
+	}
+	send := func(*pb.AddStreamReply) error {
+		t.Error("Did not expect an ack")
+		return nil
+	}
+	if err := server.addStream(context.Background(), recv, send); err == nil {
+		t.Error("Expected an error")
+	}
+
+	// They are dropped so that the client can send them again
+	getReq := &pb.GetRequest{Sketches: []*pb.Sketch{in}}
+	if res, err := client.GetCardinality(context.Background(), getReq); err != nil {
+		t.Error("Did not expect error, got", err)
+	} else if c := res.GetResults()[0].GetCardinality(); c != 0 {
+		t.Error("Expected cardinality 0, got", c)
+	}
+}
+
+func TestAddReply(t *testing.T) {
+	config.Reset()
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	client, conn := setupClient()
+	defer tearDownClient(conn)
+
+	name := proto.String("marvel")
+	cardTyp := pb.SketchType_CARD
+	membTyp := pb.SketchType_MEMB
+	dom := &pb.Domain{
+		Name: name,
+		Sketches: []*pb.Sketch{
+			{Name: name, Type: &cardTyp},
+			{Name: name, Type: &membTyp, Properties: &pb.SketchProperties{MaxUniqueItems: proto.Int64(1000)}},
+		},
+	}
+	if _, err := client.CreateDomain(context.Background(), dom); err != nil {
+		t.Fatal("Did not expect error, got", err)
+	}
+
+	addReq := &pb.AddRequest{
+		Domain: dom,
+		Values: []string{"hulk", "thor", "hulk"},
+		WeightedValues: []*pb.WeightedValue{
+			{Value: proto.String("thor"), Count: proto.Int64(5)},
+			{Value: proto.String("loki"), Count: proto.Int64(5)},
+		},
+	}
+	res, err := client.Add(context.Background(), addReq)
+	if err != nil {
+		t.Fatal("Did not expect error, got", err)
+	}
+	if len(res.GetResults()) != 2 {
+		t.Fatalf("Expected 2 results, got %v", res.GetResults())
+	}
+	for _, r := range res.GetResults() {
+		if !r.GetSuccess() || r.Error != nil {
+			t.Errorf("Expected adding to %s to succeed, got %v", r.GetSketch().GetType(), r)
+		}
+		if r.GetSketch().GetType() == pb.SketchType_CARD && len(r.GetInsertions()) != 0 {
+			t.Errorf("Expected no insertions for CARD, got %v", r.GetInsertions())
+		}
+		if r.GetSketch().GetType() != pb.SketchType_MEMB {
+			continue
+		}
+		expected := []bool{true, true, false, false, true}
+		if len(r.GetInsertions()) != len(expected) {
+			t.Fatalf("Expected %d insertions, got %v", len(expected), r.GetInsertions())
+		}
+		for i, ins := range r.GetInsertions() {
+			if ins.GetInserted() != expected[i] {
+				t.Errorf("Expected %s inserted == %t, got %t", ins.GetValue(), expected[i], ins.GetInserted())
+			}
+		}
+	}
+
+	// Test-and-add on the MEMB sketch alone
+	addReq = &pb.AddRequest{
+		Sketch: dom.Sketches[1],
+		Values: []string{"loki", "odin"},
+	}
+	if res, err := client.Add(context.Background(), addReq); err != nil {
+		t.Error("Did not expect error, got", err)
+	} else if ins := res.GetResults()[0].GetInsertions(); ins[0].GetInserted() || !ins[1].GetInserted() {
+		t.Errorf("Expected [false true], got %v", ins)
+	}
+}
+
+func TestQuantiles(t *testing.T) {
+	config.Reset()
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	client, conn := setupClient()
+	defer tearDownClient(conn)
+
+	quanTyp := pb.SketchType_QUAN
+	cardTyp := pb.SketchType_CARD
+	name := proto.String("latencies")
+	dom := &pb.Domain{
+		Name: name,
+		Sketches: []*pb.Sketch{
+			{Name: name, Type: &cardTyp},
+			{Name: name, Type: &quanTyp},
+		},
+	}
+	if _, err := client.CreateDomain(context.Background(), dom); err != nil {
+		t.Fatal("Did not expect error, got", err)
+	}
+	var values []string
+	for i := 1; i <= 100; i++ {
+		values = append(values, strconv.Itoa(i))
+	}
+	if _, err := client.Add(context.Background(), &pb.AddRequest{Domain: dom, Values: values}); err != nil {
+		t.Fatal("Did not expect error, got", err)
+	}
+	// Only numbers can be added to QUAN sketches
+	sketch := &pb.Sketch{Name: name, Type: &quanTyp}
+	if _, err := client.Add(context.Background(), &pb.AddRequest{Sketch: sketch, Values: []string{"hulk"}}); err == nil {
+		t.Error("Expected error adding hulk to a QUAN sketch")
+	}
+
+	check := func() {
+		in := &pb.GetRequest{
+			Sketches:  []*pb.Sketch{sketch},
+			Quantiles: []float64{0.5, 0.99},
+			Cdf:       []float64{25},
+		}
+		reply, err := client.GetQuantiles(context.Background(), in)
+		if err != nil {
+			t.Fatal("Did not expect error, got", err)
+		}
+		res := reply.GetResults()[0]
+		if res.GetCount() != 100 || res.GetMin() != 1 || res.GetMax() != 100 {
+			t.Errorf("Expected 100 values from 1 to 100, got %v", res)
+		}
+		if q := res.GetQuantiles(); q[0].GetValue() != 50.5 || q[1].GetValue() != 99.5 {
+			t.Errorf("Expected p50 == 50.5 and p99 == 99.5, got %v", q)
+		}
+		if f := res.GetCdf()[0].GetFraction(); f < 0.24 || f > 0.26 {
+			t.Errorf("Expected CDF(25) ~= 0.25, got %v", f)
+		}
+
+		req := &pb.QueryDomainRequest{Domain: dom, Quantiles: []float64{0.5}}
+		dres, err := client.QueryDomain(context.Background(), req)
+		if err != nil {
+			t.Fatal("Did not expect error, got", err)
+		}
+		if dres.GetCardinality().GetCardinality() != 100 {
+			t.Error("Expected cardinality 100, got", dres.GetCardinality().GetCardinality())
+		}
+		if q := dres.GetQuantiles().GetQuantiles(); len(q) != 1 || q[0].GetValue() != 50.5 {
+			t.Errorf("Expected p50 == 50.5, got %v", q)
+		}
+	}
+	check()
+
+	Stop()
+	go Run(manager.NewManager(), "127.0.0.1", 7777, config.DataDir)
+	time.Sleep(time.Millisecond * 50)
+	check()
+}
+
+func TestRemove(t *testing.T) {
+	config.Reset()
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	client, conn := setupClient()
+	defer tearDownClient(conn)
+
+	typ := pb.SketchType_MEMB
+	online := &pb.Sketch{
+		Name: proto.String("online"),
+		Type: &typ,
+		Properties: &pb.SketchProperties{
+			MaxUniqueItems: proto.Int64(1000),
+			Deletable:      proto.Bool(true),
+		},
+	}
+	bloom := &pb.Sketch{
+		Name:       proto.String("seen"),
+		Type:       &typ,
+		Properties: &pb.SketchProperties{MaxUniqueItems: proto.Int64(1000)},
+	}
+	for _, sketch := range []*pb.Sketch{online, bloom} {
+		if _, err := client.CreateSketch(context.Background(), sketch); err != nil {
+			t.Fatal("Did not expect error, got", err)
+		}
+		addReq := &pb.AddRequest{Sketch: sketch, Values: []string{"hulk", "thor"}}
+		if _, err := client.Add(context.Background(), addReq); err != nil {
+			t.Fatal("Did not expect error, got", err)
+		}
+	}
+
+	in := &pb.RemoveRequest{Sketch: online, Values: []string{"hulk", "loki"}}
+	res, err := client.Remove(context.Background(), in)
+	if err != nil {
+		t.Fatal("Did not expect error, got", err)
+	}
+	if r := res.GetRemovals(); len(r) != 2 || !r[0].GetRemoved() || r[1].GetRemoved() {
+		t.Errorf("Expected [true false], got %v", r)
+	}
+	in = &pb.RemoveRequest{Sketch: bloom, Values: []string{"hulk"}}
+	if _, err := client.Remove(context.Background(), in); err == nil {
+		t.Error("Expected error removing from a bloom filter")
+	}
+
+	check := func() {
+		getReq := &pb.GetRequest{Sketches: []*pb.Sketch{online}, Values: []string{"hulk", "thor"}}
+		reply, err := client.GetMembership(context.Background(), getReq)
+		if err != nil {
+			t.Fatal("Did not expect error, got", err)
+		}
+		if m := reply.GetResults()[0].GetMemberships(); m[0].GetIsMember() || !m[1].GetIsMember() {
+			t.Errorf("Expected [false true], got %v", m)
+		}
+	}
+	check()
+
+	Stop()
+	go Run(manager.NewManager(), "127.0.0.1", 7777, config.DataDir)
+	time.Sleep(time.Millisecond * 50)
+	check()
+}
+
+func TestSimilarity(t *testing.T) {
+	config.Reset()
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	client, conn := setupClient()
+	defer tearDownClient(conn)
+
+	simiTyp := pb.SketchType_SIMI
+	cardTyp := pb.SketchType_CARD
+	dom := &pb.Domain{
+		Name: proto.String("featureA"),
+		Sketches: []*pb.Sketch{
+			{Name: proto.String("featureA"), Type: &cardTyp},
+			{Name: proto.String("featureA"), Type: &simiTyp},
+		},
+	}
+	if _, err := client.CreateDomain(context.Background(), dom); err != nil {
+		t.Fatal("Did not expect error, got", err)
+	}
+	featureB := &pb.Sketch{Name: proto.String("featureB"), Type: &simiTyp}
+	if _, err := client.CreateSketch(context.Background(), featureB); err != nil {
+		t.Fatal("Did not expect error, got", err)
+	}
+	adds := []*pb.AddRequest{
+		{Domain: dom, Values: []string{"hulk", "thor", "loki"}},
+		{Sketch: featureB, Values: []string{"thor", "loki", "thanos", "hela"}},
+	}
+	for _, in := range adds {
+		if _, err := client.Add(context.Background(), in); err != nil {
+			t.Fatal("Did not expect error, got", err)
+		}
+	}
+
+	featureA := &pb.Sketch{Name: proto.String("featureA"), Type: &simiTyp}
+	check := func() {
+		in := &pb.GetRequest{Sketches: []*pb.Sketch{featureA, featureB}}
+		res, err := client.GetSimilarity(context.Background(), in)
+		if err != nil {
+			t.Fatal("Did not expect error, got", err)
+		}
+		if res.GetJaccard() != 0.4 || res.GetIntersection() != 2 || res.GetUnion() != 5 {
+			t.Errorf("Expected jaccard 0.4, intersection 2 and union 5, got %v", res)
+		}
+
+		// The SIMI sketch does not take over the cardinality of the domain
+		dres, err := client.QueryDomain(context.Background(), &pb.QueryDomainRequest{Domain: dom})
+		if err != nil {
+			t.Fatal("Did not expect error, got", err)
+		}
+		if dres.GetCardinality().GetCardinality() != 3 {
+			t.Error("Expected cardinality 3, got", dres.GetCardinality().GetCardinality())
+		}
+	}
+	check()
+
+	cardA := &pb.Sketch{Name: proto.String("featureA"), Type: &cardTyp}
+	in := &pb.GetRequest{Sketches: []*pb.Sketch{cardA, featureB}}
+	if _, err := client.GetSimilarity(context.Background(), in); err == nil {
+		t.Error("Expected error comparing a CARD sketch")
+	}
+	in = &pb.GetRequest{Sketches: []*pb.Sketch{featureB}}
+	if _, err := client.GetSimilarity(context.Background(), in); err == nil {
+		t.Error("Expected error comparing a single sketch")
+	}
+
+	Stop()
+	go Run(manager.NewManager(), "127.0.0.1", 7777, config.DataDir)
+	time.Sleep(time.Millisecond * 50)
+	check()
+}
+
+func TestSetCardinality(t *testing.T) {
+	config.Reset()
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	client, conn := setupClient()
+	defer tearDownClient(conn)
+
+	typ := pb.SketchType_CARD
+	days := []*pb.Sketch{
+		{Name: proto.String("day1"), Type: &typ},
+		{Name: proto.String("day7"), Type: &typ},
+		{Name: proto.String("day14"), Type: &typ},
+	}
+	ranges := [][2]int{{0, 3000}, {2000, 5000}, {2500, 4000}}
+	for i, sketch := range days {
+		if _, err := client.CreateSketch(context.Background(), sketch); err != nil {
+			t.Fatal("Did not expect error, got", err)
+		}
+		var values []string
+		for j := ranges[i][0]; j < ranges[i][1]; j++ {
+			values = append(values, "user-"+strconv.Itoa(j))
+		}
+		if _, err := client.Add(context.Background(), &pb.AddRequest{Sketch: sketch, Values: values}); err != nil {
+			t.Fatal("Did not expect error, got", err)
+		}
+	}
+
+	expect := func(name string, res *pb.CardinalityEstimate, expected int64) {
+		if res.GetError() <= 0 {
+			t.Errorf("Expected an error bound for the %s, got %d", name, res.GetError())
+		}
+		if d := res.GetCardinality() - expected; d > res.GetError() || -d > res.GetError() {
+			t.Errorf("Expected %s %d within %d, got %d", name, expected, res.GetError(), res.GetCardinality())
+		}
+	}
+	res, err := client.GetSetCardinality(context.Background(), &pb.GetRequest{Sketches: days[:2]})
+	if err != nil {
+		t.Fatal("Did not expect error, got", err)
+	}
+	expect("union", res.GetUnion(), 5000)
+	expect("intersection", res.GetIntersection(), 1000)
+	expect("difference", res.GetDifference(), 2000)
+
+	res, err = client.GetSetCardinality(context.Background(), &pb.GetRequest{Sketches: days})
+	if err != nil {
+		t.Fatal("Did not expect error, got", err)
+	}
+	expect("union", res.GetUnion(), 5000)
+	expect("intersection", res.GetIntersection(), 500)
+	expect("difference", res.GetDifference(), 2000)
+
+	if _, err := client.GetSetCardinality(context.Background(), &pb.GetRequest{Sketches: days[:1]}); err == nil {
+		t.Error("Expected error combining a single sketch")
+	}
+	membTyp := pb.SketchType_MEMB
+	memb := &pb.Sketch{Name: proto.String("day1"), Type: &membTyp}
+	in := &pb.GetRequest{Sketches: []*pb.Sketch{days[0], memb}}
+	if _, err := client.GetSetCardinality(context.Background(), in); err == nil {
+		t.Error("Expected error combining a MEMB sketch")
+	}
+}
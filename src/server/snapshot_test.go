@@ -0,0 +1,160 @@
+package server
+
+import (
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/context"
+
+	"config"
+	pb "datamodel/protobuf"
+	"manager"
+	"storage"
+	"testutils"
+)
+
+func waitForSnapshot(t *testing.T, client pb.SkizzeClient) *pb.GetSnapshotReply {
+	for i := 0; i < 100; i++ {
+		res, err := client.GetSnapshot(context.Background(), &pb.GetSnapshotRequest{})
+		if err != nil {
+			t.Fatal("Did not expect error, got", err)
+		}
+		if res.GetStatus() != pb.SnapshotStatus_PENDING && res.GetStatus() != pb.SnapshotStatus_IN_PROGRESS {
+			return res
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("Snapshot did not complete")
+	return nil
+}
+
+func TestGetSnapshotNone(t *testing.T) {
+	config.Reset()
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	client, conn := setupClient()
+	defer tearDownClient(conn)
+
+	if res, err := client.GetSnapshot(context.Background(), &pb.GetSnapshotRequest{}); err != nil {
+		t.Error("Did not expect error, got", err)
+	} else if res.GetStatus() != pb.SnapshotStatus_FAILED {
+		t.Error("Expected status FAILED, got", res.GetStatus())
+	}
+}
+
+func TestCreateSnapshotRestart(t *testing.T) {
+	config.Reset()
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	client, conn := setupClient()
+	defer tearDownClient(conn)
+
+	typ := pb.SketchType_FREQ
+	in := &pb.Sketch{
+		Name: proto.String("yoyo"),
+		Type: &typ,
+		Properties: &pb.SketchProperties{
+			MaxUniqueItems: proto.Int64(1337),
+			Size:           proto.Int64(7),
+		},
+	}
+	if _, err := client.CreateSketch(context.Background(), in); err != nil {
+		t.Error("Did not expect error, got", err)
+	}
+	addReq := &pb.AddRequest{
+		Sketch: in,
+		Values: []string{"a", "b", "c", "a"},
+	}
+	if _, err := client.Add(context.Background(), addReq); err != nil {
+		t.Error("Did not expect error, got", err)
+	}
+
+	if res, err := client.CreateSnapshot(context.Background(), &pb.CreateSnapshotRequest{}); err != nil {
+		t.Error("Did not expect error, got", err)
+	} else if res.GetStatus() != pb.SnapshotStatus_PENDING {
+		t.Error("Expected status PENDING, got", res.GetStatus())
+	}
+	res := waitForSnapshot(t, client)
+	if res.GetStatus() != pb.SnapshotStatus_SUCCESSFUL {
+		t.Fatal("Expected status SUCCESSFUL, got", res.GetStatus(), res.GetStatusMessage())
+	}
+	if sketch, err := client.GetSketch(context.Background(), in); err != nil {
+		t.Error("Did not expect error, got", err)
+	} else if sketch.GetState().GetLastSnapshot() != res.GetTimestamp() {
+		t.Errorf("Expected last snapshot %d, got %d", res.GetTimestamp(), sketch.GetState().GetLastSnapshot())
+	}
+
+	// The AOF is compacted to start at the snapshot
+	aof := storage.NewAOF(filepath.Join(config.DataDir, "skizze.aof"))
+	if e, err := aof.Read(); err != nil {
+		t.Error("Did not expect error, got", err)
+	} else if e.OpType() != storage.Snapshot {
+		t.Error("Expected AOF to start with a snapshot marker, got", e.OpType())
+	} else if _, err := aof.Read(); err != io.EOF {
+		t.Error("Expected EOF, got", err)
+	}
+
+	// Operations after the snapshot are only in the AOF
+	if _, err := client.Add(context.Background(), addReq); err != nil {
+		t.Error("Did not expect error, got", err)
+	}
+	// Wait for the AOF to be flushed
+	time.Sleep(1100 * time.Millisecond)
+
+	Stop()
+	go Run(manager.NewManager(), "127.0.0.1", 7777, config.DataDir)
+	time.Sleep(time.Millisecond * 50)
+
+	getReq := &pb.GetRequest{
+		Sketches: []*pb.Sketch{in},
+		Values:   []string{"a", "c"},
+	}
+	if res, err := client.GetFrequency(context.Background(), getReq); err != nil {
+		t.Error("Did not expect error, got", err)
+	} else if v := res.GetResults()[0].GetFrequencies()[0].GetCount(); v != 4 {
+		t.Error("Expected a: 4, got", v)
+	} else if v := res.GetResults()[0].GetFrequencies()[1].GetCount(); v != 2 {
+		t.Error("Expected c: 2, got", v)
+	}
+
+	if res, err := client.GetSnapshot(context.Background(), &pb.GetSnapshotRequest{}); err != nil {
+		t.Error("Did not expect error, got", err)
+	} else if res.GetStatus() != pb.SnapshotStatus_SUCCESSFUL {
+		t.Error("Expected status SUCCESSFUL, got", res.GetStatus())
+	}
+}
+
+func TestReplayMissingMarker(t *testing.T) {
+	config.Reset()
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	path := filepath.Join(config.DataDir, "replay.aof")
+	aof := storage.NewAOF(path)
+	aof.Run()
+	typ := pb.SketchType_CARD
+	in := &pb.Sketch{Name: proto.String("marvel"), Type: &typ}
+	if err := aof.Append(storage.CreateSketch, in); err != nil {
+		t.Fatal("Did not expect error, got", err)
+	}
+	if err := aof.Append(storage.Add, &pb.AddRequest{Sketch: in, Values: []string{"hulk", "thor"}}); err != nil {
+		t.Fatal("Did not expect error, got", err)
+	}
+	if err := aof.Stop(); err != nil {
+		t.Fatal("Did not expect error, got", err)
+	}
+
+	// A marker that isn't in the AOF replays all of it
+	s := &serverStruct{manager: manager.NewManager(), storage: storage.NewAOF(path)}
+	s.replay(snapshotMarker(42))
+	if res, err := s.manager.GetFromSketch("marvel.CARD", nil); err != nil {
+		t.Error("Did not expect error, got", err)
+	} else if v := res.(*pb.CardinalityResult).GetCardinality(); v != 2 {
+		t.Error("Expected cardinality 2, got", v)
+	}
+}
@@ -0,0 +1,116 @@
+package server
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/context"
+
+	"config"
+	pb "datamodel/protobuf"
+	"testutils"
+)
+
+// TestConcurrentRPCs mixes all RPCs from concurrent clients, it is meant to
+// be run with the race detector
+func TestConcurrentRPCs(t *testing.T) {
+	config.Reset()
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	client, conn := setupClient()
+	defer tearDownClient(conn)
+
+	types := []pb.SketchType{pb.SketchType_CARD, pb.SketchType_MEMB, pb.SketchType_FREQ, pb.SketchType_RANK}
+	newSketch := func(r *rand.Rand) *pb.Sketch {
+		typ := types[r.Intn(len(types))]
+		return &pb.Sketch{
+			Name: proto.String(fmt.Sprintf("sketch%d", r.Intn(4))),
+			Type: &typ,
+			Properties: &pb.SketchProperties{
+				MaxUniqueItems: proto.Int64(100),
+				Size:           proto.Int64(10),
+			},
+		}
+	}
+	// Domains are created with a sketch of each type
+	newDomain := func(r *rand.Rand) *pb.Domain {
+		name := proto.String(fmt.Sprintf("domain%d", r.Intn(2)))
+		props := newSketch(r).GetProperties()
+		dom := &pb.Domain{Name: name}
+		for i := range types {
+			dom.Sketches = append(dom.Sketches, &pb.Sketch{Name: name, Type: &types[i], Properties: props})
+		}
+		return dom
+	}
+	newValues := func(r *rand.Rand) []string {
+		values := make([]string, r.Intn(20))
+		for i := range values {
+			values[i] = fmt.Sprintf("value%d", r.Intn(50))
+		}
+		return values
+	}
+
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			r := rand.New(rand.NewSource(seed))
+			for j := 0; j < 200; j++ {
+				// Errors are expected as clients race for the same names
+				switch r.Intn(14) {
+				case 0:
+					_, _ = client.CreateSketch(ctx, newSketch(r))
+				case 1:
+					_, _ = client.DeleteSketch(ctx, newSketch(r))
+				case 2:
+					_, _ = client.GetSketch(ctx, newSketch(r))
+				case 3:
+					_, _ = client.CreateDomain(ctx, newDomain(r))
+				case 4:
+					_, _ = client.DeleteDomain(ctx, newDomain(r))
+				case 5:
+					_, _ = client.GetDomain(ctx, newDomain(r))
+				case 6:
+					_, _ = client.Add(ctx, &pb.AddRequest{Sketch: newSketch(r), Values: newValues(r)})
+				case 7:
+					_, _ = client.Add(ctx, &pb.AddRequest{Domain: newDomain(r), Values: newValues(r)})
+				case 8:
+					sketch := newSketch(r)
+					req := &pb.GetRequest{Sketches: []*pb.Sketch{sketch}, Values: newValues(r)}
+					switch sketch.GetType() {
+					case pb.SketchType_CARD:
+						_, _ = client.GetCardinality(ctx, req)
+					case pb.SketchType_MEMB:
+						_, _ = client.GetMembership(ctx, req)
+					case pb.SketchType_FREQ:
+						_, _ = client.GetFrequency(ctx, req)
+					case pb.SketchType_RANK:
+						_, _ = client.GetRankings(ctx, req)
+					}
+				case 9:
+					_, _ = client.ListAll(ctx, &pb.Empty{})
+				case 10:
+					typ := types[r.Intn(len(types))]
+					_, _ = client.List(ctx, &pb.ListRequest{Type: &typ})
+				case 11:
+					_, _ = client.ListDomains(ctx, &pb.Empty{})
+				case 12:
+					_, _ = client.CreateSnapshot(ctx, &pb.CreateSnapshotRequest{})
+				case 13:
+					_, _ = client.GetSnapshot(ctx, &pb.GetSnapshotRequest{})
+				}
+			}
+		}(int64(i))
+	}
+	wg.Wait()
+
+	if _, err := client.ListAll(ctx, &pb.Empty{}); err != nil {
+		t.Error("Did not expect error, got", err)
+	}
+}
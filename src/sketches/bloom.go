@@ -1,6 +1,11 @@
 package sketches
 
 import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+
 	bloom "github.com/AndreasBriese/bbloom"
 
 	"datamodel"
@@ -8,50 +13,119 @@ import (
 	"utils"
 )
 
+// Range of the false positive rate of bloom filters, it sets the size of the
+// filter and its number of hash locations
+const (
+	defaultBloomErrorRate = 0.01
+	minBloomErrorRate     = 0.000001
+	maxBloomErrorRate     = 0.5
+)
+
 // BloomSketch is the toplevel Sketch to control the count-min-log implementation
 type BloomSketch struct {
 	*datamodel.Info
 	impl      *bloom.Bloom
 	threshold *Dict
+	errorRate float64
+	// bbloom only exposes its bitset through its serialization, it is read
+	// when the filter is built, loaded or merged and the distinct values
+	// added since are counted on insert
+	bits     uint64  // size of the filter in bits
+	locs     uint64  // hash locations per value
+	distinct float64 // estimated number of distinct values added
 }
 
 // NewBloomSketch ...
 func NewBloomSketch(info *datamodel.Info) (*BloomSketch, error) {
-	// FIXME: We are converting from int64 to uint
-	threshold := NewDict(info)
-	d := BloomSketch{info, nil, threshold}
+	if info.GetProperties().GetMaxUniqueItems() <= 0 {
+		return nil, fmt.Errorf("Can not create MEMB sketch without maxUniqueItems")
+	}
+	rate, err := errorRate(info, defaultBloomErrorRate, minBloomErrorRate, maxBloomErrorRate)
+	if err != nil {
+		return nil, err
+	}
+	setErrorRate(info, rate)
+	threshold, err := newThreshold(info)
+	if err != nil {
+		return nil, err
+	}
+	d := BloomSketch{info, nil, threshold, rate, 0, 0, 0}
+	if threshold == nil {
+		d.promote()
+	}
 	return &d, nil
 }
 
 // Add ...
 func (d *BloomSketch) Add(values [][]byte) (bool, error) {
-	success := true
-	dict := make(map[string]uint)
+	return d.AddCounts(countValues(values))
+}
+
+// AddCounts adds each value once, counts do not change the memberships
+func (d *BloomSketch) AddCounts(counts map[string]uint) (bool, error) {
 	if d.threshold != nil {
-		s, err := d.threshold.Add(values)
-		success = s
+		success, err := d.threshold.AddCounts(counts)
 		if err != nil {
 			return false, err
 		}
-		if !d.threshold.IsFull() {
-			return true, nil
+		if d.threshold.IsFull() {
+			d.promote()
 		}
-		values = d.threshold.Keys()
-		d.threshold = nil
-		if d.impl == nil {
-			sketch := bloom.New(float64(d.Info.Properties.GetMaxUniqueItems()), 4.0)
-			d.impl = &sketch
+		return success, nil
+	}
+
+	for v := range counts {
+		d.add([]byte(v))
+	}
+	return true, nil
+}
+
+// Insert adds values and returns whether each of them was new to the sketch, a
+// value repeated in values is only new the first time. Once promoted, a false
+// positive reports a new value as already present.
+func (d *BloomSketch) Insert(values [][]byte) ([]bool, error) {
+	inserted := make([]bool, len(values))
+	for i, v := range values {
+		if d.threshold != nil {
+			inserted[i] = d.threshold.impl[string(v)] == 0
+			d.threshold.impl[string(v)]++
+			if d.threshold.IsFull() {
+				d.promote()
+			}
+			continue
 		}
+		inserted[i] = d.add(v)
 	}
+	return inserted, nil
+}
+
+// add adds a value to the bloom filter and returns whether it was new to it
+func (d *BloomSketch) add(v []byte) bool {
+	if d.impl.Has(v) {
+		return false
+	}
+	d.impl.Add(v)
+	d.distinct++
+	return true
+}
 
-	for _, v := range values {
-		dict[string(v)]++
+// promote builds the bloom filter and moves the values of the threshold dict
+// to it
+func (d *BloomSketch) promote() {
+	if d.impl == nil {
+		// FIXME: We are converting from int64 to uint
+		sketch := bloom.New(float64(d.Info.Properties.GetMaxUniqueItems()), d.errorRate)
+		if err := d.load(&sketch); err != nil {
+			logger.Errorf("Failed to read the bloom filter of %s: %v", d.ID(), err)
+		}
+	}
+	if d.threshold == nil {
+		return
 	}
-	for v := range dict {
-		d.impl.Add([]byte(v))
+	for _, v := range d.threshold.Keys() {
+		d.add(v)
 	}
-	// Fixme: return what was added and what not
-	return success, nil
+	d.threshold = nil
 }
 
 // Get ...
@@ -64,6 +138,11 @@ func (d *BloomSketch) Get(data interface{}) (interface{}, error) {
 	tmpRes := make(map[string]*pb.Membership)
 	res := &pb.MembershipResult{
 		Memberships: make([]*pb.Membership, len(values), len(values)),
+		Exact:       utils.Boolp(false),
+	}
+	// A value that was not added hits set bits at all of its locations
+	if d.bits > 0 {
+		res.FalsePositiveRate = utils.Float64p(math.Pow(d.fill(), float64(d.locs)))
 	}
 
 	for i, v := range values {
@@ -79,3 +158,146 @@ func (d *BloomSketch) Get(data interface{}) (interface{}, error) {
 	}
 	return res, nil
 }
+
+// Marshal ...
+func (d *BloomSketch) Marshal() ([]byte, error) {
+	if d.threshold != nil {
+		data, err := d.threshold.Marshal()
+		return marshalStage(thresholdStage, data), err
+	}
+	// The number of set bits is not part of bbloom's serialization
+	buf := make([]byte, binary.MaxVarintLen64+8)
+	n := binary.PutUvarint(buf, d.impl.ElemNum)
+	binary.LittleEndian.PutUint64(buf[n:], math.Float64bits(d.distinct))
+	return marshalStage(sketchStage, append(buf[:n+8], d.impl.JSONMarshal()...)), nil
+}
+
+// Unmarshal ...
+func (d *BloomSketch) Unmarshal(data []byte) error {
+	version, stage, data, err := unmarshalVersion(data)
+	if err != nil {
+		return err
+	}
+	if stage == thresholdStage {
+		d.threshold = NewDict(d.Info)
+		return d.threshold.Unmarshal(data)
+	}
+	elemNum, n := binary.Uvarint(data)
+	if n <= 0 {
+		return fmt.Errorf("Invalid bloom filter data")
+	}
+	data = data[n:]
+	distinct := -1.0
+	if version > 3 {
+		if len(data) < 8 {
+			return fmt.Errorf("Invalid bloom filter data")
+		}
+		distinct = math.Float64frombits(binary.LittleEndian.Uint64(data))
+		data = data[8:]
+	}
+	sketch := bloom.JSONUnmarshal(data)
+	sketch.ElemNum = elemNum
+	d.threshold = nil
+	if err := d.load(&sketch); err != nil {
+		return err
+	}
+	// Older versions did not count the distinct values, load estimated them
+	if distinct >= 0 {
+		d.distinct = distinct
+	}
+	return nil
+}
+
+// load sets the filter of the sketch and reads its size and the number of
+// distinct values from its bitset
+func (d *BloomSketch) load(impl *bloom.Bloom) error {
+	fs, err := filterSet(impl)
+	if err != nil {
+		return err
+	}
+	d.impl = impl
+	d.bits = uint64(len(fs.FilterSet) * 8)
+	d.locs = fs.SetLocs
+	d.distinct = 0
+	if d.bits == 0 || d.locs == 0 {
+		return nil
+	}
+	set := 0
+	for _, b := range fs.FilterSet {
+		set += utils.PopCount(uint64(b))
+	}
+	// Estimate the number of distinct values from the number of bits set
+	fill := float64(set) / float64(d.bits)
+	d.distinct = -float64(d.bits) / float64(d.locs) * math.Log(1-fill)
+	return nil
+}
+
+// fill returns the expected share of the bits of the filter that are set
+func (d *BloomSketch) fill() float64 {
+	return 1 - math.Exp(-float64(d.locs)*d.distinct/float64(d.bits))
+}
+
+// bloomFilterSet is the serialization of a bbloom filter
+type bloomFilterSet struct {
+	FilterSet []byte
+	SetLocs   uint64
+}
+
+// filterSet returns the bitset of a bbloom filter, which it only exposes through
+// its serialization
+func filterSet(impl *bloom.Bloom) (*bloomFilterSet, error) {
+	fs := &bloomFilterSet{}
+	if err := json.Unmarshal(impl.JSONMarshal(), fs); err != nil {
+		return nil, err
+	}
+	return fs, nil
+}
+
+// Merge adds the values of other to the sketch by OR-ing both filters
+func (d *BloomSketch) Merge(other datamodel.Sketcher) error {
+	o, ok := other.(*BloomSketch)
+	if !ok {
+		return fmt.Errorf("Can not merge sketch of type %T into %s", other, d.GetType())
+	}
+	if o.threshold != nil {
+		_, err := d.Add(o.threshold.Keys())
+		return err
+	}
+	d.promote()
+
+	dst, err := filterSet(d.impl)
+	if err != nil {
+		return err
+	}
+	src, err := filterSet(o.impl)
+	if err != nil {
+		return err
+	}
+	if len(dst.FilterSet) != len(src.FilterSet) || dst.SetLocs != src.SetLocs {
+		return fmt.Errorf("Can not merge bloom filters of different sizes")
+	}
+	for i, b := range src.FilterSet {
+		dst.FilterSet[i] |= b
+	}
+	data, err := json.Marshal(dst)
+	if err != nil {
+		return err
+	}
+	sketch := bloom.JSONUnmarshal(data)
+	sketch.ElemNum = d.impl.ElemNum + o.impl.ElemNum
+	return d.load(&sketch)
+}
+
+// State ...
+func (d *BloomSketch) State(state *pb.SketchState) {
+	if d.threshold != nil {
+		d.threshold.State(state)
+		return
+	}
+	state.Exact = utils.Boolp(false)
+	if d.bits == 0 {
+		return
+	}
+	state.Memory = utils.Int64p(int64(d.bits / 8))
+	state.FillRate = utils.Float32p(fillRate(d.Info, d.distinct))
+}
@@ -1,7 +1,9 @@
 package sketches
 
 import (
+	"encoding/binary"
 	"fmt"
+	"math"
 	"strconv"
 	"testing"
 
@@ -160,3 +162,82 @@ func BenchmarkBloom(b *testing.B) {
 		}
 	}
 }
+
+func TestInsertBloom(t *testing.T) {
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	for _, threshold := range []int64{0, -1} {
+		info := datamodel.NewEmptyInfo()
+		info.Properties.MaxUniqueItems = utils.Int64p(1024)
+		info.Properties.ThresholdSize = utils.Int64p(threshold)
+		info.Name = utils.Stringp("marvel")
+		sketch, err := NewBloomSketch(info)
+		if err != nil {
+			t.Fatal("expected no errors, got", err)
+		}
+
+		values := [][]byte{[]byte("havoc"), []byte("cyclops"), []byte("havoc")}
+		if inserted, err := sketch.Insert(values); err != nil {
+			t.Error("expected no errors, got", err)
+		} else if !inserted[0] || !inserted[1] || inserted[2] {
+			t.Errorf("expected [true true false] with threshold %d, got %v", threshold, inserted)
+		}
+		values = [][]byte{[]byte("cyclops"), []byte("storm")}
+		if inserted, err := sketch.Insert(values); err != nil {
+			t.Error("expected no errors, got", err)
+		} else if inserted[0] || !inserted[1] {
+			t.Errorf("expected [false true] with threshold %d, got %v", threshold, inserted)
+		}
+	}
+}
+
+func TestUnmarshalBloomVersion3(t *testing.T) {
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	info := datamodel.NewEmptyInfo()
+	info.Properties.MaxUniqueItems = utils.Int64p(1024)
+	info.Properties.ThresholdSize = utils.Int64p(-1)
+	info.Name = utils.Stringp("marvel")
+	sketch, err := NewBloomSketch(info)
+	if err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+	values := make([][]byte, 500)
+	for i := range values {
+		values[i] = []byte(strconv.Itoa(i))
+	}
+	if _, err := sketch.Add(values); err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+	if sketch.distinct != 500 {
+		t.Errorf("expected 500 distinct values, got %v", sketch.distinct)
+	}
+
+	// Version 3 did not store the number of distinct values after ElemNum
+	data, err := sketch.Marshal()
+	if err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+	data[0] = 3
+	_, n := binary.Uvarint(data[2:])
+	data = append(data[:2+n], data[2+n+8:]...)
+
+	loaded, err := NewBloomSketch(info.Copy())
+	if err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+	if err := loaded.Unmarshal(data); err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+	// The number is estimated from the bits set instead
+	if math.Abs(loaded.distinct-500) > 25 {
+		t.Errorf("expected about 500 distinct values, got %v", loaded.distinct)
+	}
+	for _, v := range values {
+		if !loaded.impl.Has(v) {
+			t.Errorf("expected %s to be a member", v)
+		}
+	}
+}
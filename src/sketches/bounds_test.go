@@ -0,0 +1,200 @@
+package sketches
+
+import (
+	"encoding/binary"
+	"fmt"
+	"testing"
+
+	"datamodel"
+	pb "datamodel/protobuf"
+	"testutils"
+	"utils"
+)
+
+func TestExactResults(t *testing.T) {
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	for _, typ := range []pb.SketchType{pb.SketchType_CARD, pb.SketchType_FREQ, pb.SketchType_MEMB} {
+		sketch, err := createWithThreshold(typ, 10000, 100)
+		if err != nil {
+			t.Fatal("expected no errors, got", err)
+		}
+		for _, n := range []int{50, 5000} {
+			if _, err := sketch.Add(users(0, n)); err != nil {
+				t.Fatal("expected no errors, got", err)
+			}
+			res, err := sketch.Get(users(0, 1))
+			if err != nil {
+				t.Fatal("expected no errors, got", err)
+			}
+			var exact bool
+			switch r := res.(type) {
+			case *pb.CardinalityResult:
+				exact = r.GetExact()
+			case *pb.FrequencyResult:
+				exact = r.GetExact()
+			case *pb.MembershipResult:
+				exact = r.GetExact()
+			}
+			if expected := n < 100; exact != expected {
+				t.Errorf("expected exact == %v for %s with %d values, got %v", expected, typ, n, exact)
+			}
+		}
+	}
+}
+
+func TestCardinalityBounds(t *testing.T) {
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	sketch, err := createWithThreshold(pb.SketchType_CARD, 10000, 100)
+	if err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+	if _, err := sketch.Add(users(0, 50)); err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+	res, _ := sketch.Get(nil)
+	if r := res.(*pb.CardinalityResult); r.GetLower() != 50 || r.GetUpper() != 50 {
+		t.Errorf("expected exact bounds [50, 50], got %v", r)
+	}
+
+	if _, err := sketch.Add(users(50, 100000)); err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+	res, _ = sketch.Get(nil)
+	r := res.(*pb.CardinalityResult)
+	// One standard error on each side, 0.81% for the default precision
+	if r.GetLower() >= r.GetCardinality() || r.GetUpper() <= r.GetCardinality() ||
+		r.GetUpper()-r.GetLower() < 1500 || r.GetUpper()-r.GetLower() > 1700 {
+		t.Errorf("expected a standard error range around the cardinality, got %v", r)
+	}
+	if err := r.GetUpper() - r.GetLower(); r.GetCardinality() < 100000-3*err/2 || r.GetCardinality() > 100000+3*err/2 {
+		t.Errorf("expected cardinality 100000 within 3 standard errors, got %v", r)
+	}
+}
+
+func TestFrequencyBounds(t *testing.T) {
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	sketch, err := createWithThreshold(pb.SketchType_FREQ, 1000, -1)
+	if err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+	if _, err := sketch.Add(users(0, 1000)); err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+	query := append(users(0, 10), []byte("hulk"))
+	res, _ := sketch.Get(query)
+	for _, f := range res.(*pb.FrequencyResult).GetFrequencies() {
+		expected := int64(1)
+		if f.GetValue() == "hulk" {
+			expected = 0
+		}
+		// Collisions overestimate counts by at most 1% of the 1000 values
+		// added, the log counters are within a standard deviation
+		noise := counterError(f.GetCount())
+		if f.GetUpper() != f.GetCount()+noise || f.GetLower() != f.GetCount()-10-noise && f.GetLower() != 0 {
+			t.Errorf("expected bounds [count-10-%d, count+%d], got %v", noise, noise, f)
+		}
+		if f.GetLower() > expected || f.GetUpper() < expected {
+			t.Errorf("expected %d within the bounds, got %v", expected, f)
+		}
+	}
+
+	// Sketches serialized with version 1 did not keep the total count
+	data, err := sketch.Marshal()
+	if err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+	_, n := binary.Varint(data[2:])
+	data = append([]byte{1, sketchStage}, data[2+n:]...)
+	loaded, err := LoadSketch(sketch.Info, data)
+	if err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+	res, _ = loaded.Get(users(0, 1))
+	if f := res.(*pb.FrequencyResult).GetFrequencies()[0]; f.GetLower() != 0 || f.GetUpper() != f.GetCount()+counterError(f.GetCount()) {
+		t.Errorf("expected bounds [0, count+%d] without the total count, got %v", counterError(f.GetCount()), f)
+	}
+}
+
+func TestFalsePositiveRate(t *testing.T) {
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	for _, deletable := range []bool{false, true} {
+		info := datamodel.NewEmptyInfo()
+		info.Properties.MaxUniqueItems = utils.Int64p(10000)
+		info.Properties.ThresholdSize = utils.Int64p(-1)
+		info.Properties.Deletable = utils.Boolp(deletable)
+		info.Name = utils.Stringp("marvel")
+		typ := pb.SketchType_MEMB
+		info.Type = &typ
+		sketch, err := CreateSketch(info)
+		if err != nil {
+			t.Fatal("expected no errors, got", err)
+		}
+
+		rate := 0.0
+		for _, n := range []int{0, 5000, 10000} {
+			if _, err := sketch.Add(users(0, n)); err != nil {
+				t.Fatal("expected no errors, got", err)
+			}
+			res, _ := sketch.Get(users(0, 1))
+			r := res.(*pb.MembershipResult).GetFalsePositiveRate()
+			if n > 0 && r <= rate || r > 0.01 {
+				t.Errorf("expected the false positive rate to grow up to 1%% with %d values, got %v", n, r)
+			}
+			rate = r
+		}
+
+		// The rate matches the false positives of values that were not added
+		res, _ := sketch.Get(users(10000, 110000))
+		fp := 0
+		for _, m := range res.(*pb.MembershipResult).GetMemberships() {
+			if m.GetIsMember() {
+				fp++
+			}
+		}
+		if r := float64(fp) / 100000; r > 2*rate || r < rate/2 {
+			t.Errorf("expected a false positive rate ~= %v, got %v", rate, r)
+		}
+	}
+}
+
+func TestRankingsError(t *testing.T) {
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	info := datamodel.NewEmptyInfo()
+	info.Properties.Size = utils.Int64p(4)
+	info.Name = utils.Stringp("marvel")
+	typ := pb.SketchType_RANK
+	info.Type = &typ
+	sketch, err := CreateSketch(info)
+	if err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+	counts := map[string]uint{}
+	for i := 0; i < 100; i++ {
+		counts[fmt.Sprintf("hero-%d", i)] = uint(i%10 + 1)
+	}
+	if _, err := sketch.AddCountsAt(counts, 0); err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+	res, _ := sketch.Get(nil)
+	evicted := false
+	for _, r := range res.(*pb.RankingsResult).GetRankings() {
+		actual := int64(counts[r.GetValue()])
+		if r.GetCount()-r.GetError() > actual || r.GetCount() < actual {
+			t.Errorf("expected the count of %s to be %d within the error, got %v", r.GetValue(), actual, r)
+		}
+		evicted = evicted || r.GetError() > 0
+	}
+	if !evicted {
+		t.Error("expected errors once elements were evicted")
+	}
+}
@@ -1,6 +1,10 @@
 package sketches
 
 import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
 	"github.com/skizzehq/count-min-log"
 
 	"datamodel"
@@ -8,53 +12,114 @@ import (
 	"utils"
 )
 
+// Range of the error rate of CML sketches, it sets the width of the sketch.
+// Collisions overestimate a count by at most the error rate times the total
+// count with high probability.
+const (
+	defaultCMLErrorRate = 0.01
+	minCMLErrorRate     = 0.00001
+	maxCMLErrorRate     = 0.5
+)
+
+// cmlBase is the base of the log counters count-min-log sketches are built
+// with. A counter reaching n estimates it with a variance of (base-1)/2*n^2,
+// so it may under- as well as overestimate the count.
+const cmlBase = 1.00026
+
+// counterError returns one standard deviation of the log counter estimating
+// count
+func counterError(count int64) int64 {
+	return int64(math.Ceil(math.Sqrt((cmlBase-1)/2) * float64(count)))
+}
+
 // CMLSketch is the toplevel Sketch to control the count-min-log implementation
 type CMLSketch struct {
 	*datamodel.Info
 	impl      *cml.Sketch
 	threshold *Dict
+	errorRate float64
+	// Total count of the values added once promoted, -1 if unknown as sketches
+	// serialized with version 1 did not keep it
+	total int64
 }
 
 // NewCMLSketch ...
 func NewCMLSketch(info *datamodel.Info) (*CMLSketch, error) {
-	threshold := NewDict(info)
-	d := CMLSketch{info, nil, threshold}
+	if info.GetProperties().GetMaxUniqueItems() <= 0 {
+		return nil, fmt.Errorf("Can not create FREQ sketch without maxUniqueItems")
+	}
+	rate, err := errorRate(info, defaultCMLErrorRate, minCMLErrorRate, maxCMLErrorRate)
+	if err != nil {
+		return nil, err
+	}
+	setErrorRate(info, rate)
+	threshold, err := newThreshold(info)
+	if err != nil {
+		return nil, err
+	}
+	d := CMLSketch{info, nil, threshold, rate, 0}
+	if threshold == nil {
+		if err := d.promote(); err != nil {
+			return nil, err
+		}
+	}
 	return &d, nil
 }
 
 // Add ...
 func (d *CMLSketch) Add(values [][]byte) (bool, error) {
-	success := true
-	dict := make(map[string]uint)
+	return d.AddCounts(countValues(values))
+}
+
+// AddCounts adds each value count times
+func (d *CMLSketch) AddCounts(counts map[string]uint) (bool, error) {
 	if d.threshold != nil {
-		s, err := d.threshold.Add(values)
-		success = s
+		success, err := d.threshold.AddCounts(counts)
 		if err != nil {
 			return false, err
 		}
-		if !d.threshold.IsFull() {
-			return true, nil
-		}
-		values = d.threshold.Keys()
-		d.threshold = nil
-		if d.impl == nil {
-			sketch, err := cml.NewForCapacity16(uint64(d.Info.Properties.GetMaxUniqueItems()), 0.01)
-			if err != nil {
-				logger.Errorf("an error has occurred while saving CMLSketch: %s", err.Error())
+		if d.threshold.IsFull() {
+			if err := d.promote(); err != nil {
+				return false, err
 			}
-			d.impl = sketch
 		}
+		return success, nil
+	}
+
+	// BulkUpdate reports whether a log counter was incremented, which is
+	// random and not a failure to add the values
+	for v, count := range counts {
+		d.impl.BulkUpdate([]byte(v), count)
+		d.addTotal(int64(count))
 	}
+	return true, nil
+}
 
-	for _, v := range values {
-		dict[string(v)]++
+func (d *CMLSketch) addTotal(count int64) {
+	if d.total >= 0 {
+		d.total += count
 	}
-	for v, count := range dict {
-		if b := d.impl.BulkUpdate([]byte(v), count); !b {
-			success = false
+}
+
+// promote builds the count-min-log sketch and moves the counts of the
+// threshold dict to it
+func (d *CMLSketch) promote() error {
+	if d.impl == nil {
+		sketch, err := cml.NewForCapacity16(uint64(d.Info.Properties.GetMaxUniqueItems()), d.errorRate)
+		if err != nil {
+			return err
 		}
+		d.impl = sketch
 	}
-	return success, nil
+	if d.threshold == nil {
+		return nil
+	}
+	for v, count := range d.threshold.impl {
+		d.impl.BulkUpdate([]byte(v), count)
+		d.addTotal(int64(count))
+	}
+	d.threshold = nil
+	return nil
 }
 
 // Get ...
@@ -66,6 +131,7 @@ func (d *CMLSketch) Get(data interface{}) (interface{}, error) {
 	values := data.([][]byte)
 	res := &pb.FrequencyResult{
 		Frequencies: make([]*pb.Frequency, len(values), len(values)),
+		Exact:       utils.Boolp(false),
 	}
 	tmpRes := make(map[string]*pb.Frequency)
 	for i, v := range values {
@@ -73,11 +139,115 @@ func (d *CMLSketch) Get(data interface{}) (interface{}, error) {
 			res.Frequencies[i] = r
 			continue
 		}
+		// The bounds are approximate: collisions are bounded with high
+		// probability, the log counter is one standard deviation away
+		count := int64(d.impl.Query(v))
+		noise := counterError(count)
+		lower := int64(0)
+		if d.total >= 0 {
+			lower = count - int64(math.Ceil(d.errorRate*float64(d.total))) - noise
+			if lower < 0 {
+				lower = 0
+			}
+		}
 		res.Frequencies[i] = &pb.Frequency{
 			Value: utils.Stringp(string(v)),
-			Count: utils.Int64p(int64(d.impl.Query(v))),
+			Count: utils.Int64p(count),
+			Lower: utils.Int64p(lower),
+			Upper: utils.Int64p(count + noise),
 		}
 		tmpRes[string(v)] = res.Frequencies[i]
 	}
 	return res, nil
 }
+
+// Marshal ...
+func (d *CMLSketch) Marshal() ([]byte, error) {
+	if d.threshold != nil {
+		data, err := d.threshold.Marshal()
+		return marshalStage(thresholdStage, data), err
+	}
+	data, err := d.impl.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutVarint(buf, d.total)
+	return marshalStage(sketchStage, append(buf[:n], data...)), nil
+}
+
+// Unmarshal ...
+func (d *CMLSketch) Unmarshal(data []byte) error {
+	version, stage, data, err := unmarshalVersion(data)
+	if err != nil {
+		return err
+	}
+	if stage == thresholdStage {
+		d.threshold = NewDict(d.Info)
+		return d.threshold.Unmarshal(data)
+	}
+	total := int64(-1)
+	if version > 1 {
+		var n int
+		if total, n = binary.Varint(data); n <= 0 {
+			return fmt.Errorf("Invalid CML sketch data")
+		}
+		data = data[n:]
+	}
+	impl := &cml.Sketch{}
+	if err := impl.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	d.threshold = nil
+	d.impl = impl
+	d.total = total
+	return nil
+}
+
+// Merge adds the counts of other to the sketch
+func (d *CMLSketch) Merge(other datamodel.Sketcher) error {
+	o, ok := other.(*CMLSketch)
+	if !ok {
+		return fmt.Errorf("Can not merge sketch of type %T into %s", other, d.GetType())
+	}
+	if o.threshold != nil {
+		if d.threshold != nil {
+			d.threshold.Merge(o.threshold)
+			if d.threshold.IsFull() {
+				return d.promote()
+			}
+			return nil
+		}
+		for v, count := range o.threshold.impl {
+			d.impl.BulkUpdate([]byte(v), count)
+			d.addTotal(int64(count))
+		}
+		return nil
+	}
+	if err := d.promote(); err != nil {
+		return err
+	}
+	if err := d.impl.Merge(o.impl); err != nil {
+		return err
+	}
+	if o.total < 0 {
+		d.total = -1
+	}
+	d.addTotal(o.total)
+	return nil
+}
+
+// State ...
+func (d *CMLSketch) State(state *pb.SketchState) {
+	if d.threshold != nil {
+		d.threshold.State(state)
+		return
+	}
+	state.Exact = utils.Boolp(false)
+	if data, err := d.impl.MarshalBinary(); err == nil {
+		state.Memory = utils.Int64p(int64(len(data)))
+	}
+	// The counters of the sketch saturate as the total count reaches the
+	// capacity it was built for
+	state.FillRate = utils.Float32p(fillRate(d.Info, float64(state.GetValuesAdded())))
+}
@@ -89,8 +89,15 @@ func TestAddCMLThreshold(t *testing.T) {
 			tmp := res.(*pb.FrequencyResult)
 			mres := tmp.GetFrequencies()
 			for i := 0; i < len(mres); i++ {
-				if key := mres[i].GetValue(); rValues[key] != uint64(mres[i].GetCount()) {
-					t.Fatalf("expected %s: %d, got %d", mres[i].GetValue(), rValues[key], uint64(mres[i].GetCount()))
+				key := mres[i].GetValue()
+				// Counts are exact in the threshold stage and carried over
+				// to the approximate sketch once it is promoted
+				tolerance := uint64(0)
+				if sketch.threshold == nil {
+					tolerance = rValues[key]/10 + 2
+				}
+				if count := uint64(mres[i].GetCount()); count+tolerance < rValues[key] || count > rValues[key]+tolerance {
+					t.Fatalf("expected %s: %d, got %d", key, rValues[key], count)
 				}
 			}
 		}
@@ -119,3 +126,82 @@ func BenchmarkCML(b *testing.B) {
 		}
 	}
 }
+
+func TestAddCountsCML(t *testing.T) {
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	for _, threshold := range []int64{0, -1} {
+		info := datamodel.NewEmptyInfo()
+		info.Properties.MaxUniqueItems = utils.Int64p(1000000)
+		info.Properties.ThresholdSize = utils.Int64p(threshold)
+		info.Name = utils.Stringp("marvel")
+		typ := pb.SketchType_FREQ
+		info.Type = &typ
+		sketch, err := NewCMLSketch(info)
+		if err != nil {
+			t.Fatal("expected no errors, got", err)
+		}
+
+		// Log counters that aren't incremented do not fail the add
+		counts := map[string]uint{"cyclops": 10000, "havoc": 3}
+		if ok, err := sketch.AddCounts(counts); err != nil || !ok {
+			t.Errorf("expected success and no errors, got %t, %v", ok, err)
+		}
+		if ok, err := sketch.Add([][]byte{[]byte("havoc")}); err != nil || !ok {
+			t.Errorf("expected success and no errors, got %t, %v", ok, err)
+		}
+
+		res, err := sketch.Get([][]byte{[]byte("cyclops"), []byte("havoc")})
+		if err != nil {
+			t.Fatal("expected no errors, got", err)
+		}
+		freqs := res.(*pb.FrequencyResult).GetFrequencies()
+		// Counts are exact in the threshold stage only
+		if c := freqs[0].GetCount(); (threshold == 0 && c != 10000) || c < 9000 || c > 11000 {
+			t.Errorf("expected 'cyclops' count ~= 10000 with threshold %d, got %d", threshold, c)
+		}
+		if c := freqs[1].GetCount(); c != 4 {
+			t.Errorf("expected 'havoc' count == 4 with threshold %d, got %d", threshold, c)
+		}
+	}
+}
+
+func TestCounterError(t *testing.T) {
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	info := datamodel.NewEmptyInfo()
+	info.Properties.MaxUniqueItems = utils.Int64p(1000)
+	info.Properties.ThresholdSize = utils.Int64p(-1)
+	info.Name = utils.Stringp("marvel")
+	typ := pb.SketchType_FREQ
+	info.Type = &typ
+
+	// Log counters may underestimate, the bounds are one standard deviation
+	// away from the count
+	within := 0
+	for i := 0; i < 20; i++ {
+		sketch, err := NewCMLSketch(info)
+		if err != nil {
+			t.Fatal("expected no errors, got", err)
+		}
+		if _, err := sketch.AddCounts(map[string]uint{"cyclops": 100000}); err != nil {
+			t.Fatal("expected no errors, got", err)
+		}
+		res, err := sketch.Get([][]byte{[]byte("cyclops")})
+		if err != nil {
+			t.Fatal("expected no errors, got", err)
+		}
+		f := res.(*pb.FrequencyResult).GetFrequencies()[0]
+		if f.GetUpper() <= f.GetCount() {
+			t.Errorf("expected upper above the count, got %v", f)
+		}
+		if f.GetLower() <= 100000 && f.GetUpper() >= 100000 {
+			within++
+		}
+	}
+	if within < 5 {
+		t.Errorf("expected the count within the bounds most of the time, got %d out of 20", within)
+	}
+}
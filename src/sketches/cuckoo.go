@@ -0,0 +1,420 @@
+package sketches
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sort"
+
+	"datamodel"
+	pb "datamodel/protobuf"
+	"utils"
+)
+
+// Cuckoo filters keep the fingerprints of the values in buckets of
+// cuckooBucketSize slots, a value can be in one of two buckets
+const (
+	cuckooBucketSize = 4
+	cuckooLoadFactor = 0.95
+	cuckooMaxKicks   = 500
+)
+
+// Range of the false positive rate of cuckoo filters, it sets the number of
+// bits of the fingerprints
+const (
+	defaultCuckooErrorRate = 0.01
+	minCuckooErrorRate     = 2 * cuckooBucketSize / float64(1<<16)
+	maxCuckooErrorRate     = 0.5
+)
+
+// CuckooSketch is a MEMB sketch values can be removed from. Values are only
+// stored once, a value that is a false positive when added is not stored and
+// removing the value it collides with removes it as well.
+type CuckooSketch struct {
+	*datamodel.Info
+	slots     []uint16 // fingerprints, 0 for an empty slot
+	mask      uint64   // number of buckets - 1
+	fpMask    uint16
+	count     uint64
+	threshold *Dict
+	errorRate float64
+	// A fingerprint that could not be placed, the filter is full while it is set
+	victim      uint16
+	victimIndex uint64
+}
+
+// cuckooHeader precedes the slots of a serialized cuckoo filter
+type cuckooHeader struct {
+	Count       uint64
+	Victim      uint16
+	VictimIndex uint64
+}
+
+// NewCuckooSketch ...
+func NewCuckooSketch(info *datamodel.Info) (*CuckooSketch, error) {
+	if info.GetProperties().GetMaxUniqueItems() <= 0 {
+		return nil, fmt.Errorf("Can not create MEMB sketch without maxUniqueItems")
+	}
+	rate, err := errorRate(info, defaultCuckooErrorRate, minCuckooErrorRate, maxCuckooErrorRate)
+	if err != nil {
+		return nil, err
+	}
+	setErrorRate(info, rate)
+	threshold, err := newThreshold(info)
+	if err != nil {
+		return nil, err
+	}
+	// A lookup compares the fingerprint to 2 buckets of slots
+	bits := math.Ceil(math.Log2(2 * cuckooBucketSize / rate))
+	d := &CuckooSketch{
+		Info:      info,
+		fpMask:    uint16(1<<uint(bits) - 1),
+		threshold: threshold,
+		errorRate: rate,
+	}
+	if threshold == nil {
+		if err := d.promote(); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+// buckets returns the number of buckets holding the maxUniqueItems of the
+// sketch, a power of 2
+func (d *CuckooSketch) buckets() uint64 {
+	n := uint64(math.Ceil(float64(d.Info.Properties.GetMaxUniqueItems()) / cuckooBucketSize / cuckooLoadFactor))
+	buckets := uint64(1)
+	for buckets < n {
+		buckets <<= 1
+	}
+	return buckets
+}
+
+// locate returns the fingerprint of value and the first bucket it can be in
+func (d *CuckooSketch) locate(value []byte) (uint16, uint64) {
+	h := fnv.New64a()
+	_, _ = h.Write(value)
+	sum := h.Sum64()
+	fp := uint16(sum>>48) & d.fpMask
+	if fp == 0 {
+		fp = 1
+	}
+	return fp, sum & d.mask
+}
+
+// alt returns the other bucket of the fingerprint fp in bucket i
+func (d *CuckooSketch) alt(i uint64, fp uint16) uint64 {
+	return (i ^ uint64(fp)*0x5bd1e995) & d.mask
+}
+
+// put stores fp in a free slot of bucket i
+func (d *CuckooSketch) put(fp uint16, i uint64) bool {
+	for s := i * cuckooBucketSize; s < (i+1)*cuckooBucketSize; s++ {
+		if d.slots[s] == 0 {
+			d.slots[s] = fp
+			return true
+		}
+	}
+	return false
+}
+
+// take removes fp from bucket i
+func (d *CuckooSketch) take(fp uint16, i uint64) bool {
+	for s := i * cuckooBucketSize; s < (i+1)*cuckooBucketSize; s++ {
+		if d.slots[s] == fp {
+			d.slots[s] = 0
+			return true
+		}
+	}
+	return false
+}
+
+func (d *CuckooSketch) has(fp uint16, i uint64) bool {
+	for s := i * cuckooBucketSize; s < (i+1)*cuckooBucketSize; s++ {
+		if d.slots[s] == fp {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *CuckooSketch) contains(fp uint16, i uint64) bool {
+	j := d.alt(i, fp)
+	if d.victim == fp && (d.victimIndex == i || d.victimIndex == j) {
+		return true
+	}
+	return d.has(fp, i) || d.has(fp, j)
+}
+
+// insert stores fp in bucket i or its alternate one, moving other fingerprints
+// to their alternate bucket to make room if needed
+func (d *CuckooSketch) insert(fp uint16, i uint64) error {
+	if d.victim != 0 {
+		return fmt.Errorf("MEMB sketch %s is full", d.ID())
+	}
+	d.count++
+	if d.put(fp, i) || d.put(fp, d.alt(i, fp)) {
+		return nil
+	}
+	for n := uint64(0); n < cuckooMaxKicks; n++ {
+		s := i*cuckooBucketSize + d.kick(fp, n)
+		fp, d.slots[s] = d.slots[s], fp
+		i = d.alt(i, fp)
+		if d.put(fp, i) {
+			return nil
+		}
+	}
+	// Keep the last fingerprint moved out, no more values can be inserted
+	d.victim, d.victimIndex = fp, i
+	return nil
+}
+
+// kick picks the slot of a full bucket to move a fingerprint out of for the
+// n-th kick of fp. It only depends on the state of the filter so that
+// replaying the same values rebuilds the same filter.
+func (d *CuckooSketch) kick(fp uint16, n uint64) uint64 {
+	h := (d.count*cuckooMaxKicks + n) ^ uint64(fp)<<48
+	h *= 0x9e3779b97f4a7c15
+	return (h >> 32) % cuckooBucketSize
+}
+
+// remove removes fp from bucket i or its alternate one
+func (d *CuckooSketch) remove(fp uint16, i uint64) bool {
+	j := d.alt(i, fp)
+	switch {
+	case d.victim == fp && (d.victimIndex == i || d.victimIndex == j):
+		d.victim = 0
+	case d.take(fp, i), d.take(fp, j):
+		// There is room for the victim now
+		if d.victim != 0 {
+			victim := d.victim
+			d.victim = 0
+			d.count--
+			_ = d.insert(victim, d.victimIndex)
+		}
+	default:
+		return false
+	}
+	d.count--
+	return true
+}
+
+// Add ...
+func (d *CuckooSketch) Add(values [][]byte) (bool, error) {
+	return d.AddCounts(countValues(values))
+}
+
+// AddCounts adds each value once, counts do not change the memberships
+func (d *CuckooSketch) AddCounts(counts map[string]uint) (bool, error) {
+	if d.threshold != nil {
+		success, err := d.threshold.AddCounts(counts)
+		if err != nil {
+			return false, err
+		}
+		if d.threshold.IsFull() {
+			if err := d.promote(); err != nil {
+				return false, err
+			}
+		}
+		return success, nil
+	}
+
+	// Insert in a fixed order so that kicks, and the filter, only depend on
+	// the values added
+	values := make([]string, 0, len(counts))
+	for v := range counts {
+		values = append(values, v)
+	}
+	sort.Strings(values)
+	for _, v := range values {
+		fp, i := d.locate([]byte(v))
+		if d.contains(fp, i) {
+			continue
+		}
+		if err := d.insert(fp, i); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// Insert adds values and returns whether each of them was new to the sketch, a
+// value repeated in values is only new the first time. Once promoted, a false
+// positive reports a new value as already present.
+func (d *CuckooSketch) Insert(values [][]byte) ([]bool, error) {
+	inserted := make([]bool, len(values))
+	for i, v := range values {
+		if d.threshold != nil {
+			inserted[i] = d.threshold.impl[string(v)] == 0
+			d.threshold.impl[string(v)]++
+			if d.threshold.IsFull() {
+				if err := d.promote(); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+		fp, j := d.locate(v)
+		if d.contains(fp, j) {
+			continue
+		}
+		if err := d.insert(fp, j); err != nil {
+			return nil, err
+		}
+		inserted[i] = true
+	}
+	return inserted, nil
+}
+
+// Remove removes values and returns whether each of them was held by the
+// sketch. Once promoted, removing a value that was never added but shares the
+// fingerprint and buckets of another one removes the latter.
+func (d *CuckooSketch) Remove(values [][]byte) ([]bool, error) {
+	removed := make([]bool, len(values))
+	for i, v := range values {
+		if d.threshold != nil {
+			_, removed[i] = d.threshold.impl[string(v)]
+			delete(d.threshold.impl, string(v))
+			continue
+		}
+		fp, j := d.locate(v)
+		removed[i] = d.remove(fp, j)
+	}
+	return removed, nil
+}
+
+// promote builds the cuckoo filter and moves the values of the threshold dict
+// to it
+func (d *CuckooSketch) promote() error {
+	if d.slots == nil {
+		buckets := d.buckets()
+		d.slots = make([]uint16, buckets*cuckooBucketSize)
+		d.mask = buckets - 1
+	}
+	if d.threshold == nil {
+		return nil
+	}
+	threshold := d.threshold
+	d.threshold = nil
+	_, err := d.Add(threshold.Keys())
+	return err
+}
+
+// Get ...
+func (d *CuckooSketch) Get(data interface{}) (interface{}, error) {
+	if d.threshold != nil {
+		return d.threshold.Get(data)
+	}
+
+	values := data.([][]byte)
+	// A value that was not added matches a fingerprint held by one of the
+	// slots of its 2 buckets
+	matches := 2 * cuckooBucketSize * float64(d.count) / float64(len(d.slots))
+	rate := 1 - math.Pow(1-1/float64(d.fpMask), matches)
+	res := &pb.MembershipResult{
+		Memberships:       make([]*pb.Membership, len(values), len(values)),
+		FalsePositiveRate: utils.Float64p(rate),
+		Exact:             utils.Boolp(false),
+	}
+	for i, v := range values {
+		fp, j := d.locate(v)
+		res.Memberships[i] = &pb.Membership{
+			Value:    utils.Stringp(string(v)),
+			IsMember: utils.Boolp(d.contains(fp, j)),
+		}
+	}
+	return res, nil
+}
+
+// Marshal ...
+func (d *CuckooSketch) Marshal() ([]byte, error) {
+	if d.threshold != nil {
+		data, err := d.threshold.Marshal()
+		return marshalStage(thresholdStage, data), err
+	}
+	buf := &bytes.Buffer{}
+	header := cuckooHeader{d.count, d.victim, d.victimIndex}
+	if err := binary.Write(buf, binary.LittleEndian, header); err != nil {
+		return nil, err
+	}
+	if err := binary.Write(buf, binary.LittleEndian, d.slots); err != nil {
+		return nil, err
+	}
+	return marshalStage(sketchStage, buf.Bytes()), nil
+}
+
+// Unmarshal ...
+func (d *CuckooSketch) Unmarshal(data []byte) error {
+	stage, data, err := unmarshalStage(data)
+	if err != nil {
+		return err
+	}
+	if stage == thresholdStage {
+		d.threshold = NewDict(d.Info)
+		return d.threshold.Unmarshal(data)
+	}
+	rdr := bytes.NewReader(data)
+	header := cuckooHeader{}
+	if err := binary.Read(rdr, binary.LittleEndian, &header); err != nil {
+		return err
+	}
+	buckets := d.buckets()
+	if uint64(rdr.Len()) != buckets*cuckooBucketSize*2 {
+		return fmt.Errorf("Invalid cuckoo filter data")
+	}
+	slots := make([]uint16, buckets*cuckooBucketSize)
+	if err := binary.Read(rdr, binary.LittleEndian, slots); err != nil {
+		return err
+	}
+	d.threshold = nil
+	d.slots = slots
+	d.mask = buckets - 1
+	d.count, d.victim, d.victimIndex = header.Count, header.Victim, header.VictimIndex
+	return nil
+}
+
+// Merge adds the fingerprints of other to the sketch, both filters must have
+// the same size
+func (d *CuckooSketch) Merge(other datamodel.Sketcher) error {
+	o, ok := other.(*CuckooSketch)
+	if !ok {
+		return fmt.Errorf("Can not merge sketch of type %T into %s", other, d.GetType())
+	}
+	if o.threshold != nil {
+		_, err := d.Add(o.threshold.Keys())
+		return err
+	}
+	if err := d.promote(); err != nil {
+		return err
+	}
+	if len(o.slots) != len(d.slots) || o.fpMask != d.fpMask {
+		return fmt.Errorf("Can not merge cuckoo filters of different sizes")
+	}
+	for s, fp := range o.slots {
+		i := uint64(s) / cuckooBucketSize
+		if fp == 0 || d.contains(fp, i) {
+			continue
+		}
+		if err := d.insert(fp, i); err != nil {
+			return err
+		}
+	}
+	if o.victim != 0 && !d.contains(o.victim, o.victimIndex) {
+		return d.insert(o.victim, o.victimIndex)
+	}
+	return nil
+}
+
+// State ...
+func (d *CuckooSketch) State(state *pb.SketchState) {
+	if d.threshold != nil {
+		d.threshold.State(state)
+		return
+	}
+	state.Exact = utils.Boolp(false)
+	state.Memory = utils.Int64p(int64(2 * len(d.slots)))
+	state.FillRate = utils.Float32p(fillRate(d.Info, float64(d.count)))
+}
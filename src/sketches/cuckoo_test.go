@@ -0,0 +1,219 @@
+package sketches
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"datamodel"
+	pb "datamodel/protobuf"
+	"testutils"
+	"utils"
+)
+
+func createCuckooSketch(t *testing.T, maxUniqueItems, thresholdSize int64) *CuckooSketch {
+	info := datamodel.NewEmptyInfo()
+	info.Properties.MaxUniqueItems = utils.Int64p(maxUniqueItems)
+	info.Properties.ThresholdSize = utils.Int64p(thresholdSize)
+	info.Properties.Deletable = utils.Boolp(true)
+	info.Name = utils.Stringp("online")
+	typ := pb.SketchType_MEMB
+	info.Type = &typ
+	sketch, err := NewCuckooSketch(info)
+	if err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+	return sketch
+}
+
+func users(from, to int) [][]byte {
+	values := make([][]byte, 0, to-from)
+	for i := from; i < to; i++ {
+		values = append(values, []byte(fmt.Sprintf("user-%d", i)))
+	}
+	return values
+}
+
+func members(t *testing.T, sketch interface {
+	Get(interface{}) (interface{}, error)
+}, values [][]byte) int {
+	res, err := sketch.Get(values)
+	if err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+	n := 0
+	for _, m := range res.(*pb.MembershipResult).GetMemberships() {
+		if m.GetIsMember() {
+			n++
+		}
+	}
+	return n
+}
+
+func TestAddRemoveCuckoo(t *testing.T) {
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	// Threshold and filter stages
+	for _, size := range []int64{100, -1} {
+		sketch := createCuckooSketch(t, 10000, size)
+		if _, err := sketch.Add(users(0, 50)); err != nil {
+			t.Fatal("expected no errors, got", err)
+		}
+		removed, err := sketch.Remove([][]byte{[]byte("user-1"), []byte("user-1"), []byte("hulk")})
+		if err != nil {
+			t.Fatal("expected no errors, got", err)
+		}
+		if !removed[0] || removed[1] || removed[2] {
+			t.Errorf("expected only the first user-1 to be removed, got %v", removed)
+		}
+		if n := members(t, sketch, users(0, 50)); n != 49 {
+			t.Errorf("expected 49 members, got %d", n)
+		}
+
+		// Promote the threshold stage
+		if _, err := sketch.Add(users(50, 5000)); err != nil {
+			t.Fatal("expected no errors, got", err)
+		}
+		if _, err := sketch.Remove(users(0, 2500)); err != nil {
+			t.Fatal("expected no errors, got", err)
+		}
+		// Values that were false positives when added share the fingerprint
+		// of a removed value
+		if n := members(t, sketch, users(2500, 5000)); n < 2500*99/100 {
+			t.Errorf("expected few false negatives, got %d members out of 2500", n)
+		}
+		if n := members(t, sketch, users(0, 2500)); n > 2500*3/100 {
+			t.Errorf("expected few of the removed users to be members, got %d", n)
+		}
+		if sketch.count > 2500 || sketch.count < 2500*99/100 {
+			t.Errorf("expected ~2500 values held, got %d", sketch.count)
+		}
+	}
+}
+
+func TestCuckooFull(t *testing.T) {
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	sketch := createCuckooSketch(t, 100, -1)
+	var err error
+	for i := 0; i < 10 && err == nil; i++ {
+		_, err = sketch.Add(users(i*100, (i+1)*100))
+	}
+	if err == nil {
+		t.Error("expected an error once the filter is full")
+	}
+	// Removing a value makes room again
+	if _, err := sketch.Remove(users(0, 1)); err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+	if _, err := sketch.Add([][]byte{[]byte("hulk")}); err != nil {
+		t.Error("expected no errors, got", err)
+	}
+}
+
+func TestCuckooDeterministic(t *testing.T) {
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	// Replaying the same values rebuilds the same filter, kicks included up
+	// to the fingerprint that fills it
+	var data [][]byte
+	for i := 0; i < 2; i++ {
+		sketch := createCuckooSketch(t, 100, -1)
+		if _, err := sketch.Add(users(0, 1000)); err == nil {
+			t.Fatal("expected an error once the filter is full")
+		}
+		d, err := sketch.Marshal()
+		if err != nil {
+			t.Fatal("expected no errors, got", err)
+		}
+		data = append(data, d)
+	}
+	if !bytes.Equal(data[0], data[1]) {
+		t.Error("expected filters built from the same values to be equal")
+	}
+}
+
+func TestMarshalMergeCuckoo(t *testing.T) {
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	dest := createCuckooSketch(t, 10000, -1)
+	if _, err := dest.Add(users(0, 1000)); err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+	src := createCuckooSketch(t, 10000, -1)
+	if _, err := src.Add(users(500, 1500)); err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+	data, err := src.Marshal()
+	if err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+	loaded := createCuckooSketch(t, 10000, -1)
+	if err := loaded.Unmarshal(data); err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+	if err := dest.Merge(loaded); err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+	if n := members(t, dest, users(0, 1500)); n != 1500 {
+		t.Errorf("expected 1500 members, got %d", n)
+	}
+	// Values held by both are only held once
+	if dest.count > 1500 {
+		t.Errorf("expected at most 1500 values held, got %d", dest.count)
+	}
+}
+
+func TestDeletable(t *testing.T) {
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+	defer setNow(1000)()
+
+	info := datamodel.NewEmptyInfo()
+	info.Properties.MaxUniqueItems = utils.Int64p(1000)
+	info.Properties.Deletable = utils.Boolp(true)
+	info.Name = utils.Stringp("online")
+	typ := pb.SketchType_FREQ
+	info.Type = &typ
+	if _, err := CreateSketch(info); err == nil {
+		t.Error("expected an error creating a deletable FREQ sketch")
+	}
+
+	sketch := createWindowedSketch(t, pb.SketchType_MEMB, 60, 3)
+	if _, err := sketch.Remove(users(0, 1)); err == nil {
+		t.Error("expected an error removing from a bloom filter")
+	}
+
+	info = datamodel.NewEmptyInfo()
+	info.Properties.MaxUniqueItems = utils.Int64p(1000)
+	info.Properties.Deletable = utils.Boolp(true)
+	info.Properties.BucketDuration = utils.Int64p(60)
+	info.Properties.BucketCount = utils.Int64p(3)
+	info.Name = utils.Stringp("online")
+	typ = pb.SketchType_MEMB
+	info.Type = &typ
+	sketch, err := CreateSketch(info)
+	if err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+	for _, ts := range []int64{880, 940} {
+		if _, err := sketch.AddAt(users(0, 2), ts); err != nil {
+			t.Fatal("expected no errors, got", err)
+		}
+	}
+	// Values are removed from all the buckets
+	removed, err := sketch.Remove(users(1, 3))
+	if err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+	if !removed[0] || removed[1] {
+		t.Errorf("expected [true false], got %v", removed)
+	}
+	if n := members(t, sketch, users(0, 2)); n != 1 {
+		t.Errorf("expected 1 member, got %d", n)
+	}
+}
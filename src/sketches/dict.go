@@ -1,9 +1,12 @@
 package sketches
 
 import (
+	"bytes"
 	"datamodel"
 	pb "datamodel/protobuf"
+	"encoding/binary"
 	"fmt"
+	"io"
 	"utils"
 )
 
@@ -17,7 +20,7 @@ type Dict struct {
 // NewDict ...
 func NewDict(info *datamodel.Info) *Dict {
 	sketch := make(map[string]uint)
-	size := info.GetProperties().GetMaxUniqueItems() / 10
+	size := thresholdSize(info)
 	d := Dict{info, sketch, uint(size)}
 	return &d
 }
@@ -35,6 +38,46 @@ func (d *Dict) Add(values [][]byte) (bool, error) {
 	return true, nil
 }
 
+// AddCounts adds each value count times
+func (d *Dict) AddCounts(counts map[string]uint) (bool, error) {
+	for v, count := range counts {
+		d.impl[v] += count
+	}
+	return true, nil
+}
+
+// countValues returns the number of times each value occurs in values
+func countValues(values [][]byte) map[string]uint {
+	counts := make(map[string]uint)
+	for _, v := range values {
+		counts[string(v)]++
+	}
+	return counts
+}
+
+// Merge adds the counts of other to the dict
+func (d *Dict) Merge(other *Dict) {
+	for k, v := range other.impl {
+		d.impl[k] += v
+	}
+}
+
+// State ...
+func (d *Dict) State(state *pb.SketchState) {
+	state.Exact = utils.Boolp(true)
+	// Rough size of the keys and their map entries
+	memory := int64(0)
+	for k := range d.impl {
+		memory += int64(len(k)) + 48
+	}
+	state.Memory = utils.Int64p(memory)
+	if datamodel.GetTypeString(d.GetType()) == datamodel.CML {
+		state.FillRate = utils.Float32p(fillRate(d.Info, float64(state.GetValuesAdded())))
+	} else {
+		state.FillRate = utils.Float32p(fillRate(d.Info, float64(len(d.impl))))
+	}
+}
+
 // Keys ...
 func (d *Dict) Keys() [][]byte {
 	keys := make([][]byte, len(d.impl), len(d.impl))
@@ -64,7 +107,9 @@ func (d *Dict) getMemb(data interface{}) (interface{}, error) {
 	values := data.([][]byte)
 	tmpRes := make(map[string]*pb.Membership)
 	res := &pb.MembershipResult{
-		Memberships: make([]*pb.Membership, len(values), len(values)),
+		Memberships:       make([]*pb.Membership, len(values), len(values)),
+		FalsePositiveRate: utils.Float64p(0),
+		Exact:             utils.Boolp(true),
 	}
 	for i, v := range values {
 		if r, ok := tmpRes[string(v)]; ok {
@@ -85,6 +130,7 @@ func (d *Dict) getFreq(data interface{}) (interface{}, error) {
 	values := data.([][]byte)
 	res := &pb.FrequencyResult{
 		Frequencies: make([]*pb.Frequency, len(values), len(values)),
+		Exact:       utils.Boolp(true),
 	}
 	tmpRes := make(map[string]*pb.Frequency)
 	for i, v := range values {
@@ -92,9 +138,12 @@ func (d *Dict) getFreq(data interface{}) (interface{}, error) {
 			res.Frequencies[i] = r
 			continue
 		}
+		count := utils.Int64p(int64(d.impl[string(v)]))
 		res.Frequencies[i] = &pb.Frequency{
 			Value: utils.Stringp(string(v)),
-			Count: utils.Int64p(int64(d.impl[string(v)])),
+			Count: count,
+			Lower: count,
+			Upper: count,
 		}
 		tmpRes[string(v)] = res.Frequencies[i]
 	}
@@ -102,7 +151,58 @@ func (d *Dict) getFreq(data interface{}) (interface{}, error) {
 }
 
 func (d *Dict) getCard(data interface{}) (interface{}, error) {
-	return &pb.CardinalityResult{
-		Cardinality: utils.Int64p(int64(len(d.impl))),
-	}, nil
+	return cardinalityResult(float64(len(d.impl)), 0), nil
+}
+
+// Marshal serializes the counts of the dict
+func (d *Dict) Marshal() ([]byte, error) {
+	buf := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutUvarint(buf, uint64(len(d.impl)))
+	data := append([]byte{}, buf[:n]...)
+	for k, v := range d.impl {
+		n = binary.PutUvarint(buf, uint64(len(k)))
+		data = append(data, buf[:n]...)
+		data = append(data, k...)
+		n = binary.PutUvarint(buf, uint64(v))
+		data = append(data, buf[:n]...)
+	}
+	return data, nil
+}
+
+// Unmarshal restores counts serialized by Marshal
+func (d *Dict) Unmarshal(data []byte) error {
+	r := bytes.NewReader(data)
+	size, err := binary.ReadUvarint(r)
+	if err != nil {
+		return err
+	}
+	// Each entry takes at least two bytes
+	if size > uint64(r.Len()/2) {
+		return fmt.Errorf("Invalid dict size %d", size)
+	}
+	hint := size
+	if hint > uint64(d.size) {
+		hint = uint64(d.size)
+	}
+	impl := make(map[string]uint, hint)
+	for i := uint64(0); i < size; i++ {
+		l, err := binary.ReadUvarint(r)
+		if err != nil {
+			return err
+		}
+		if l > uint64(r.Len()) {
+			return fmt.Errorf("Invalid dict entry length %d", l)
+		}
+		key := make([]byte, l)
+		if _, err = io.ReadFull(r, key); err != nil {
+			return err
+		}
+		count, err := binary.ReadUvarint(r)
+		if err != nil {
+			return err
+		}
+		impl[string(key)] = uint(count)
+	}
+	d.impl = impl
+	return nil
 }
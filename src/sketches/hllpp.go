@@ -1,6 +1,9 @@
 package sketches
 
 import (
+	"fmt"
+	"math"
+
 	"github.com/retailnext/hllpp"
 
 	"datamodel"
@@ -8,48 +11,105 @@ import (
 	"utils"
 )
 
+// Range of the precision of HLL++ sketches, the error rate is the standard
+// error of the estimate: 1.04/sqrt(2^precision)
+const (
+	defaultHLLPPPrecision = 14
+	minHLLPPPrecision     = 4
+	maxHLLPPPrecision     = 16
+)
+
 // HLLPPSketch is the toplevel sketch to control the HLL implementation
 type HLLPPSketch struct {
 	*datamodel.Info
 	impl      *hllpp.HLLPP
 	threshold *Dict
+	precision uint8
 }
 
 // NewHLLPPSketch ...
 func NewHLLPPSketch(info *datamodel.Info) (*HLLPPSketch, error) {
-	threshold := NewDict(info)
-	d := HLLPPSketch{info, nil, threshold}
+	rate, err := errorRate(info, hllppErrorRate(defaultHLLPPPrecision),
+		hllppErrorRate(maxHLLPPPrecision), hllppErrorRate(minHLLPPPrecision))
+	if err != nil {
+		return nil, err
+	}
+	// Use the lowest precision with a standard error within the rate, leave
+	// room for the rounding of reported rates
+	precision := uint8(math.Ceil(math.Log2(math.Pow(1.04/rate, 2)) - 1e-6))
+	if precision < minHLLPPPrecision {
+		precision = minHLLPPPrecision
+	} else if precision > maxHLLPPPrecision {
+		precision = maxHLLPPPrecision
+	}
+	setErrorRate(info, hllppErrorRate(precision))
+	threshold, err := newThreshold(info)
+	if err != nil {
+		return nil, err
+	}
+	d := HLLPPSketch{info, nil, threshold, precision}
+	if threshold == nil {
+		if err := d.promote(); err != nil {
+			return nil, err
+		}
+	}
 	return &d, nil
 }
 
+// hllppErrorRate returns the standard error of HLL++ sketches with precision
+func hllppErrorRate(precision uint8) float64 {
+	return 1.04 / math.Sqrt(float64(uint64(1)<<precision))
+}
+
 // Add ...
 func (d *HLLPPSketch) Add(values [][]byte) (bool, error) {
-	success := true
-	dict := make(map[string]uint)
+	return d.AddCounts(countValues(values))
+}
+
+// AddCounts adds each value once, counts do not change the cardinality. It
+// returns whether the cardinality changed.
+func (d *HLLPPSketch) AddCounts(counts map[string]uint) (bool, error) {
 	if d.threshold != nil {
-		s, err := d.threshold.Add(values)
-		success = s
-		if err != nil {
-			return false, err
+		changed := false
+		for v := range counts {
+			changed = changed || d.threshold.impl[v] == 0
 		}
-		if !d.threshold.IsFull() {
-			return true, nil
+		if _, err := d.threshold.AddCounts(counts); err != nil {
+			return false, err
 		}
-		values = d.threshold.Keys()
-		d.threshold = nil
-		if d.impl == nil {
-			sketch := hllpp.New()
-			d.impl = sketch
+		if d.threshold.IsFull() {
+			if err := d.promote(); err != nil {
+				return false, err
+			}
 		}
+		return changed, nil
 	}
 
-	for _, v := range values {
-		dict[string(v)]++
-	}
-	for v := range dict {
+	before := d.impl.Count()
+	for v := range counts {
 		d.impl.Add([]byte(v))
 	}
-	return success, nil
+	return d.impl.Count() != before, nil
+}
+
+// promote builds the HLL++ sketch and moves the values of the threshold dict
+// to it
+func (d *HLLPPSketch) promote() error {
+	if d.impl == nil {
+		impl, err := hllpp.NewWithConfig(hllpp.Config{Precision: d.precision})
+		if err != nil {
+			return err
+		}
+		d.impl = impl
+	}
+	if d.threshold == nil {
+		return nil
+	}
+	for _, v := range d.threshold.Keys() {
+		d.impl.Add(v)
+	}
+	d.threshold = nil
+	return nil
 }
 
 // Get ...
@@ -57,7 +117,60 @@ func (d *HLLPPSketch) Get(interface{}) (interface{}, error) {
 	if d.threshold != nil {
 		return d.threshold.Get(nil)
 	}
-	return &pb.CardinalityResult{
-		Cardinality: utils.Int64p(int64(d.impl.Count())),
-	}, nil
+	return cardinalityResult(float64(d.impl.Count()), hllppErrorRate(d.precision)), nil
+}
+
+// Marshal ...
+func (d *HLLPPSketch) Marshal() ([]byte, error) {
+	if d.threshold != nil {
+		data, err := d.threshold.Marshal()
+		return marshalStage(thresholdStage, data), err
+	}
+	return marshalStage(sketchStage, d.impl.Marshal()), nil
+}
+
+// Unmarshal ...
+func (d *HLLPPSketch) Unmarshal(data []byte) error {
+	stage, data, err := unmarshalStage(data)
+	if err != nil {
+		return err
+	}
+	if stage == thresholdStage {
+		d.threshold = NewDict(d.Info)
+		return d.threshold.Unmarshal(data)
+	}
+	impl, err := hllpp.Unmarshal(data)
+	if err != nil {
+		return err
+	}
+	d.threshold = nil
+	d.impl = impl
+	return nil
+}
+
+// Merge adds the values of other to the sketch
+func (d *HLLPPSketch) Merge(other datamodel.Sketcher) error {
+	o, ok := other.(*HLLPPSketch)
+	if !ok {
+		return fmt.Errorf("Can not merge sketch of type %T into %s", other, d.GetType())
+	}
+	if o.threshold != nil {
+		_, err := d.Add(o.threshold.Keys())
+		return err
+	}
+	if err := d.promote(); err != nil {
+		return err
+	}
+	return d.impl.Merge(o.impl)
+}
+
+// State ...
+func (d *HLLPPSketch) State(state *pb.SketchState) {
+	if d.threshold != nil {
+		d.threshold.State(state)
+		return
+	}
+	state.Exact = utils.Boolp(false)
+	state.Memory = utils.Int64p(int64(len(d.impl.Marshal())))
+	state.FillRate = utils.Float32p(fillRate(d.Info, float64(d.impl.Count())))
 }
@@ -122,3 +122,24 @@ func BenchmarkHLLPP(b *testing.B) {
 		}
 	}
 }
+
+func TestAddHLLPPChanged(t *testing.T) {
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	for _, threshold := range []int64{0, -1} {
+		info := datamodel.NewEmptyInfo()
+		info.Properties.ThresholdSize = utils.Int64p(threshold)
+		info.Name = utils.Stringp("marvel")
+		sketch, err := NewHLLPPSketch(info)
+		if err != nil {
+			t.Fatal("expected no errors, got", err)
+		}
+		if changed, err := sketch.Add([][]byte{[]byte("hulk"), []byte("thor")}); err != nil || !changed {
+			t.Errorf("expected a change with threshold %d, got %v, %v", threshold, changed, err)
+		}
+		if changed, err := sketch.Add([][]byte{[]byte("thor")}); err != nil || changed {
+			t.Errorf("expected no change with threshold %d, got %v, %v", threshold, changed, err)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package sketches
+
+import "fmt"
+
+// Serialized sketches start with the version of the serialization format
+// followed by the stage the sketch was in. Version 2 added the number of values
+// added to CML sketches, version 3 the errors of the elements merged into RANK
+// sketches, version 4 the number of distinct values added to MEMB sketches,
+// older versions can still be read.
+const (
+	marshalVersion    = byte(4)
+	minMarshalVersion = byte(1)
+
+	thresholdStage = byte(0)
+	sketchStage    = byte(1)
+	windowStage    = byte(2)
+)
+
+func marshalStage(stage byte, data []byte) []byte {
+	return append([]byte{marshalVersion, stage}, data...)
+}
+
+func unmarshalStage(data []byte) (byte, []byte, error) {
+	_, stage, data, err := unmarshalVersion(data)
+	return stage, data, err
+}
+
+// unmarshalVersion returns the version data was serialized with along with
+// its stage and the data of the stage
+func unmarshalVersion(data []byte) (byte, byte, []byte, error) {
+	if len(data) < 2 {
+		return 0, 0, nil, fmt.Errorf("Can not unmarshal sketch from %d bytes", len(data))
+	}
+	version := data[0]
+	if version < minMarshalVersion || version > marshalVersion {
+		return 0, 0, nil, fmt.Errorf("Unsupported sketch serialization version %d", version)
+	}
+	stage := data[1]
+	if stage != thresholdStage && stage != sketchStage && stage != windowStage {
+		return 0, 0, nil, fmt.Errorf("Invalid sketch stage %d", stage)
+	}
+	return version, stage, data[2:], nil
+}
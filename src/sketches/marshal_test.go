@@ -0,0 +1,103 @@
+package sketches
+
+import (
+	"encoding/binary"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"datamodel"
+	pb "datamodel/protobuf"
+	"testutils"
+	"utils"
+)
+
+func testMarshalRoundTrip(t *testing.T, typ pb.SketchType, nValues int) {
+	info := datamodel.NewEmptyInfo()
+	info.Properties.MaxUniqueItems = utils.Int64p(1000)
+	info.Properties.Size = utils.Int64p(10)
+	info.Name = utils.Stringp("marvel")
+	info.Type = &typ
+	sketch, err := CreateSketch(info)
+	if err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+
+	values := [][]byte{}
+	for i := 0; i < nValues; i++ {
+		for j := 0; j <= i%3; j++ {
+			values = append(values, []byte(fmt.Sprintf("hero-%d", i)))
+		}
+	}
+	if _, err := sketch.Add(values); err != nil {
+		t.Error("expected no errors, got", err)
+	}
+
+	data, err := sketch.Marshal()
+	if err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+	loaded, err := LoadSketch(info.Copy(), data)
+	if err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+
+	query := append(values, []byte("not-a-hero"))
+	expected, err := sketch.Get(query)
+	if err != nil {
+		t.Error("expected no errors, got", err)
+	}
+	if res, err := loaded.Get(query); err != nil {
+		t.Error("expected no errors, got", err)
+	} else if !reflect.DeepEqual(res, expected) {
+		t.Errorf("expected %v for %s with %d values, got %v", expected, typ, nValues, res)
+	}
+
+	// The loaded sketch keeps working
+	if _, err := loaded.Add(values); err != nil {
+		t.Error("expected no errors, got", err)
+	}
+}
+
+func TestMarshalThresholdStage(t *testing.T) {
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	for _, typ := range datamodel.GetTypesPb() {
+		testMarshalRoundTrip(t, typ, 10)
+	}
+}
+
+func TestMarshalSketchStage(t *testing.T) {
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	for _, typ := range datamodel.GetTypesPb() {
+		testMarshalRoundTrip(t, typ, 500)
+	}
+}
+
+func TestUnmarshalInvalid(t *testing.T) {
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	info := datamodel.NewEmptyInfo()
+	info.Properties.MaxUniqueItems = utils.Int64p(1000)
+	info.Name = utils.Stringp("marvel")
+	typ := pb.SketchType_CARD
+	info.Type = &typ
+
+	for _, data := range [][]byte{nil, {marshalVersion}, {marshalVersion + 1, sketchStage}, {marshalVersion, 42}} {
+		if _, err := LoadSketch(info, data); err == nil {
+			t.Errorf("expected an error for %v, got %v", data, err)
+		}
+	}
+
+	// A dict claiming more entries than its data holds
+	size := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutUvarint(size, 1<<40)
+	data := append([]byte{marshalVersion, thresholdStage}, size[:n]...)
+	if _, err := LoadSketch(info, append(data, 1, 'a', 1)); err == nil {
+		t.Error("expected an error for an invalid dict size")
+	}
+}
@@ -0,0 +1,163 @@
+package sketches
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"datamodel"
+	pb "datamodel/protobuf"
+	"testutils"
+	"utils"
+)
+
+func createMergeSketch(t *testing.T, typ pb.SketchType, name string, maxUniqueItems int64) *SketchProxy {
+	info := datamodel.NewEmptyInfo()
+	info.Properties.MaxUniqueItems = utils.Int64p(maxUniqueItems)
+	info.Properties.Size = utils.Int64p(10)
+	info.Name = utils.Stringp(name)
+	info.Type = &typ
+	sketch, err := CreateSketch(info)
+	if err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+	return sketch
+}
+
+// heroes adds the values hero-from to hero-(to-1) to sketch, hero-i is added
+// i%3+1 times, and records their counts
+func heroes(t *testing.T, sketch *SketchProxy, from, to int, counts map[string]int64) {
+	values := [][]byte{}
+	for i := from; i < to; i++ {
+		v := fmt.Sprintf("hero-%d", i)
+		for j := 0; j <= i%3; j++ {
+			values = append(values, []byte(v))
+		}
+		counts[v] += int64(i%3 + 1)
+	}
+	if _, err := sketch.Add(values); err != nil {
+		t.Error("expected no errors, got", err)
+	}
+}
+
+func testMerge(t *testing.T, typ pb.SketchType, nDest, nSrc int) {
+	counts := make(map[string]int64)
+	dest := createMergeSketch(t, typ, "avengers", 1000)
+	heroes(t, dest, 0, nDest, counts)
+	src := createMergeSketch(t, typ, "x-men", 1000)
+	heroes(t, src, nDest/2, nDest/2+nSrc, counts)
+
+	if err := dest.Merge([]*SketchProxy{src}); err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+
+	values := [][]byte{}
+	for v := range counts {
+		values = append(values, []byte(v))
+	}
+	res, err := dest.Get(values)
+	if err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+	switch typ {
+	case pb.SketchType_CARD:
+		card := float64(res.(*pb.CardinalityResult).GetCardinality())
+		if math.Abs(card-float64(len(counts))) > 0.02*float64(len(counts)) {
+			t.Errorf("expected cardinality of %d values merged into %d ~= %d, got %v", nSrc, nDest, len(counts), card)
+		}
+	case pb.SketchType_MEMB:
+		for _, m := range res.(*pb.MembershipResult).GetMemberships() {
+			if !m.GetIsMember() {
+				t.Errorf("expected %s to be a member after merging %d values into %d", m.GetValue(), nSrc, nDest)
+			}
+		}
+	case pb.SketchType_FREQ:
+		// Counts are exact as long as both sketches are in the threshold stage
+		exact := nDest+nSrc < 100
+		for _, f := range res.(*pb.FrequencyResult).GetFrequencies() {
+			if expected := counts[f.GetValue()]; (exact && f.GetCount() != expected) || f.GetCount() == 0 {
+				t.Errorf("expected count of %s == %d after merging %d values into %d, got %d",
+					f.GetValue(), expected, nSrc, nDest, f.GetCount())
+			}
+		}
+	case pb.SketchType_RANK:
+		// Space-saving never underestimates the counts of the top elements
+		for _, r := range res.(*pb.RankingsResult).GetRankings() {
+			if expected := counts[r.GetValue()]; r.GetCount() < expected {
+				t.Errorf("expected count of %s >= %d after merging %d values into %d, got %d",
+					r.GetValue(), expected, nSrc, nDest, r.GetCount())
+			}
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	for _, typ := range datamodel.GetTypesPb() {
+		// Threshold and sketch stages on both sides
+		testMerge(t, typ, 10, 10)
+		testMerge(t, typ, 10, 500)
+		testMerge(t, typ, 500, 10)
+		testMerge(t, typ, 500, 500)
+	}
+}
+
+func TestMergeThresholdFull(t *testing.T) {
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	// Merging two dicts that together exceed the threshold promotes the sketch
+	counts := make(map[string]int64)
+	dest := createMergeSketch(t, pb.SketchType_FREQ, "avengers", 1000)
+	heroes(t, dest, 0, 60, counts)
+	src := createMergeSketch(t, pb.SketchType_FREQ, "x-men", 1000)
+	heroes(t, src, 60, 120, counts)
+
+	if err := dest.Merge([]*SketchProxy{src}); err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+	if dest.sketch.(*CMLSketch).threshold != nil {
+		t.Error("expected sketch to be promoted after merging")
+	}
+}
+
+func TestMergeInvalidType(t *testing.T) {
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	dest := createMergeSketch(t, pb.SketchType_CARD, "avengers", 1000)
+	src := createMergeSketch(t, pb.SketchType_MEMB, "x-men", 1000)
+	if err := dest.Merge([]*SketchProxy{src}); err == nil {
+		t.Error("expected an error merging a MEMB sketch into a CARD sketch")
+	}
+
+	// A failed merge leaves the sketch unchanged
+	counts := make(map[string]int64)
+	heroes(t, dest, 0, 10, counts)
+	card := createMergeSketch(t, pb.SketchType_CARD, "x-force", 1000)
+	heroes(t, card, 10, 20, counts)
+	if err := dest.Merge([]*SketchProxy{card, src}); err == nil {
+		t.Error("expected an error merging a MEMB sketch into a CARD sketch")
+	}
+	if res, err := dest.Get(nil); err != nil {
+		t.Error("expected no errors, got", err)
+	} else if v := res.(*pb.CardinalityResult).GetCardinality(); v != 10 {
+		t.Error("expected cardinality 10 after a failed merge, got", v)
+	}
+}
+
+func TestMergeBloomSizes(t *testing.T) {
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	counts := make(map[string]int64)
+	dest := createMergeSketch(t, pb.SketchType_MEMB, "avengers", 1000)
+	heroes(t, dest, 0, 500, counts)
+	src := createMergeSketch(t, pb.SketchType_MEMB, "x-men", 100000)
+	heroes(t, src, 0, 50000, counts)
+	if err := dest.Merge([]*SketchProxy{src}); err == nil {
+		t.Error("expected an error merging bloom filters of different sizes")
+	}
+}
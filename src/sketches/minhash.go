@@ -0,0 +1,237 @@
+package sketches
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+
+	farm "github.com/dgryski/go-farm"
+
+	"datamodel"
+	pb "datamodel/protobuf"
+	"utils"
+)
+
+// Range of the error rate of MinHash sketches, the standard error of the
+// similarities: 1/sqrt(k) where k is the number of hashes kept
+const (
+	defaultMinHashErrorRate = 1.0 / 32
+	minMinHashErrorRate     = 1.0 / 256
+	maxMinHashErrorRate     = 0.25
+)
+
+// SignatureQuery is the data SIMI sketches are queried with for their
+// Signature, they return their cardinality otherwise
+type SignatureQuery struct{}
+
+// Signature holds the K smallest hashes of the values added to a SIMI sketch,
+// or all of them if fewer values were added
+type Signature struct {
+	K      int
+	Hashes []uint64 // sorted
+}
+
+// exact returns whether the signature holds the hashes of all the values
+func (s *Signature) exact() bool {
+	return len(s.Hashes) < s.K
+}
+
+// uint64s sorts hashes in ascending order
+type uint64s []uint64
+
+func (h uint64s) Len() int           { return len(h) }
+func (h uint64s) Less(i, j int) bool { return h[i] < h[j] }
+func (h uint64s) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+// cardinality estimates the number of distinct values from the k smallest
+// hashes, they are spread evenly over the range of hashes
+func cardinality(hashes []uint64, k int) float64 {
+	if len(hashes) < k {
+		return float64(len(hashes))
+	}
+	return float64(k-1) / (float64(hashes[k-1]) / math.MaxUint64)
+}
+
+// MinHashSketch is a bottom-k MinHash sketch, it keeps the k smallest hashes
+// of the values added to compare sets of values
+type MinHashSketch struct {
+	*datamodel.Info
+	k      int
+	hashes []uint64 // sorted
+}
+
+// NewMinHashSketch ...
+func NewMinHashSketch(info *datamodel.Info) (*MinHashSketch, error) {
+	rate, err := errorRate(info, defaultMinHashErrorRate, minMinHashErrorRate, maxMinHashErrorRate)
+	if err != nil {
+		return nil, err
+	}
+	// Leave room for the rounding of reported rates
+	k := int(math.Ceil(1/(rate*rate) - 1e-6))
+	setErrorRate(info, 1/math.Sqrt(float64(k)))
+	return &MinHashSketch{Info: info, k: k}, nil
+}
+
+// insert keeps h if it is one of the k smallest hashes
+func (d *MinHashSketch) insert(h uint64) {
+	n := len(d.hashes)
+	if n == d.k && h >= d.hashes[n-1] {
+		return
+	}
+	i := sort.Search(n, func(i int) bool { return d.hashes[i] >= h })
+	if i < n && d.hashes[i] == h {
+		return
+	}
+	if n < d.k {
+		d.hashes = append(d.hashes, 0)
+	}
+	copy(d.hashes[i+1:], d.hashes[i:])
+	d.hashes[i] = h
+}
+
+// Add ...
+func (d *MinHashSketch) Add(values [][]byte) (bool, error) {
+	for _, v := range values {
+		d.insert(farm.Hash64(v))
+	}
+	return true, nil
+}
+
+// AddCounts adds each value once, counts do not change the similarities
+func (d *MinHashSketch) AddCounts(counts map[string]uint) (bool, error) {
+	for v := range counts {
+		d.insert(farm.Hash64([]byte(v)))
+	}
+	return true, nil
+}
+
+// Get returns the Signature of the sketch if data is a *SignatureQuery, and
+// the cardinality of the values added otherwise
+func (d *MinHashSketch) Get(data interface{}) (interface{}, error) {
+	if _, ok := data.(*SignatureQuery); ok {
+		hashes := make([]uint64, len(d.hashes))
+		copy(hashes, d.hashes)
+		return &Signature{K: d.k, Hashes: hashes}, nil
+	}
+	rate := 0.0
+	if len(d.hashes) == d.k {
+		rate = 1 / math.Sqrt(float64(d.k))
+	}
+	return cardinalityResult(cardinality(d.hashes, d.k), rate), nil
+}
+
+// Similarity estimates the Jaccard similarity of the sets of values the
+// signatures were taken from along with the size of their intersection and
+// union. The estimates are exact while all the signatures hold every hash.
+func Similarity(signatures []*Signature) (*pb.GetSimilarityReply, error) {
+	if len(signatures) < 2 {
+		return nil, fmt.Errorf("Can not compare %d sketches, expected at least 2", len(signatures))
+	}
+	k := math.MaxInt32
+	exact := true
+	for _, s := range signatures {
+		if s.K < k {
+			k = s.K
+		}
+		exact = exact && s.exact()
+	}
+
+	var union []uint64
+	for _, s := range signatures {
+		union = append(union, s.Hashes...)
+	}
+	sort.Sort(uint64s(union))
+	n := 0
+	for i, h := range union {
+		if i == 0 || h != union[n-1] {
+			union[n] = h
+			n++
+		}
+	}
+	union = union[:n]
+	if !exact && len(union) > k {
+		union = union[:k]
+	}
+
+	// A hash among the k smallest of the union that belongs to a set is also
+	// among the k smallest of that set, so the signatures tell whether it
+	// belongs to all of them
+	shared := 0
+	for _, h := range union {
+		all := true
+		for _, s := range signatures {
+			i := sort.Search(len(s.Hashes), func(i int) bool { return s.Hashes[i] >= h })
+			if i == len(s.Hashes) || s.Hashes[i] != h {
+				all = false
+				break
+			}
+		}
+		if all {
+			shared++
+		}
+	}
+
+	jaccard := 0.0
+	if len(union) > 0 {
+		jaccard = float64(shared) / float64(len(union))
+	}
+	size := float64(len(union))
+	if !exact {
+		size = cardinality(union, k)
+	}
+	return &pb.GetSimilarityReply{
+		Jaccard:      utils.Float64p(jaccard),
+		Intersection: utils.Int64p(int64(math.Floor(jaccard*size + 0.5))),
+		Union:        utils.Int64p(int64(math.Floor(size + 0.5))),
+	}, nil
+}
+
+// Marshal ...
+func (d *MinHashSketch) Marshal() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := binary.Write(buf, binary.LittleEndian, d.hashes); err != nil {
+		return nil, err
+	}
+	return marshalStage(sketchStage, buf.Bytes()), nil
+}
+
+// Unmarshal ...
+func (d *MinHashSketch) Unmarshal(data []byte) error {
+	stage, data, err := unmarshalStage(data)
+	if err != nil {
+		return err
+	}
+	if stage != sketchStage {
+		return fmt.Errorf("Invalid SIMI sketch stage %d", stage)
+	}
+	if len(data)%8 != 0 || len(data)/8 > d.k {
+		return fmt.Errorf("Can not unmarshal up to %d hashes from %d bytes", d.k, len(data))
+	}
+	hashes := make([]uint64, len(data)/8)
+	if err := binary.Read(bytes.NewReader(data), binary.LittleEndian, hashes); err != nil {
+		return err
+	}
+	d.hashes = hashes
+	return nil
+}
+
+// Merge keeps the k smallest hashes of both sketches
+func (d *MinHashSketch) Merge(other datamodel.Sketcher) error {
+	o, ok := other.(*MinHashSketch)
+	if !ok {
+		return fmt.Errorf("Can not merge sketch of type %T into %s", other, d.GetType())
+	}
+	for _, h := range o.hashes {
+		d.insert(h)
+	}
+	return nil
+}
+
+// State reports the sketch as exact until it holds k hashes
+func (d *MinHashSketch) State(state *pb.SketchState) {
+	state.Exact = utils.Boolp(len(d.hashes) < d.k)
+	state.Memory = utils.Int64p(int64(8 * cap(d.hashes)))
+	state.FillRate = utils.Float32p(float32(len(d.hashes)) / float32(d.k))
+}
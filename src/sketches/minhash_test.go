@@ -0,0 +1,152 @@
+package sketches
+
+import (
+	"math"
+	"testing"
+
+	"datamodel"
+	pb "datamodel/protobuf"
+	"testutils"
+	"utils"
+)
+
+func createMinHashSketch(t *testing.T, rate float32) *MinHashSketch {
+	info := datamodel.NewEmptyInfo()
+	info.Properties.ErrorRate = &rate
+	info.Name = utils.Stringp("audience")
+	typ := pb.SketchType_SIMI
+	info.Type = &typ
+	sketch, err := NewMinHashSketch(info)
+	if err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+	return sketch
+}
+
+func signature(t *testing.T, sketch *MinHashSketch) *Signature {
+	res, err := sketch.Get(&SignatureQuery{})
+	if err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+	return res.(*Signature)
+}
+
+func TestSimilarityExact(t *testing.T) {
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	a := createMinHashSketch(t, 0)
+	if a.k != 1024 || a.GetProperties().GetErrorRate() != defaultMinHashErrorRate {
+		t.Errorf("expected the default size 1024, got %d", a.k)
+	}
+	b := createMinHashSketch(t, 0)
+	if _, err := a.Add(users(0, 300)); err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+	if _, err := b.AddCounts(map[string]uint{"user-250": 3, "user-400": 1}); err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+	if _, err := b.Add(users(200, 500)); err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+
+	res, err := Similarity([]*Signature{signature(t, a), signature(t, b)})
+	if err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+	if res.GetIntersection() != 100 || res.GetUnion() != 500 || res.GetJaccard() != 0.2 {
+		t.Errorf("expected intersection 100, union 500 and jaccard 0.2, got %v", res)
+	}
+	if _, err := Similarity([]*Signature{signature(t, a)}); err == nil {
+		t.Error("expected an error comparing a single sketch")
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	a := createMinHashSketch(t, 0.02)
+	b := createMinHashSketch(t, 0.02)
+	c := createMinHashSketch(t, 0.02)
+	if _, err := a.Add(users(0, 60000)); err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+	if _, err := b.Add(users(20000, 80000)); err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+	if _, err := c.Add(users(30000, 100000)); err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+
+	// A, B: 40000 shared out of 80000, A, B, C: 30000 shared out of 100000
+	res, err := Similarity([]*Signature{signature(t, a), signature(t, b)})
+	if err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+	if math.Abs(res.GetJaccard()-0.5) > 3*0.02 {
+		t.Errorf("expected jaccard ~= 0.5, got %v", res.GetJaccard())
+	}
+	if math.Abs(float64(res.GetUnion())-80000) > 80000*3*0.02 {
+		t.Errorf("expected union ~= 80000, got %v", res.GetUnion())
+	}
+	res, err = Similarity([]*Signature{signature(t, a), signature(t, b), signature(t, c)})
+	if err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+	if math.Abs(res.GetJaccard()-0.3) > 3*0.02 {
+		t.Errorf("expected jaccard ~= 0.3, got %v", res.GetJaccard())
+	}
+	if math.Abs(float64(res.GetIntersection())-30000) > 30000*0.2 {
+		t.Errorf("expected intersection ~= 30000, got %v", res.GetIntersection())
+	}
+
+	card, err := a.Get(nil)
+	if err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+	if n := card.(*pb.CardinalityResult).GetCardinality(); math.Abs(float64(n)-60000) > 60000*3*0.02 {
+		t.Errorf("expected cardinality ~= 60000, got %d", n)
+	}
+}
+
+func TestMarshalMergeMinHash(t *testing.T) {
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	a := createMinHashSketch(t, 0.1)
+	if _, err := a.Add(users(0, 1000)); err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+	b := createMinHashSketch(t, 0.1)
+	if _, err := b.Add(users(1000, 2000)); err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+	all := createMinHashSketch(t, 0.1)
+	if _, err := all.Add(users(0, 2000)); err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+
+	data, err := b.Marshal()
+	if err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+	loaded := createMinHashSketch(t, 0.1)
+	if err := loaded.Unmarshal(data); err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+	if err := a.Merge(loaded); err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+	// The merged sketch holds the same hashes as one of the union
+	res, err := Similarity([]*Signature{signature(t, a), signature(t, all)})
+	if err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+	if res.GetJaccard() != 1 {
+		t.Errorf("expected jaccard 1, got %v", res.GetJaccard())
+	}
+	if err := loaded.Unmarshal(data[:len(data)-1]); err == nil {
+		t.Error("expected an error unmarshaling truncated data")
+	}
+}
@@ -0,0 +1,100 @@
+package sketches
+
+import (
+	"fmt"
+	"math"
+
+	"datamodel"
+	pb "datamodel/protobuf"
+	"utils"
+)
+
+// errorRate returns the error rate requested in the properties of info, or def
+// if none was requested. The rate must lie within min and max.
+func errorRate(info *datamodel.Info, def, min, max float64) (float64, error) {
+	rate := info.GetProperties().GetErrorRate()
+	if rate == 0 {
+		return def, nil
+	}
+	// Rates are reported as float32, compare them as such so that they can be
+	// passed back as is
+	if rate < float32(min) || rate > float32(max) {
+		return 0, fmt.Errorf("Invalid error rate %v for %s sketch, expected a value between %v and %v",
+			rate, datamodel.GetTypeString(info.GetType()), min, max)
+	}
+	return float64(rate), nil
+}
+
+// setErrorRate reports the error rate the sketch of info was actually built with
+func setErrorRate(info *datamodel.Info, rate float64) {
+	if info.Properties == nil {
+		info.Properties = &pb.SketchProperties{}
+	}
+	// Only write if needed, buckets of windowed sketches share the same info
+	if r := float32(rate); info.Properties.GetErrorRate() != r {
+		info.Properties.ErrorRate = &r
+	}
+}
+
+// Threshold sizes with a special meaning
+const (
+	defaultThresholdSize  = 0 // maxUniqueItems/10
+	disabledThresholdSize = -1
+)
+
+// thresholdSize returns the number of distinct values counted exactly before
+// building the sketch of info, 0 if values go straight to the sketch
+func thresholdSize(info *datamodel.Info) int64 {
+	switch size := info.GetProperties().GetThresholdSize(); size {
+	case defaultThresholdSize:
+		return info.GetProperties().GetMaxUniqueItems() / 10
+	case disabledThresholdSize:
+		return 0
+	default:
+		return size
+	}
+}
+
+// newThreshold returns the dict of the threshold stage of the sketch of info,
+// or nil if the threshold stage is disabled, and reports the size it uses
+func newThreshold(info *datamodel.Info) (*Dict, error) {
+	if size := info.GetProperties().GetThresholdSize(); size < disabledThresholdSize {
+		return nil, fmt.Errorf("Invalid threshold size %d, expected -1 to disable it or a positive size", size)
+	}
+	size := thresholdSize(info)
+	if size == 0 {
+		size = disabledThresholdSize
+	}
+	if info.Properties == nil {
+		info.Properties = &pb.SketchProperties{}
+	}
+	// Only write if needed, buckets of windowed sketches share the same info
+	if info.Properties.GetThresholdSize() != size {
+		info.Properties.ThresholdSize = &size
+	}
+	if size == disabledThresholdSize {
+		return nil, nil
+	}
+	return NewDict(info), nil
+}
+
+// fillRate returns n relative to the capacity of the sketch of info, or 0 if
+// it has no capacity
+func fillRate(info *datamodel.Info, n float64) float32 {
+	capacity := info.GetProperties().GetMaxUniqueItems()
+	if capacity <= 0 {
+		return 0
+	}
+	return float32(math.Min(n/float64(capacity), 1))
+}
+
+// cardinalityResult returns the cardinality n estimated with a standard error
+// of rate times n, n is exact if rate is 0
+func cardinalityResult(n, rate float64) *pb.CardinalityResult {
+	return &pb.CardinalityResult{
+		Cardinality: utils.Int64p(int64(math.Floor(n + 0.5))),
+		Lower:       utils.Int64p(int64(math.Floor(n * (1 - rate)))),
+		Upper:       utils.Int64p(int64(math.Ceil(n * (1 + rate)))),
+		Exact:       utils.Boolp(rate == 0),
+	}
+}
@@ -0,0 +1,78 @@
+package sketches
+
+import (
+	"testing"
+
+	"datamodel"
+	pb "datamodel/protobuf"
+	"testutils"
+	"utils"
+)
+
+func createWithErrorRate(typ pb.SketchType, rate float32) (*SketchProxy, error) {
+	info := datamodel.NewEmptyInfo()
+	info.Properties.MaxUniqueItems = utils.Int64p(1000)
+	info.Properties.Size = utils.Int64p(10)
+	info.Properties.ErrorRate = utils.Float32p(rate)
+	info.Name = utils.Stringp("marvel")
+	info.Type = &typ
+	return CreateSketch(info)
+}
+
+func TestErrorRate(t *testing.T) {
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	tests := []struct {
+		typ       pb.SketchType
+		requested float32
+		used      float32
+	}{
+		{pb.SketchType_FREQ, 0, defaultCMLErrorRate},
+		{pb.SketchType_FREQ, 0.001, 0.001},
+		{pb.SketchType_MEMB, 0, defaultBloomErrorRate},
+		{pb.SketchType_MEMB, 0.0001, 0.0001},
+		{pb.SketchType_CARD, 0, float32(hllppErrorRate(14))},
+		{pb.SketchType_CARD, 0.05, float32(hllppErrorRate(9))},
+		{pb.SketchType_CARD, 0.26, 0.26},
+		{pb.SketchType_RANK, 0, 0.05}, // 2*size counters
+		{pb.SketchType_RANK, 0.001, 0.001},
+	}
+	for _, test := range tests {
+		sketch, err := createWithErrorRate(test.typ, test.requested)
+		if err != nil {
+			t.Errorf("expected no errors for %s with rate %v, got %v", test.typ, test.requested, err)
+			continue
+		}
+		used := sketch.Properties.GetErrorRate()
+		if used != test.used {
+			t.Errorf("expected %s with rate %v to use %v, got %v", test.typ, test.requested, test.used, used)
+		}
+		// Passing the reported rate back builds the same sketch
+		if again, err := createWithErrorRate(test.typ, used); err != nil {
+			t.Errorf("expected no errors for %s with rate %v, got %v", test.typ, used, err)
+		} else if rate := again.Properties.GetErrorRate(); rate != used {
+			t.Errorf("expected %s with rate %v to use %v, got %v", test.typ, used, used, rate)
+		}
+	}
+
+	if sketch, _ := createWithErrorRate(pb.SketchType_CARD, 0.05); sketch.sketch.(*HLLPPSketch).precision != 9 {
+		t.Error("expected precision 9, got", sketch.sketch.(*HLLPPSketch).precision)
+	}
+}
+
+func TestInvalidErrorRate(t *testing.T) {
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	for _, typ := range datamodel.GetTypesPb() {
+		for _, rate := range []float32{-0.1, 0.9, 1, 2} {
+			if _, err := createWithErrorRate(typ, rate); err == nil {
+				t.Errorf("expected an error for %s with rate %v", typ, rate)
+			}
+		}
+	}
+	if _, err := createWithErrorRate(pb.SketchType_CARD, 0.001); err == nil {
+		t.Error("expected an error for a CARD sketch more precise than supported")
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"github.com/njpatel/loggo"
 
 	"datamodel"
+	pb "datamodel/protobuf"
+	"utils"
 )
 
 var logger = loggo.GetLogger("sketches")
@@ -15,53 +17,241 @@ var logger = loggo.GetLogger("sketches")
 type SketchProxy struct {
 	*datamodel.Info
 	sketch datamodel.Sketcher
-	lock   sync.RWMutex
+	// Reads need exclusive access as well since some sketches (e.g. HLL++)
+	// update their internal state when queried
+	lock        sync.Mutex
+	valuesAdded int64
 }
 
 // Add ...
 func (sp *SketchProxy) Add(values [][]byte) (bool, error) {
 	sp.lock.Lock()
 	defer sp.lock.Unlock()
+	sp.valuesAdded += int64(len(values))
 	return sp.sketch.Add(values)
 }
 
+// AddAt adds values at timestamp, only windowed sketches make use of it
+func (sp *SketchProxy) AddAt(values [][]byte, timestamp int64) (bool, error) {
+	sp.lock.Lock()
+	defer sp.lock.Unlock()
+	sp.valuesAdded += int64(len(values))
+	if w, ok := sp.sketch.(*WindowedSketch); ok {
+		return w.AddAt(values, timestamp)
+	}
+	return sp.sketch.Add(values)
+}
+
+// AddCountsAt adds each value count times at timestamp, only windowed sketches
+// make use of the timestamp
+func (sp *SketchProxy) AddCountsAt(counts map[string]uint, timestamp int64) (bool, error) {
+	sp.lock.Lock()
+	defer sp.lock.Unlock()
+	for _, count := range counts {
+		sp.valuesAdded += int64(count)
+	}
+	if w, ok := sp.sketch.(*WindowedSketch); ok {
+		return w.AddCountsAt(counts, timestamp)
+	}
+	return sp.sketch.AddCounts(counts)
+}
+
+// inserter is implemented by sketches telling whether added values were new
+type inserter interface {
+	Insert([][]byte) ([]bool, error)
+}
+
+// remover is implemented by sketches values can be removed from
+type remover interface {
+	Remove([][]byte) ([]bool, error)
+}
+
+// Remove removes values and returns whether each of them was held by the
+// sketch, only deletable MEMB sketches support it
+func (sp *SketchProxy) Remove(values [][]byte) ([]bool, error) {
+	sp.lock.Lock()
+	defer sp.lock.Unlock()
+	r, ok := sp.sketch.(remover)
+	if !ok || !sp.GetProperties().GetDeletable() {
+		return nil, fmt.Errorf("Can not remove values from %s sketch %s, only deletable MEMB sketches support it",
+			sp.GetType(), sp.GetName())
+	}
+	return r.Remove(values)
+}
+
+// InsertAt adds values at timestamp and returns whether each of them was new to
+// the sketch, only MEMB sketches support it
+func (sp *SketchProxy) InsertAt(values [][]byte, timestamp int64) ([]bool, error) {
+	sp.lock.Lock()
+	defer sp.lock.Unlock()
+	var inserted []bool
+	var err error
+	if w, ok := sp.sketch.(*WindowedSketch); ok {
+		inserted, err = w.InsertAt(values, timestamp)
+	} else if ins, ok := sp.sketch.(inserter); ok {
+		inserted, err = ins.Insert(values)
+	} else {
+		return nil, fmt.Errorf("Sketch %s can not report inserted values", sp.ID())
+	}
+	if err == nil {
+		sp.valuesAdded += int64(len(values))
+	}
+	return inserted, err
+}
+
 // Get ...
 func (sp *SketchProxy) Get(data interface{}) (interface{}, error) {
+	sp.lock.Lock()
+	defer sp.lock.Unlock()
+	data, err := sp.queryData(data)
+	if err != nil {
+		return nil, err
+	}
+	return sp.sketch.Get(data)
+}
+
+// GetWindow queries the buckets of a windowed sketch selected by window
+func (sp *SketchProxy) GetWindow(data interface{}, window *Window) (interface{}, error) {
+	sp.lock.Lock()
+	defer sp.lock.Unlock()
+	w, ok := sp.sketch.(*WindowedSketch)
+	if !ok {
+		return nil, fmt.Errorf("Sketch %s is not windowed", sp.ID())
+	}
+	data, err := sp.queryData(data)
+	if err != nil {
+		return nil, err
+	}
+	return w.GetWindow(data, window)
+}
+
+// queryData returns the part of data the sketch is queried with
+func (sp *SketchProxy) queryData(data interface{}) (interface{}, error) {
 	switch datamodel.GetTypeString(sp.GetType()) {
 	case datamodel.HLLPP:
-		return sp.sketch.Get(nil)
+		return nil, nil
 	case datamodel.CML:
-		return sp.sketch.Get(data)
+		return data, nil
 	case datamodel.TopK:
-		return sp.sketch.Get(nil)
+		return nil, nil
 	case datamodel.Bloom:
-		return sp.sketch.Get(data)
+		return data, nil
+	case datamodel.TDigest:
+		return data, nil
+	case datamodel.MinHash:
+		return data, nil
 	default:
 		return nil, fmt.Errorf("Invalid sketch type: %s", sp.GetType())
 	}
 }
 
+// State fills in the live state of the sketch
+func (sp *SketchProxy) State(state *pb.SketchState) {
+	sp.lock.Lock()
+	defer sp.lock.Unlock()
+	state.ValuesAdded = utils.Int64p(sp.valuesAdded)
+	sp.sketch.State(state)
+}
+
+// Marshal ...
+func (sp *SketchProxy) Marshal() ([]byte, error) {
+	sp.lock.Lock()
+	defer sp.lock.Unlock()
+	return sp.sketch.Marshal()
+}
+
+// Merge merges the sources into the sketch, all of them must be of the same
+// type as the sketch. The sketch is left unchanged unless all of them merge.
+func (sp *SketchProxy) Merge(sources []*SketchProxy) error {
+	// Merge copies of the sources so that only one sketch is locked at a time
+	copies := make([]datamodel.Sketcher, len(sources))
+	added := int64(0)
+	for i, src := range sources {
+		data, err := src.Marshal()
+		if err != nil {
+			return err
+		}
+		src.lock.Lock()
+		added += src.valuesAdded
+		src.lock.Unlock()
+		tmp, err := LoadSketch(src.Info, data)
+		if err != nil {
+			return err
+		}
+		copies[i] = tmp.sketch
+	}
+
+	sp.lock.Lock()
+	defer sp.lock.Unlock()
+	data, err := sp.sketch.Marshal()
+	if err != nil {
+		return err
+	}
+	dest, err := LoadSketch(sp.Info, data)
+	if err != nil {
+		return err
+	}
+	for _, src := range copies {
+		if err := dest.sketch.Merge(src); err != nil {
+			return err
+		}
+	}
+	sp.sketch = dest.sketch
+	sp.valuesAdded += added
+	return nil
+}
+
 // CreateSketch ...
 func CreateSketch(info *datamodel.Info) (*SketchProxy, error) {
 	var err error
 	var sketch datamodel.Sketcher
-	sp := &SketchProxy{info, sketch, sync.RWMutex{}}
+	sp := &SketchProxy{info, sketch, sync.Mutex{}, info.GetState().GetValuesAdded()}
+
+	if info.Properties.GetBucketDuration() != 0 || info.Properties.GetBucketCount() != 0 {
+		sp.sketch, err = NewWindowedSketch(info)
+	} else {
+		sp.sketch, err = newSketcher(info)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return sp, nil
+}
 
+// newSketcher creates a sketch of the type of info
+func newSketcher(info *datamodel.Info) (datamodel.Sketcher, error) {
+	if info.GetProperties().GetDeletable() {
+		if info.GetType() != pb.SketchType_MEMB {
+			return nil, fmt.Errorf("Can not create deletable %s sketch, only MEMB sketches can be", info.GetType())
+		}
+		return NewCuckooSketch(info)
+	}
 	switch datamodel.GetTypeString(info.GetType()) {
 	case datamodel.HLLPP:
-		sp.sketch, err = NewHLLPPSketch(info)
+		return NewHLLPPSketch(info)
 	case datamodel.CML:
-		sp.sketch, err = NewCMLSketch(info)
+		return NewCMLSketch(info)
 	case datamodel.TopK:
-		sp.sketch, err = NewTopKSketch(info)
+		return NewTopKSketch(info)
 	case datamodel.Bloom:
-		sp.sketch, err = NewBloomSketch(info)
+		return NewBloomSketch(info)
+	case datamodel.TDigest:
+		return NewTDigestSketch(info)
+	case datamodel.MinHash:
+		return NewMinHashSketch(info)
 	default:
-		return nil, fmt.Errorf("Invalid sketch type: %s", sp.GetType())
+		return nil, fmt.Errorf("Invalid sketch type: %s", info.GetType())
 	}
+}
 
+// LoadSketch creates a sketch and restores its state from data
+func LoadSketch(info *datamodel.Info, data []byte) (*SketchProxy, error) {
+	sp, err := CreateSketch(info)
 	if err != nil {
 		return nil, err
 	}
+	if err := sp.sketch.Unmarshal(data); err != nil {
+		return nil, err
+	}
 	return sp, nil
 }
@@ -0,0 +1,60 @@
+package sketches
+
+import (
+	"fmt"
+	"testing"
+
+	"datamodel"
+	pb "datamodel/protobuf"
+	"testutils"
+)
+
+func TestState(t *testing.T) {
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	for _, typ := range datamodel.GetTypesPb() {
+		sketch := createMergeSketch(t, typ, "marvel", 1000)
+		values := [][]byte{}
+		for i := 0; i < 50; i++ {
+			values = append(values, []byte(fmt.Sprintf("hero-%d", i)))
+		}
+		if _, err := sketch.Add(values); err != nil {
+			t.Error("expected no errors, got", err)
+		}
+
+		state := &pb.SketchState{}
+		sketch.State(state)
+		if state.GetValuesAdded() != 50 {
+			t.Errorf("expected 50 values added to %s, got %d", typ, state.GetValuesAdded())
+		}
+		if state.GetExact() != (typ != pb.SketchType_RANK) {
+			t.Errorf("expected %s to be exact == %t, got %t", typ, typ != pb.SketchType_RANK, state.GetExact())
+		}
+		if state.GetMemory() <= 0 {
+			t.Errorf("expected %s to report its memory, got %d", typ, state.GetMemory())
+		}
+
+		for i := 50; i < 800; i++ {
+			values = append(values, []byte(fmt.Sprintf("hero-%d", i)))
+		}
+		if _, err := sketch.Add(values[50:]); err != nil {
+			t.Error("expected no errors, got", err)
+		}
+		sketch.State(state)
+		if state.GetExact() {
+			t.Errorf("expected %s not to be exact after 800 values", typ)
+		}
+		if state.GetMemory() <= 0 {
+			t.Errorf("expected %s to report its memory, got %d", typ, state.GetMemory())
+		}
+		// 800 distinct values out of 1000
+		if typ != pb.SketchType_RANK {
+			if rate := state.GetFillRate(); rate < 0.7 || rate > 0.9 {
+				t.Errorf("expected %s fill rate ~= 0.8, got %v", typ, rate)
+			}
+		} else if rate := state.GetFillRate(); rate != 1 {
+			t.Errorf("expected %s fill rate == 1, got %v", typ, rate)
+		}
+	}
+}
@@ -0,0 +1,331 @@
+package sketches
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"datamodel"
+	pb "datamodel/protobuf"
+	"utils"
+)
+
+// Range of the compression of t-digest sketches, the number of centroids kept
+// grows with it and the error of the quantiles shrinks with it
+const (
+	defaultTDigestCompression = 100
+	minTDigestCompression     = 10
+	maxTDigestCompression     = 10000
+)
+
+// QuantilesQuery is the data QUAN sketches are queried with
+type QuantilesQuery struct {
+	Quantiles []float64 // in [0, 1]
+	CDF       []float64 // values whose CDF is returned
+}
+
+// centroid is the mean of weight values
+type centroid struct {
+	Mean   float64
+	Weight float64
+}
+
+// centroidsByMean sorts centroids by ascending mean
+type centroidsByMean []centroid
+
+func (c centroidsByMean) Len() int           { return len(c) }
+func (c centroidsByMean) Less(i, j int) bool { return c[i].Mean < c[j].Mean }
+func (c centroidsByMean) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
+
+// TDigestSketch estimates the quantiles of numeric values with a merging
+// t-digest: values are buffered and then merged into centroids that are kept
+// small near the extreme quantiles
+type TDigestSketch struct {
+	*datamodel.Info
+	compression float64
+	centroids   []centroid // sorted by mean
+	buffer      []centroid // not merged yet
+	count       float64
+	min         float64
+	max         float64
+}
+
+// NewTDigestSketch ...
+func NewTDigestSketch(info *datamodel.Info) (*TDigestSketch, error) {
+	compression := info.GetProperties().GetCompression()
+	if compression == 0 {
+		compression = defaultTDigestCompression
+	}
+	if compression < minTDigestCompression || compression > maxTDigestCompression {
+		return nil, fmt.Errorf("Invalid compression %d for QUAN sketch, expected a value between %d and %d",
+			compression, minTDigestCompression, maxTDigestCompression)
+	}
+	if info.Properties == nil {
+		info.Properties = &pb.SketchProperties{}
+	}
+	// Only write if needed, buckets of windowed sketches share the same info
+	if info.Properties.GetCompression() != compression {
+		info.Properties.Compression = &compression
+	}
+	d := &TDigestSketch{
+		Info:        info,
+		compression: float64(compression),
+		min:         math.Inf(1),
+		max:         math.Inf(-1),
+	}
+	return d, nil
+}
+
+// parseValue returns the number added to a QUAN sketch as value
+func parseValue(value string) (float64, error) {
+	x, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(x) || math.IsInf(x, 0) {
+		return 0, fmt.Errorf("Invalid value %q for QUAN sketch, expected a number", value)
+	}
+	return x, nil
+}
+
+// Add ...
+func (d *TDigestSketch) Add(values [][]byte) (bool, error) {
+	return d.AddCounts(countValues(values))
+}
+
+// AddCounts adds each value count times, none are added if a value is not a
+// number
+func (d *TDigestSketch) AddCounts(counts map[string]uint) (bool, error) {
+	parsed := make(map[float64]uint, len(counts))
+	for v, count := range counts {
+		x, err := parseValue(v)
+		if err != nil {
+			return false, err
+		}
+		parsed[x] += count
+	}
+	for x, count := range parsed {
+		d.add(x, float64(count))
+	}
+	return true, nil
+}
+
+func (d *TDigestSketch) add(x, weight float64) {
+	d.buffer = append(d.buffer, centroid{x, weight})
+	d.count += weight
+	d.min = math.Min(d.min, x)
+	d.max = math.Max(d.max, x)
+	if len(d.buffer) >= int(5*d.compression) {
+		d.compress()
+	}
+}
+
+// k maps the quantile q to the t-digest scale, a centroid spans at most one
+// unit of it
+func (d *TDigestSketch) k(q float64) float64 {
+	return d.compression / (2 * math.Pi) * math.Asin(2*q-1)
+}
+
+// weightLimit returns the cumulative weight the centroid following before
+// values can grow to
+func (d *TDigestSketch) weightLimit(before float64) float64 {
+	k := d.k(before/d.count) + 1
+	if k >= d.compression/4 {
+		return d.count
+	}
+	return (math.Sin(k*2*math.Pi/d.compression) + 1) / 2 * d.count
+}
+
+// compress merges the buffered values into the centroids
+func (d *TDigestSketch) compress() {
+	if len(d.buffer) == 0 {
+		return
+	}
+	all := make([]centroid, 0, len(d.centroids)+len(d.buffer))
+	all = append(all, d.centroids...)
+	all = append(all, d.buffer...)
+	sort.Sort(centroidsByMean(all))
+
+	merged := make([]centroid, 0, len(d.centroids))
+	cur := all[0]
+	before := 0.0
+	limit := d.weightLimit(before)
+	for _, c := range all[1:] {
+		if before+cur.Weight+c.Weight <= limit {
+			cur.Weight += c.Weight
+			cur.Mean += (c.Mean - cur.Mean) * c.Weight / cur.Weight
+			continue
+		}
+		merged = append(merged, cur)
+		before += cur.Weight
+		limit = d.weightLimit(before)
+		cur = c
+	}
+	d.centroids = append(merged, cur)
+	d.buffer = d.buffer[:0]
+}
+
+// interpolate returns the y of x on the line from x0, y0 to x1, y1
+func interpolate(x0, y0, x1, y1, x float64) float64 {
+	if x1 == x0 {
+		return y1
+	}
+	return y0 + (y1-y0)*(x-x0)/(x1-x0)
+}
+
+// quantile interpolates between the centers of the centroids, the minimum and
+// maximum bound the first and last centroids
+func (d *TDigestSketch) quantile(q float64) float64 {
+	if d.count == 0 {
+		return math.NaN()
+	}
+	target := q * d.count
+	prevPos, prevMean := 0.0, d.min
+	pos := 0.0
+	for _, c := range d.centroids {
+		center := pos + c.Weight/2
+		if target < center {
+			return interpolate(prevPos, prevMean, center, c.Mean, target)
+		}
+		prevPos, prevMean = center, c.Mean
+		pos += c.Weight
+	}
+	return interpolate(prevPos, prevMean, d.count, d.max, target)
+}
+
+// cdf returns the fraction of the values less than or equal to x, it is the
+// inverse of quantile
+func (d *TDigestSketch) cdf(x float64) float64 {
+	switch {
+	case d.count == 0:
+		return math.NaN()
+	case x < d.min:
+		return 0
+	case x >= d.max:
+		return 1
+	}
+	prevPos, prevMean := 0.0, d.min
+	pos := 0.0
+	for _, c := range d.centroids {
+		center := pos + c.Weight/2
+		if x < c.Mean {
+			return interpolate(prevMean, prevPos, c.Mean, center, x) / d.count
+		}
+		prevPos, prevMean = center, c.Mean
+		pos += c.Weight
+	}
+	return interpolate(prevMean, prevPos, d.max, d.count, x) / d.count
+}
+
+// Get returns the count, min and max of the values, along with the quantiles
+// and CDF requested if data is a *QuantilesQuery
+func (d *TDigestSketch) Get(data interface{}) (interface{}, error) {
+	d.compress()
+	res := &pb.QuantilesResult{Count: utils.Int64p(int64(d.count))}
+	if d.count > 0 {
+		res.Min = utils.Float64p(d.min)
+		res.Max = utils.Float64p(d.max)
+	}
+	query, _ := data.(*QuantilesQuery)
+	if query == nil {
+		return res, nil
+	}
+	for _, q := range query.Quantiles {
+		if !(q >= 0 && q <= 1) {
+			return nil, fmt.Errorf("Invalid quantile %v, expected a value between 0 and 1", q)
+		}
+		res.Quantiles = append(res.Quantiles, &pb.Quantile{
+			Quantile: utils.Float64p(q),
+			Value:    utils.Float64p(d.quantile(q)),
+		})
+	}
+	for _, x := range query.CDF {
+		res.Cdf = append(res.Cdf, &pb.CDFPoint{
+			Value:    utils.Float64p(x),
+			Fraction: utils.Float64p(d.cdf(x)),
+		})
+	}
+	return res, nil
+}
+
+// tdigestHeader precedes the centroids of a serialized t-digest sketch
+type tdigestHeader struct {
+	Count     float64
+	Min       float64
+	Max       float64
+	Centroids uint32
+}
+
+// Marshal ...
+func (d *TDigestSketch) Marshal() ([]byte, error) {
+	d.compress()
+	buf := &bytes.Buffer{}
+	header := tdigestHeader{d.count, d.min, d.max, uint32(len(d.centroids))}
+	if err := binary.Write(buf, binary.LittleEndian, header); err != nil {
+		return nil, err
+	}
+	if err := binary.Write(buf, binary.LittleEndian, d.centroids); err != nil {
+		return nil, err
+	}
+	return marshalStage(sketchStage, buf.Bytes()), nil
+}
+
+// Unmarshal ...
+func (d *TDigestSketch) Unmarshal(data []byte) error {
+	stage, data, err := unmarshalStage(data)
+	if err != nil {
+		return err
+	}
+	if stage != sketchStage {
+		return fmt.Errorf("Invalid QUAN sketch stage %d", stage)
+	}
+	rdr := bytes.NewReader(data)
+	header := tdigestHeader{}
+	if err := binary.Read(rdr, binary.LittleEndian, &header); err != nil {
+		return err
+	}
+	if uint64(header.Centroids)*16 != uint64(rdr.Len()) {
+		return fmt.Errorf("Can not unmarshal %d centroids from %d bytes", header.Centroids, rdr.Len())
+	}
+	centroids := make([]centroid, header.Centroids)
+	if err := binary.Read(rdr, binary.LittleEndian, centroids); err != nil {
+		return err
+	}
+	d.count, d.min, d.max = header.Count, header.Min, header.Max
+	d.centroids = centroids
+	d.buffer = nil
+	return nil
+}
+
+// Merge adds the centroids of other to the sketch
+func (d *TDigestSketch) Merge(other datamodel.Sketcher) error {
+	o, ok := other.(*TDigestSketch)
+	if !ok {
+		return fmt.Errorf("Can not merge sketch of type %T into %s", other, d.GetType())
+	}
+	if o.count == 0 {
+		return nil
+	}
+	o.compress()
+	d.buffer = append(d.buffer, o.centroids...)
+	d.count += o.count
+	d.min = math.Min(d.min, o.min)
+	d.max = math.Max(d.max, o.max)
+	d.compress()
+	return nil
+}
+
+// State reports the sketch as exact while each centroid holds a single value
+func (d *TDigestSketch) State(state *pb.SketchState) {
+	d.compress()
+	exact := true
+	for _, c := range d.centroids {
+		if c.Weight != 1 {
+			exact = false
+			break
+		}
+	}
+	state.Exact = utils.Boolp(exact)
+	state.Memory = utils.Int64p(int64(16 * (cap(d.centroids) + cap(d.buffer))))
+	state.FillRate = utils.Float32p(0)
+}
@@ -0,0 +1,163 @@
+package sketches
+
+import (
+	"math"
+	"math/rand"
+	"strconv"
+	"testing"
+
+	"datamodel"
+	pb "datamodel/protobuf"
+	"testutils"
+	"utils"
+)
+
+func createTDigestSketch(t *testing.T, compression int64) *TDigestSketch {
+	info := datamodel.NewEmptyInfo()
+	info.Properties.Compression = utils.Int64p(compression)
+	info.Name = utils.Stringp("latencies")
+	typ := pb.SketchType_QUAN
+	info.Type = &typ
+	sketch, err := NewTDigestSketch(info)
+	if err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+	return sketch
+}
+
+// addRange adds the numbers from to to, excluded, in random order
+func addRange(t *testing.T, sketch *TDigestSketch, from, to int) {
+	values := make([][]byte, 0, to-from)
+	for _, i := range rand.Perm(to - from) {
+		values = append(values, []byte(strconv.Itoa(from+i)))
+	}
+	if _, err := sketch.Add(values); err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+}
+
+func getQuantiles(t *testing.T, sketch *TDigestSketch, query *QuantilesQuery) *pb.QuantilesResult {
+	res, err := sketch.Get(query)
+	if err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+	return res.(*pb.QuantilesResult)
+}
+
+func TestAddTDigest(t *testing.T) {
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	sketch := createTDigestSketch(t, 0)
+	if c := sketch.GetProperties().GetCompression(); c != defaultTDigestCompression {
+		t.Errorf("expected the default compression to be reported, got %d", c)
+	}
+	addRange(t, sketch, 1, 100001)
+
+	query := &QuantilesQuery{
+		Quantiles: []float64{0, 0.5, 0.95, 0.99, 1},
+		CDF:       []float64{-1, 25000, 99000, 200000},
+	}
+	res := getQuantiles(t, sketch, query)
+	if res.GetCount() != 100000 || res.GetMin() != 1 || res.GetMax() != 100000 {
+		t.Errorf("expected 100000 values from 1 to 100000, got %v", res)
+	}
+	for _, q := range res.GetQuantiles() {
+		expected := math.Max(1, q.GetQuantile()*100000)
+		// The error shrinks towards the extreme quantiles
+		if math.Abs(q.GetValue()-expected) > 100000*0.005 {
+			t.Errorf("expected quantile %v ~= %v, got %v", q.GetQuantile(), expected, q.GetValue())
+		}
+	}
+	for i, expected := range []float64{0, 0.25, 0.99, 1} {
+		if f := res.GetCdf()[i].GetFraction(); math.Abs(f-expected) > 0.005 {
+			t.Errorf("expected CDF(%v) ~= %v, got %v", res.GetCdf()[i].GetValue(), expected, f)
+		}
+	}
+	if n := len(sketch.centroids); n > 10*defaultTDigestCompression {
+		t.Errorf("expected the centroids to be bounded by the compression, got %d", n)
+	}
+}
+
+func TestAddTDigestInvalid(t *testing.T) {
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	sketch := createTDigestSketch(t, 100)
+	values := [][]byte{[]byte("12.5"), []byte("hulk")}
+	if _, err := sketch.Add(values); err == nil {
+		t.Error("expected an error adding hulk")
+	}
+	if res := getQuantiles(t, sketch, nil); res.GetCount() != 0 || res.Min != nil {
+		t.Errorf("expected no values to be added, got %v", res)
+	}
+	if res := getQuantiles(t, sketch, &QuantilesQuery{Quantiles: []float64{0.5}}); !math.IsNaN(res.GetQuantiles()[0].GetValue()) {
+		t.Errorf("expected NaN quantile without values, got %v", res)
+	}
+	if _, err := sketch.Get(&QuantilesQuery{Quantiles: []float64{1.5}}); err == nil {
+		t.Error("expected an error for quantile 1.5")
+	}
+
+	info := datamodel.NewEmptyInfo()
+	info.Properties.Compression = utils.Int64p(1)
+	typ := pb.SketchType_QUAN
+	info.Type = &typ
+	if _, err := NewTDigestSketch(info); err == nil {
+		t.Error("expected an error for compression 1")
+	}
+}
+
+func TestAddCountsTDigest(t *testing.T) {
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	sketch := createTDigestSketch(t, 100)
+	if _, err := sketch.AddCounts(map[string]uint{"10": 50, "1000": 50}); err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+	res := getQuantiles(t, sketch, &QuantilesQuery{Quantiles: []float64{0.25, 0.75}})
+	if res.GetCount() != 100 {
+		t.Error("expected count == 100, got", res.GetCount())
+	}
+	if q := res.GetQuantiles(); q[0].GetValue() != 10 || q[1].GetValue() != 1000 {
+		t.Errorf("expected p25 == 10 and p75 == 1000, got %v", q)
+	}
+
+	state := &pb.SketchState{}
+	sketch.State(state)
+	if state.GetExact() {
+		t.Error("expected weighted centroids not to be exact")
+	}
+}
+
+func TestMarshalMergeTDigest(t *testing.T) {
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	low := createTDigestSketch(t, 100)
+	addRange(t, low, 0, 5000)
+	high := createTDigestSketch(t, 100)
+	addRange(t, high, 5000, 10000)
+
+	data, err := high.Marshal()
+	if err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+	loaded := createTDigestSketch(t, 100)
+	if err := loaded.Unmarshal(data); err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+	if err := low.Merge(loaded); err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+	res := getQuantiles(t, low, &QuantilesQuery{Quantiles: []float64{0.5}})
+	if res.GetCount() != 10000 || res.GetMin() != 0 || res.GetMax() != 9999 {
+		t.Errorf("expected 10000 values from 0 to 9999, got %v", res)
+	}
+	if v := res.GetQuantiles()[0].GetValue(); math.Abs(v-5000) > 50 {
+		t.Errorf("expected median ~= 5000, got %v", v)
+	}
+	if err := loaded.Unmarshal(data[:len(data)-1]); err == nil {
+		t.Error("expected an error unmarshaling truncated data")
+	}
+}
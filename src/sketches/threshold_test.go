@@ -0,0 +1,149 @@
+package sketches
+
+import (
+	"fmt"
+	"testing"
+
+	"datamodel"
+	pb "datamodel/protobuf"
+	"testutils"
+	"utils"
+)
+
+func createWithThreshold(typ pb.SketchType, maxUniqueItems, size int64) (*SketchProxy, error) {
+	info := datamodel.NewEmptyInfo()
+	info.Properties.MaxUniqueItems = utils.Int64p(maxUniqueItems)
+	info.Properties.ThresholdSize = utils.Int64p(size)
+	info.Name = utils.Stringp("marvel")
+	info.Type = &typ
+	return CreateSketch(info)
+}
+
+func inThresholdStage(sketch *SketchProxy) bool {
+	switch s := sketch.sketch.(type) {
+	case *HLLPPSketch:
+		return s.threshold != nil
+	case *CMLSketch:
+		return s.threshold != nil
+	case *BloomSketch:
+		return s.threshold != nil
+	}
+	return false
+}
+
+func TestThresholdSize(t *testing.T) {
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	tests := []struct {
+		maxUniqueItems int64
+		requested      int64
+		used           int64
+	}{
+		{1000, 0, 100},
+		{5, 0, -1},
+		{1000, -1, -1},
+		{1000, 5, 5},
+		{0, 5, 5},
+	}
+	for _, typ := range []pb.SketchType{pb.SketchType_CARD, pb.SketchType_FREQ, pb.SketchType_MEMB} {
+		for _, test := range tests {
+			sketch, err := createWithThreshold(typ, test.maxUniqueItems, test.requested)
+			if typ != pb.SketchType_CARD && test.maxUniqueItems == 0 {
+				if err == nil {
+					t.Errorf("expected an error for %s without maxUniqueItems", typ)
+				}
+				continue
+			} else if err != nil {
+				t.Errorf("expected no errors for %s with threshold %d, got %v", typ, test.requested, err)
+				continue
+			}
+			if used := sketch.Properties.GetThresholdSize(); used != test.used {
+				t.Errorf("expected %s with threshold %d to use %d, got %d", typ, test.requested, test.used, used)
+			}
+			if inThresholdStage(sketch) != (test.used > 0) {
+				t.Errorf("expected %s with threshold %d to be in threshold stage == %t", typ, test.used, test.used > 0)
+			}
+
+			// The sketch is promoted once the threshold is reached
+			for i := int64(0); i < test.used; i++ {
+				if !inThresholdStage(sketch) {
+					t.Errorf("expected %s to be promoted after %d values, got %d", typ, test.used, i)
+				}
+				if _, err := sketch.Add([][]byte{[]byte(fmt.Sprintf("hero-%d", i))}); err != nil {
+					t.Error("expected no errors, got", err)
+				}
+			}
+			if inThresholdStage(sketch) {
+				t.Errorf("expected %s to be promoted after %d values", typ, test.used)
+			}
+		}
+	}
+
+	if _, err := createWithThreshold(pb.SketchType_CARD, 1000, -2); err == nil {
+		t.Error("expected an error for threshold -2")
+	}
+}
+
+// TestPromotionContinuity checks that the answers of sketches don't jump when
+// they switch from the threshold stage to the approximate sketch
+func TestPromotionContinuity(t *testing.T) {
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	for _, typ := range []pb.SketchType{pb.SketchType_CARD, pb.SketchType_FREQ, pb.SketchType_MEMB} {
+		sketch, err := createWithThreshold(typ, 10000, 20)
+		if err != nil {
+			t.Fatal("expected no errors, got", err)
+		}
+
+		counts := make(map[string]int64)
+		query := [][]byte{}
+		var before interface{}
+		for i := 0; i < 20; i++ {
+			v := fmt.Sprintf("hero-%d", i)
+			values := [][]byte{}
+			for j := 0; j < 5+i*3; j++ {
+				values = append(values, []byte(v))
+			}
+			counts[v] = int64(len(values))
+			query = append(query, []byte(v))
+			if i == 19 {
+				before, _ = sketch.Get(query)
+			}
+			if _, err := sketch.Add(values); err != nil {
+				t.Error("expected no errors, got", err)
+			}
+		}
+		if inThresholdStage(sketch) {
+			t.Fatalf("expected %s to be promoted", typ)
+		}
+		after, err := sketch.Get(query)
+		if err != nil {
+			t.Fatal("expected no errors, got", err)
+		}
+
+		switch typ {
+		case pb.SketchType_CARD:
+			if b, a := before.(*pb.CardinalityResult).GetCardinality(), after.(*pb.CardinalityResult).GetCardinality(); b != 19 || a != 20 {
+				t.Errorf("expected cardinality 19 then 20 across promotion, got %d then %d", b, a)
+			}
+		case pb.SketchType_FREQ:
+			for i, f := range after.(*pb.FrequencyResult).GetFrequencies() {
+				expected := counts[f.GetValue()]
+				if f.GetCount() < expected-expected/10-2 || f.GetCount() > expected+expected/10+2 {
+					t.Errorf("expected %s: %d after promotion, got %d", f.GetValue(), expected, f.GetCount())
+				}
+				if b := before.(*pb.FrequencyResult).GetFrequencies()[i]; i < 19 && b.GetCount() != expected {
+					t.Errorf("expected %s: %d before promotion, got %d", b.GetValue(), expected, b.GetCount())
+				}
+			}
+		case pb.SketchType_MEMB:
+			for _, m := range after.(*pb.MembershipResult).GetMemberships() {
+				if !m.GetIsMember() {
+					t.Errorf("expected %s to still be a member after promotion", m.GetValue())
+				}
+			}
+		}
+	}
+}
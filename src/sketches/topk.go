@@ -1,6 +1,12 @@
 package sketches
 
 import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+	"math"
+
 	"github.com/dgryski/go-topk"
 
 	"datamodel"
@@ -12,34 +18,58 @@ import (
 // TopKSketch is the toplevel sketch to control the HLL implementation
 type TopKSketch struct {
 	*datamodel.Info
-	impl *topk.Stream
+	impl     *topk.Stream
+	counters int
+	errors   map[string]int // errors of the merged elements, on top of their own
 }
 
 // ResultElement ...
 type ResultElement topk.Element
 
+// Range of the error rate of TopK sketches, the count of an element is
+// overestimated by at most the error rate times the number of values added
+const (
+	minTopKErrorRate = 0.00001
+	maxTopKErrorRate = 0.5
+)
+
 // NewTopKSketch ...
 func NewTopKSketch(info *datamodel.Info) (*TopKSketch, error) {
 	size := int(info.Properties.GetSize()) * 2 // For higher precision
-	d := TopKSketch{info, topk.New(size)}
+	rate, err := errorRate(info, 0, minTopKErrorRate, maxTopKErrorRate)
+	if err != nil {
+		return nil, err
+	}
+	// Space-saving needs 1/rate counters to bound the error, leave room for
+	// the rounding of reported rates
+	if rate > 0 {
+		if n := int(math.Ceil((1 - 1e-6) / rate)); n > size {
+			size = n
+		}
+	}
+	if size > 0 {
+		setErrorRate(info, 1/float64(size))
+	}
+	d := TopKSketch{info, topk.New(size), size, make(map[string]int)}
 	return &d, nil
 }
 
 // Add ...
 func (d *TopKSketch) Add(values [][]byte) (bool, error) {
-	dict := make(map[string]int)
-	for _, v := range values {
-		dict[string(v)]++
-	}
-	for v, count := range dict {
-		d.impl.Insert(v, count)
+	return d.AddCounts(countValues(values))
+}
+
+// AddCounts adds each value count times
+func (d *TopKSketch) AddCounts(counts map[string]uint) (bool, error) {
+	for v, count := range counts {
+		d.impl.Insert(v, int(count))
 	}
 	return true, nil
 }
 
 // Get ...
 func (d *TopKSketch) Get(interface{}) (interface{}, error) {
-	keys := d.impl.Keys()
+	keys := d.keys()
 	size := len(keys)
 	if size > int(d.Info.Properties.GetSize())/2 {
 		size = int(d.Info.Properties.GetSize()) / 2
@@ -52,7 +82,110 @@ func (d *TopKSketch) Get(interface{}) (interface{}, error) {
 		result.Rankings[i] = &pb.Rank{
 			Value: utils.Stringp(k.Key),
 			Count: utils.Int64p(int64(k.Count)),
+			Error: utils.Int64p(int64(k.Error)),
 		}
 	}
 	return result, nil
 }
+
+// Marshal ...
+func (d *TopKSketch) Marshal() ([]byte, error) {
+	d.pruneErrors()
+	buf := &bytes.Buffer{}
+	if err := gob.NewEncoder(buf).Encode(d.errors); err != nil {
+		return nil, err
+	}
+	size := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutUvarint(size, uint64(buf.Len()))
+	data, err := d.impl.GobEncode()
+	if err != nil {
+		return nil, err
+	}
+	data = append(append(size[:n], buf.Bytes()...), data...)
+	return marshalStage(sketchStage, data), nil
+}
+
+// Unmarshal ...
+func (d *TopKSketch) Unmarshal(data []byte) error {
+	version, stage, data, err := unmarshalVersion(data)
+	if err != nil {
+		return err
+	}
+	if stage != sketchStage {
+		return fmt.Errorf("Invalid stage %d for sketch of type %s", stage, d.GetType())
+	}
+	errors := make(map[string]int)
+	if version > 2 {
+		size, n := binary.Uvarint(data)
+		if n <= 0 || size > uint64(len(data)-n) {
+			return fmt.Errorf("Invalid RANK sketch data")
+		}
+		dec := gob.NewDecoder(bytes.NewReader(data[n : n+int(size)]))
+		if err := dec.Decode(&errors); err != nil {
+			return err
+		}
+		data = data[n+int(size):]
+	}
+	if err := d.impl.GobDecode(data); err != nil {
+		return err
+	}
+	d.errors = errors
+	return nil
+}
+
+// keys returns the tracked elements along with the errors they were merged
+// with
+func (d *TopKSketch) keys() []topk.Element {
+	keys := d.impl.Keys()
+	for i := range keys {
+		keys[i].Error += d.errors[keys[i].Key]
+	}
+	return keys
+}
+
+// pruneErrors drops the merged errors of elements that are not tracked anymore
+func (d *TopKSketch) pruneErrors() {
+	if len(d.errors) == 0 {
+		return
+	}
+	tracked := make(map[string]bool)
+	for _, e := range d.impl.Keys() {
+		tracked[e.Key] = true
+	}
+	for k := range d.errors {
+		if !tracked[k] {
+			delete(d.errors, k)
+		}
+	}
+}
+
+// Merge adds the counts of the top elements of other to the sketch, along
+// with their errors
+func (d *TopKSketch) Merge(other datamodel.Sketcher) error {
+	o, ok := other.(*TopKSketch)
+	if !ok {
+		return fmt.Errorf("Can not merge sketch of type %T into %s", other, d.GetType())
+	}
+	// go-topk inserts counts without an error, the errors of the merged
+	// elements are kept alongside the stream
+	for _, e := range o.keys() {
+		d.impl.Insert(e.Key, e.Count)
+		if e.Error > 0 {
+			d.errors[e.Key] += e.Error
+		}
+	}
+	d.pruneErrors()
+	return nil
+}
+
+// State ...
+func (d *TopKSketch) State(state *pb.SketchState) {
+	state.Exact = utils.Boolp(false)
+	if data, err := d.impl.GobEncode(); err == nil {
+		state.Memory = utils.Int64p(int64(len(data)))
+	}
+	// The share of counters tracking an element
+	if d.counters > 0 {
+		state.FillRate = utils.Float32p(float32(len(d.impl.Keys())) / float32(d.counters))
+	}
+}
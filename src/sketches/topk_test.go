@@ -105,3 +105,102 @@ func BenchmarkTopK(b *testing.B) {
 		}
 	}
 }
+
+func TestAddCountsTopK(t *testing.T) {
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	info := datamodel.NewEmptyInfo()
+	info.Properties.Size = utils.Int64p(4)
+	info.Name = utils.Stringp("marvel")
+	sketch, err := NewTopKSketch(info)
+	if err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+
+	counts := map[string]uint{"cyclops": 5000, "havoc": 20, "thunderbolt": 1000}
+	if _, err := sketch.AddCounts(counts); err != nil {
+		t.Error("expected no errors, got", err)
+	}
+	res, err := sketch.Get(nil)
+	if err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+	rankings := res.(*pb.RankingsResult).GetRankings()
+	if len(rankings) != 2 {
+		t.Fatal("expected 2 rankings, got", rankings)
+	}
+	if rankings[0].GetValue() != "cyclops" || rankings[0].GetCount() != 5000 {
+		t.Error("expected cyclops == 5000 first, got", rankings[0])
+	}
+	if rankings[1].GetValue() != "thunderbolt" || rankings[1].GetCount() != 1000 {
+		t.Error("expected thunderbolt == 1000 second, got", rankings[1])
+	}
+}
+
+func TestMergeTopKErrors(t *testing.T) {
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	newSketch := func(name string) *TopKSketch {
+		info := datamodel.NewEmptyInfo()
+		info.Properties.Size = utils.Int64p(4)
+		info.Name = utils.Stringp(name)
+		sketch, err := NewTopKSketch(info)
+		if err != nil {
+			t.Fatal("expected no errors, got", err)
+		}
+		return sketch
+	}
+	// More distinct values than counters leave errors on the tracked ones
+	src := newSketch("x-men")
+	counts := map[string]uint{}
+	for i := 0; i < 100; i++ {
+		counts["mutant-"+strconv.Itoa(i)] = uint(i%7 + 1)
+	}
+	if _, err := src.AddCounts(counts); err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+	dest := newSketch("avengers")
+	if err := dest.Merge(src); err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+
+	// Merged errors survive serialization
+	data, err := dest.Marshal()
+	if err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+	dest = newSketch("avengers")
+	if err := dest.Unmarshal(data); err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+
+	// Sketches serialized with version 2 did not keep merged errors
+	stream, err := src.impl.GobEncode()
+	if err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+	if err := newSketch("x-force").Unmarshal(append([]byte{2, sketchStage}, stream...)); err != nil {
+		t.Error("expected no errors loading version 2, got", err)
+	}
+
+	errors := make(map[string]int)
+	for _, e := range src.keys() {
+		errors[e.Key] = e.Error
+	}
+	merged := dest.keys()
+	if len(merged) != len(errors) {
+		t.Fatalf("expected %d elements after merging, got %d", len(errors), len(merged))
+	}
+	carried := false
+	for _, e := range merged {
+		if e.Error != errors[e.Key] {
+			t.Errorf("expected error of %s == %d after merging, got %d", e.Key, errors[e.Key], e.Error)
+		}
+		carried = carried || e.Error > 0
+	}
+	if !carried {
+		t.Error("expected some elements to carry an error")
+	}
+}
@@ -0,0 +1,360 @@
+package sketches
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+
+	"datamodel"
+	pb "datamodel/protobuf"
+	"utils"
+)
+
+// maxBuckets bounds the number of buckets of a windowed sketch
+const maxBuckets = 10000
+
+// now returns the current time in seconds since epoch, tests replace it
+var now = func() int64 {
+	return time.Now().Unix()
+}
+
+// Window selects the buckets of a windowed sketch to query, either the last
+// Buckets ones or those overlapping From to To in seconds since epoch. To
+// defaults to now.
+type Window struct {
+	Buckets int64
+	From    int64
+	To      int64
+}
+
+// WindowedSketch is a ring of sketches each holding the values added during
+// one bucket of time. Buckets expire as time goes by and are reused for new
+// values once they have fallen out of the window.
+type WindowedSketch struct {
+	*datamodel.Info
+	duration int64
+	buckets  []datamodel.Sketcher
+	starts   []int64 // start of the bucket held by each slot, 0 if empty
+}
+
+// NewWindowedSketch ...
+func NewWindowedSketch(info *datamodel.Info) (*WindowedSketch, error) {
+	duration := info.Properties.GetBucketDuration()
+	count := info.Properties.GetBucketCount()
+	if duration <= 0 || count <= 0 || count > maxBuckets {
+		return nil, fmt.Errorf("Invalid window of %d buckets of %d seconds", count, duration)
+	}
+	// Check the properties of the buckets up front
+	if _, err := newSketcher(info); err != nil {
+		return nil, err
+	}
+	d := &WindowedSketch{
+		Info:     info,
+		duration: duration,
+		buckets:  make([]datamodel.Sketcher, count),
+		starts:   make([]int64, count),
+	}
+	return d, nil
+}
+
+// start returns the start of the bucket holding timestamp
+func (d *WindowedSketch) start(timestamp int64) int64 {
+	return timestamp - timestamp%d.duration
+}
+
+func (d *WindowedSketch) slot(start int64) int {
+	return int((start / d.duration) % int64(len(d.buckets)))
+}
+
+// bucket returns the sketch of the bucket starting at start, a new one is
+// created in place of an expired bucket if needed. It returns nil if the
+// bucket has already expired.
+func (d *WindowedSketch) bucket(start int64) (datamodel.Sketcher, error) {
+	i := d.slot(start)
+	if d.starts[i] > start {
+		return nil, nil
+	}
+	if d.starts[i] < start || d.buckets[i] == nil {
+		sketch, err := newSketcher(d.Info)
+		if err != nil {
+			return nil, err
+		}
+		d.buckets[i] = sketch
+		d.starts[i] = start
+	}
+	return d.buckets[i], nil
+}
+
+// Add ...
+func (d *WindowedSketch) Add(values [][]byte) (bool, error) {
+	return d.AddAt(values, now())
+}
+
+// AddAt adds values to the bucket holding timestamp, values older than the
+// window are dropped
+func (d *WindowedSketch) AddAt(values [][]byte, timestamp int64) (bool, error) {
+	sketch, err := d.bucketAt(timestamp)
+	if err != nil || sketch == nil {
+		return false, err
+	}
+	return sketch.Add(values)
+}
+
+// bucketAt returns the sketch of the bucket holding timestamp, or nil if it
+// is older than the window
+func (d *WindowedSketch) bucketAt(timestamp int64) (datamodel.Sketcher, error) {
+	cur := d.start(now())
+	start := d.start(timestamp)
+	if start > cur {
+		return nil, fmt.Errorf("Can not add values in the future (timestamp %d)", timestamp)
+	}
+	if start <= cur-int64(len(d.buckets))*d.duration {
+		return nil, nil
+	}
+	return d.bucket(start)
+}
+
+// AddCounts ...
+func (d *WindowedSketch) AddCounts(counts map[string]uint) (bool, error) {
+	return d.AddCountsAt(counts, now())
+}
+
+// AddCountsAt adds each value count times to the bucket holding timestamp,
+// values older than the window are dropped
+func (d *WindowedSketch) AddCountsAt(counts map[string]uint, timestamp int64) (bool, error) {
+	sketch, err := d.bucketAt(timestamp)
+	if err != nil || sketch == nil {
+		return false, err
+	}
+	return sketch.AddCounts(counts)
+}
+
+// InsertAt adds values to the bucket holding timestamp and returns whether each
+// of them was new to the window, values older than the window are dropped
+func (d *WindowedSketch) InsertAt(values [][]byte, timestamp int64) ([]bool, error) {
+	sketch, err := d.bucketAt(timestamp)
+	if err != nil {
+		return nil, err
+	}
+	if sketch == nil {
+		return make([]bool, len(values)), nil
+	}
+	ins, ok := sketch.(inserter)
+	if !ok {
+		return nil, fmt.Errorf("Sketch %s can not report inserted values", d.ID())
+	}
+	// Values held by another bucket of the window are not new
+	seen := make([]bool, len(values))
+	first := d.start(now()) - int64(len(d.buckets)-1)*d.duration
+	for i, other := range d.buckets {
+		if other == nil || other == sketch || d.starts[i] < first {
+			continue
+		}
+		res, err := other.Get(values)
+		if err != nil {
+			return nil, err
+		}
+		for j, m := range res.(*pb.MembershipResult).GetMemberships() {
+			seen[j] = seen[j] || m.GetIsMember()
+		}
+	}
+	inserted, err := ins.Insert(values)
+	if err != nil {
+		return nil, err
+	}
+	for i := range inserted {
+		inserted[i] = inserted[i] && !seen[i]
+	}
+	return inserted, nil
+}
+
+// Remove removes values from all the buckets of the window and returns whether
+// each of them was held by any bucket
+func (d *WindowedSketch) Remove(values [][]byte) ([]bool, error) {
+	removed := make([]bool, len(values))
+	for _, sketch := range d.buckets {
+		if sketch == nil {
+			continue
+		}
+		r, ok := sketch.(remover)
+		if !ok {
+			return nil, fmt.Errorf("Can not remove values from sketch %s", d.ID())
+		}
+		res, err := r.Remove(values)
+		if err != nil {
+			return nil, err
+		}
+		for i := range removed {
+			removed[i] = removed[i] || res[i]
+		}
+	}
+	return removed, nil
+}
+
+// Get ...
+func (d *WindowedSketch) Get(data interface{}) (interface{}, error) {
+	return d.GetWindow(data, nil)
+}
+
+// GetWindow merges the buckets selected by window, or all buckets of the
+// window if it is nil, and queries the result
+func (d *WindowedSketch) GetWindow(data interface{}, window *Window) (interface{}, error) {
+	cur := d.start(now())
+	first := cur - int64(len(d.buckets)-1)*d.duration
+	last := cur
+	if window != nil {
+		switch {
+		case window.Buckets > 0 && (window.From != 0 || window.To != 0):
+			return nil, fmt.Errorf("Can not query both the last buckets and a range")
+		case window.Buckets > 0:
+			if n := cur - (window.Buckets-1)*d.duration; n > first {
+				first = n
+			}
+		case window.Buckets < 0:
+			return nil, fmt.Errorf("Invalid number of buckets %d", window.Buckets)
+		default:
+			to := window.To
+			if to == 0 {
+				to = now()
+			}
+			if window.From > to {
+				return nil, fmt.Errorf("Invalid range from %d to %d", window.From, to)
+			}
+			if n := d.start(window.From); n > first {
+				first = n
+			}
+			if n := d.start(to); n < last {
+				last = n
+			}
+		}
+	}
+
+	merged, err := newSketcher(d.Info)
+	if err != nil {
+		return nil, err
+	}
+	for i, start := range d.starts {
+		if d.buckets[i] == nil || start < first || start > last {
+			continue
+		}
+		if err := merged.Merge(d.buckets[i]); err != nil {
+			return nil, err
+		}
+	}
+	return merged.Get(data)
+}
+
+// Marshal ...
+func (d *WindowedSketch) Marshal() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	tmp := make([]byte, binary.MaxVarintLen64)
+	for i, sketch := range d.buckets {
+		if sketch == nil {
+			continue
+		}
+		data, err := sketch.Marshal()
+		if err != nil {
+			return nil, err
+		}
+		n := binary.PutVarint(tmp, d.starts[i])
+		buf.Write(tmp[:n])
+		n = binary.PutUvarint(tmp, uint64(len(data)))
+		buf.Write(tmp[:n])
+		buf.Write(data)
+	}
+	return marshalStage(windowStage, buf.Bytes()), nil
+}
+
+// Unmarshal ...
+func (d *WindowedSketch) Unmarshal(data []byte) error {
+	stage, data, err := unmarshalStage(data)
+	if err != nil {
+		return err
+	}
+	if stage != windowStage {
+		return fmt.Errorf("Invalid windowed sketch stage %d", stage)
+	}
+	buckets := make([]datamodel.Sketcher, len(d.buckets))
+	starts := make([]int64, len(d.starts))
+	rdr := bytes.NewReader(data)
+	for rdr.Len() > 0 {
+		start, err := binary.ReadVarint(rdr)
+		if err != nil {
+			return err
+		}
+		size, err := binary.ReadUvarint(rdr)
+		if err != nil {
+			return err
+		}
+		if size > uint64(rdr.Len()) {
+			return io.ErrUnexpectedEOF
+		}
+		raw := make([]byte, size)
+		_, _ = rdr.Read(raw)
+		sketch, err := newSketcher(d.Info)
+		if err != nil {
+			return err
+		}
+		if err := sketch.Unmarshal(raw); err != nil {
+			return err
+		}
+		i := d.slot(start)
+		buckets[i] = sketch
+		starts[i] = start
+	}
+	d.buckets = buckets
+	d.starts = starts
+	return nil
+}
+
+// Merge merges the buckets of other into the matching buckets of the sketch
+func (d *WindowedSketch) Merge(other datamodel.Sketcher) error {
+	o, ok := other.(*WindowedSketch)
+	if !ok {
+		return fmt.Errorf("Can not merge sketch of type %T into a windowed sketch", other)
+	}
+	if o.duration != d.duration || len(o.buckets) != len(d.buckets) {
+		return fmt.Errorf("Can not merge windowed sketches with different windows")
+	}
+	for i, sketch := range o.buckets {
+		if sketch == nil {
+			continue
+		}
+		dest, err := d.bucket(o.starts[i])
+		if err != nil {
+			return err
+		}
+		if dest == nil {
+			continue
+		}
+		if err := dest.Merge(sketch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// State reports the fill rate of the fullest bucket and the memory footprint of
+// all of them
+func (d *WindowedSketch) State(state *pb.SketchState) {
+	exact := true
+	fill := float32(0)
+	memory := int64(0)
+	for _, sketch := range d.buckets {
+		if sketch == nil {
+			continue
+		}
+		tmp := &pb.SketchState{ValuesAdded: state.ValuesAdded}
+		sketch.State(tmp)
+		exact = exact && tmp.GetExact()
+		if tmp.GetFillRate() > fill {
+			fill = tmp.GetFillRate()
+		}
+		memory += tmp.GetMemory()
+	}
+	state.Exact = utils.Boolp(exact)
+	state.FillRate = utils.Float32p(fill)
+	state.Memory = utils.Int64p(memory)
+}
@@ -0,0 +1,162 @@
+package sketches
+
+import (
+	"reflect"
+	"testing"
+
+	"datamodel"
+	pb "datamodel/protobuf"
+	"testutils"
+	"utils"
+)
+
+func createWindowedSketch(t *testing.T, typ pb.SketchType, duration, count int64) *SketchProxy {
+	info := datamodel.NewEmptyInfo()
+	info.Properties.MaxUniqueItems = utils.Int64p(1000)
+	info.Properties.Size = utils.Int64p(10)
+	info.Properties.BucketDuration = utils.Int64p(duration)
+	info.Properties.BucketCount = utils.Int64p(count)
+	info.Name = utils.Stringp("marvel")
+	info.Type = &typ
+	sketch, err := CreateSketch(info)
+	if err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+	return sketch
+}
+
+// setNow makes the windowed sketches see t as the current time
+func setNow(t int64) func() {
+	prev := now
+	now = func() int64 { return t }
+	return func() { now = prev }
+}
+
+func getCardinality(t *testing.T, sketch *SketchProxy, window *Window) int64 {
+	var res interface{}
+	var err error
+	if window == nil {
+		res, err = sketch.Get(nil)
+	} else {
+		res, err = sketch.GetWindow(nil, window)
+	}
+	if err != nil {
+		t.Fatal("expected no errors, got", err)
+	}
+	return res.(*pb.CardinalityResult).GetCardinality()
+}
+
+func TestWindowedRotation(t *testing.T) {
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+	defer setNow(1130)()
+
+	sketch := createWindowedSketch(t, pb.SketchType_CARD, 60, 3)
+	// One bucket per minute, starting at 960
+	for i, values := range [][]string{{"a", "b"}, {"c"}, {"d", "e", "f"}} {
+		byts := [][]byte{}
+		for _, v := range values {
+			byts = append(byts, []byte(v))
+		}
+		if _, err := sketch.AddAt(byts, 1000+int64(i)*60); err != nil {
+			t.Error("expected no errors, got", err)
+		}
+	}
+
+	if card := getCardinality(t, sketch, nil); card != 6 {
+		t.Error("expected cardinality 6 over the whole window, got", card)
+	}
+	if card := getCardinality(t, sketch, &Window{Buckets: 1}); card != 3 {
+		t.Error("expected cardinality 3 over the last bucket, got", card)
+	}
+	if card := getCardinality(t, sketch, &Window{From: 1000, To: 1060}); card != 3 {
+		t.Error("expected cardinality 3 over the first two buckets, got", card)
+	}
+
+	// The first bucket expires and its slot is reused
+	now = func() int64 { return 1150 }
+	if _, err := sketch.Add([][]byte{[]byte("g")}); err != nil {
+		t.Error("expected no errors, got", err)
+	}
+	if card := getCardinality(t, sketch, nil); card != 5 {
+		t.Error("expected cardinality 5 after rotation, got", card)
+	}
+
+	// Values older than the window are dropped, values in the future rejected
+	if ok, err := sketch.AddAt([][]byte{[]byte("h")}, 990); ok || err != nil {
+		t.Errorf("expected expired values to be dropped, got %v, %v", ok, err)
+	}
+	if _, err := sketch.AddAt([][]byte{[]byte("h")}, 1200); err == nil {
+		t.Error("expected an error adding values in the future")
+	}
+	if card := getCardinality(t, sketch, nil); card != 5 {
+		t.Error("expected cardinality 5, got", card)
+	}
+
+	// Nothing was added in the last 3 minutes
+	now = func() int64 { return 1400 }
+	if card := getCardinality(t, sketch, nil); card != 0 {
+		t.Error("expected cardinality 0 once all buckets expired, got", card)
+	}
+}
+
+func TestWindowedMarshal(t *testing.T) {
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+	defer setNow(1000)()
+
+	for _, typ := range datamodel.GetTypesPb() {
+		sketch := createWindowedSketch(t, typ, 60, 3)
+		for i := int64(0); i < 3; i++ {
+			values := [][]byte{[]byte("hulk"), []byte("hulk"), []byte("thor")}
+			if _, err := sketch.AddAt(values, 860+i*60); err != nil {
+				t.Error("expected no errors, got", err)
+			}
+		}
+		data, err := sketch.Marshal()
+		if err != nil {
+			t.Fatal("expected no errors, got", err)
+		}
+		loaded, err := LoadSketch(sketch.Info, data)
+		if err != nil {
+			t.Fatal("expected no errors, got", err)
+		}
+		query := [][]byte{[]byte("hulk"), []byte("loki")}
+		for _, window := range []*Window{nil, {Buckets: 1}} {
+			expected, _ := sketch.GetWindow(query, window)
+			if res, err := loaded.GetWindow(query, window); err != nil {
+				t.Error("expected no errors, got", err)
+			} else if !reflect.DeepEqual(res, expected) {
+				t.Errorf("expected %v for %s, got %v", expected, typ, res)
+			}
+		}
+	}
+}
+
+func TestWindowedInvalid(t *testing.T) {
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	info := datamodel.NewEmptyInfo()
+	info.Name = utils.Stringp("marvel")
+	typ := pb.SketchType_CARD
+	info.Type = &typ
+	for _, w := range [][2]int64{{60, 0}, {0, 3}, {-60, 3}, {60, maxBuckets + 1}} {
+		info.Properties.BucketDuration = utils.Int64p(w[0])
+		info.Properties.BucketCount = utils.Int64p(w[1])
+		if _, err := CreateSketch(info); err == nil {
+			t.Errorf("expected an error for %d buckets of %d seconds", w[1], w[0])
+		}
+	}
+
+	defer setNow(1000)()
+	sketch := createWindowedSketch(t, pb.SketchType_CARD, 60, 3)
+	for _, window := range []*Window{{Buckets: -1}, {Buckets: 1, From: 900}, {From: 1000, To: 900}} {
+		if _, err := sketch.GetWindow(nil, window); err == nil {
+			t.Errorf("expected an error for window %v", window)
+		}
+	}
+	if _, err := createMergeSketch(t, typ, "dc", 1000).GetWindow(nil, &Window{Buckets: 1}); err == nil {
+		t.Error("expected an error querying the buckets of a sketch that is not windowed")
+	}
+}
@@ -29,10 +29,10 @@ func createDomain(fields []string, in *pb.Domain) error {
 	}
 
 	types := []pb.SketchType{pb.SketchType_MEMB, pb.SketchType_FREQ, pb.SketchType_RANK, pb.SketchType_CARD}
-	for _, ty := range types {
+	for i := range types {
 		sketch := &pb.Sketch{}
 		sketch.Name = proto.String("")
-		sketch.Type = &ty
+		sketch.Type = &types[i]
 		sketch.Properties = &pb.SketchProperties{
 			Size:           proto.Int64(int64(size)),
 			MaxUniqueItems: proto.Int64(int64(capa)),
@@ -77,6 +77,8 @@ func sendDomainRequest(fields []string) error {
 		return deleteDomain(fields, in)
 	case "info":
 		return getDomainInfo(fields, in)
+	case "get":
+		return getFromDomain(fields, in)
 	default:
 		return fmt.Errorf("unkown operation: %s", fields[0])
 	}
@@ -99,15 +101,46 @@ func deleteDomain(fields []string, in *pb.Domain) error {
 }
 
 func getDomainInfo(fields []string, in *pb.Domain) error {
-	dom, err := client.GetDomain(context.Background(), in)
+	reply, err := client.QueryDomain(context.Background(), &pb.QueryDomainRequest{Domain: in})
 	if err != nil {
 		return err
 	}
+	dom := reply.GetDomain()
 	_, _ = fmt.Fprintln(w, fmt.Sprintf("Name: %s  Type: %s\t", dom.GetName(), ""))
 	_, _ = fmt.Fprintln(w, fmt.Sprintf("%d Sketches attached:", len(dom.GetSketches())))
 	for i, v := range dom.GetSketches() {
 		_, _ = fmt.Fprintln(w, fmt.Sprintf("  %d.  Name: %s  Type: %s\t", i+1, v.GetName(), v.GetType()))
 	}
+	if reply.Cardinality != nil {
+		_, _ = fmt.Fprintln(w, fmt.Sprintf("Cardinality: %d\t", reply.GetCardinality().GetCardinality()))
+	}
 	_ = w.Flush()
 	return err
 }
+
+func getFromDomain(fields []string, in *pb.Domain) error {
+	reply, err := client.QueryDomain(context.Background(), &pb.QueryDomainRequest{
+		Domain: in,
+		Values: fields[3:],
+	})
+	if err != nil {
+		return err
+	}
+	if reply.Cardinality != nil {
+		_, _ = fmt.Fprintln(w, fmt.Sprintf("Cardinality: %d\t", reply.GetCardinality().GetCardinality()))
+	}
+	for i, v := range reply.GetRankings().GetRankings() {
+		line := fmt.Sprintf("Rank: %d\t  Value: %s\t  Hits: %d", i+1, v.GetValue(), v.GetCount())
+		_, _ = fmt.Fprintln(w, line)
+	}
+	for _, v := range reply.GetFrequencies().GetFrequencies() {
+		line := fmt.Sprintf("Value: %s\t  Hits: %d", v.GetValue(), v.GetCount())
+		_, _ = fmt.Fprintln(w, line)
+	}
+	for _, v := range reply.GetMemberships().GetMemberships() {
+		line := fmt.Sprintf("Value: %s\t  Member: %t", v.GetValue(), v.GetIsMember())
+		_, _ = fmt.Fprintln(w, line)
+	}
+	_ = w.Flush()
+	return nil
+}
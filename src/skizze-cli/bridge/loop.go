@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/codegangsta/cli"
 
@@ -43,11 +44,19 @@ const helpString = `
   ADD RANK <name> <value1> [value2...]        Add values to a rankings Sketch
   ADD CARD <name> <value1> [value2...]        Add values to a cardinality Sketch
 
+  GET DOM  <name> [value1...]                 Get the cardinality, rankings, frequencies and memberships of a Domain
   GET FREQ <name> <value1> [value2...]        Get the frequencies of the values in a FREQ Sketch
   GET MEMB <name> <value1> [value2...]        Get the memberships of the values in  a MEMB Sketch
   GET RANK <name>                             Get the top ranking values in a RANK Sketch
   GET CARD <name>                             Get the cardinality of a CARD Sketch
 
+  MERGE CARD <name> <src1> [src2...]          Merge CARD Sketches into a CARD Sketch
+  MERGE MEMB <name> <src1> [src2...]          Merge MEMB Sketches into a MEMB Sketch
+  MERGE FREQ <name> <src1> [src2...]          Merge FREQ Sketches into a FREQ Sketch
+  MERGE RANK <name> <src1> [src2...]          Merge RANK Sketches into a RANK Sketch
+
+  SAVE                                        Create a snapshot of all Sketches and Domains
+
   QUIT                                        Exit skizze-cli
 
 SHORTCUTS:
@@ -70,7 +79,8 @@ var (
 		"list", "list dom",
 		"info", "info dom",
 		"add dom", "add freq", "add memb", "add rank", "add card",
-		"get freq", "get memb", "get rank", "get card",
+		"get dom", "get freq", "get memb", "get rank", "get card",
+		"merge freq", "merge memb", "merge rank", "merge card",
 		"help", "exit",
 	}
 	conn      *grpc.ClientConn
@@ -163,8 +173,23 @@ func evaluateQuery(query string) error {
 }
 
 func save() error {
-	_, err := client.CreateSnapshot(context.Background(), &pb.CreateSnapshotRequest{})
-	return err
+	if _, err := client.CreateSnapshot(context.Background(), &pb.CreateSnapshotRequest{}); err != nil {
+		return err
+	}
+	for {
+		reply, err := client.GetSnapshot(context.Background(), &pb.GetSnapshotRequest{})
+		if err != nil {
+			return err
+		}
+		switch reply.GetStatus() {
+		case pb.SnapshotStatus_SUCCESSFUL:
+			fmt.Printf("Snapshot saved at %s\n", time.Unix(reply.GetTimestamp(), 0))
+			return nil
+		case pb.SnapshotStatus_FAILED:
+			return fmt.Errorf("Snapshot failed: %s", reply.GetStatusMessage())
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
 }
 
 func printHelp() {
@@ -44,6 +44,26 @@ func addToSketch(fields []string, in *pb.Sketch) error {
 	return err
 }
 
+func mergeSketches(fields []string, in *pb.Sketch) error {
+	if len(fields) < 4 {
+		return fmt.Errorf("Expected at least 4 values, got %d", len(fields))
+	}
+	mergeRequest := &pb.MergeRequest{
+		Destination: in,
+	}
+	for _, name := range fields[3:] {
+		mergeRequest.Sources = append(mergeRequest.Sources, &pb.Sketch{
+			Name: proto.String(name),
+			Type: in.Type,
+		})
+	}
+	_, err := client.Merge(context.Background(), mergeRequest)
+	if err == nil {
+		fmt.Println("done")
+	}
+	return err
+}
+
 func sendSketchRequest(fields []string, typ pb.SketchType) error {
 	name := fields[2]
 	in := &pb.Sketch{
@@ -58,6 +78,8 @@ func sendSketchRequest(fields []string, typ pb.SketchType) error {
 		return addToSketch(fields, in)
 	case "get":
 		return getFromSketch(fields, in)
+	case "merge":
+		return mergeSketches(fields, in)
 	case "destroy":
 	case "info":
 		return getSketchInfo(in)
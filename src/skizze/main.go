@@ -2,6 +2,8 @@ package main
 
 import (
 	"os"
+	"os/signal"
+	"syscall"
 
 	_ "net/http/pprof"
 
@@ -63,9 +65,18 @@ func main() {
 		logger.Infof("Starting Skizze...")
 		logger.Infof("Listening on: %s:%d", host, port)
 		logger.Infof("Using data dir: %s", datadir)
+		if config.HTTPPort != 0 {
+			logger.Infof("Serving HTTP/JSON API on: %s:%d", config.HTTPHost, config.HTTPPort)
+		}
+		if config.RESPPort != 0 {
+			logger.Infof("Serving Redis protocol on: %s:%d", config.RESPHost, config.RESPPort)
+		}
 
 		mngr := manager.NewManager()
+		done := make(chan struct{})
+		go shutdownOnSignal(mngr, done)
 		server.Run(mngr, host, port, datadir)
+		<-done
 	}
 
 	if err := app.Run(os.Args); err != nil {
@@ -73,6 +84,18 @@ func main() {
 	}
 }
 
+func shutdownOnSignal(mngr *manager.Manager, done chan struct{}) {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	sig := <-sigs
+	logger.Infof("Received %s, shutting down...", sig)
+	if err := server.Shutdown(config.SnapshotOnShutdown); err != nil {
+		logger.Errorf("an error has occurred while shutting down: %s", err.Error())
+	}
+	mngr.Destroy()
+	close(done)
+}
+
 func setupDirectories(datadir string) string {
 	// FIXME: Allow specifying infodir
 	datadir, err := utils.FullPath(datadir)
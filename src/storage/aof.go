@@ -2,13 +2,15 @@ package storage
 
 import (
 	"bufio"
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"os"
-	"strconv"
-	"strings"
 	"sync"
 	"time"
 
+	"config"
 	"utils"
 
 	"github.com/golang/protobuf/proto"
@@ -17,41 +19,144 @@ import (
 
 var logger = loggo.GetLogger("storage")
 
+// ErrStopped is returned by operations on an AOF that isn't running
+var ErrStopped = errors.New("AOF stopped")
+
+// Fsync policies of the AOF
+const (
+	// FsyncAlways syncs entries to disk before Append returns
+	FsyncAlways = "always"
+	// FsyncEverySec syncs entries to disk every second
+	FsyncEverySec = "everysec"
+	// FsyncNo leaves syncing entries to disk to the OS
+	FsyncNo = "no"
+)
+
 // AOF ...
 type AOF struct {
-	file     *os.File
-	buffer   *bufio.ReadWriter
-	lock     sync.RWMutex
-	inChan   chan *Entry
-	tickChan <-chan time.Time
+	path        string
+	file        *os.File
+	offset      int64 // end of the last entry read
+	buffer      *bufio.ReadWriter
+	lock        sync.RWMutex
+	running     bool // set while the loop started by Run is writing entries
+	fsync       string
+	inChan      chan *pending
+	compactChan chan *compaction
+	flushChan   chan chan error
+	stopChan    chan chan error
+	stopped     chan struct{} // closed once the loop started by Run stops
+	tickChan    <-chan time.Time
+}
+
+// pending is an appended entry waiting to be written, done is set if Append
+// waits for the entry to be synced to disk
+type pending struct {
+	entry *Entry
+	done  chan error
+}
+
+type compaction struct {
+	entry *Entry
+	done  chan error
 }
 
 // NewAOF ...
 func NewAOF(path string) *AOF {
-	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_RDWR, 0600)
+	file, err := openAOF(path)
 	utils.PanicOnError(err)
 	rdr := bufio.NewReader(file)
+	_, err = rdr.Discard(len(aofHeader))
+	utils.PanicOnError(err)
 	wtr := bufio.NewWriter(file)
-	inChan := make(chan *Entry, 100)
+	fsync := config.Fsync
+	if fsync != FsyncAlways && fsync != FsyncEverySec && fsync != FsyncNo {
+		utils.PanicOnError(fmt.Errorf("Invalid fsync policy %q", fsync))
+	}
+	inChan := make(chan *pending, 100)
 	tickChan := time.NewTicker(time.Second).C
 	return &AOF{
-		file:     file,
-		buffer:   bufio.NewReadWriter(rdr, wtr),
-		lock:     sync.RWMutex{},
-		inChan:   inChan,
-		tickChan: tickChan,
+		path:        path,
+		file:        file,
+		offset:      int64(len(aofHeader)),
+		buffer:      bufio.NewReadWriter(rdr, wtr),
+		lock:        sync.RWMutex{},
+		fsync:       fsync,
+		inChan:      inChan,
+		compactChan: make(chan *compaction),
+		flushChan:   make(chan chan error),
+		stopChan:    make(chan chan error),
+		stopped:     make(chan struct{}),
+		tickChan:    tickChan,
+	}
+}
+
+// openAOF opens the AOF at path, writing the header of a new AOF or
+// migrating one in the legacy format
+func openAOF(path string) (*os.File, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+	header := make([]byte, len(aofHeader))
+	n, err := io.ReadFull(file, header)
+	switch {
+	case err == io.EOF:
+		// New AOF
+		if _, err = file.Write(aofHeader); err == nil {
+			err = file.Sync()
+		}
+		if err != nil {
+			_ = file.Close()
+			return nil, err
+		}
+	case bytes.Equal(header, aofHeader):
+	case n >= len(aofMagic) && bytes.Equal(header[:len(aofMagic)], aofMagic):
+		_ = file.Close()
+		return nil, fmt.Errorf("Unsupported AOF version %d", header[len(aofMagic)])
+	default:
+		_ = file.Close()
+		if err := migrate(path); err != nil {
+			return nil, err
+		}
+		return openAOF(path)
+	}
+	if _, err := file.Seek(0, 0); err != nil {
+		_ = file.Close()
+		return nil, err
 	}
+	return file, nil
 }
 
 // Run ...
 func (aof *AOF) Run() {
+	aof.lock.Lock()
+	aof.running = true
+	aof.lock.Unlock()
 	go func() {
 		for {
 			select {
-			case e := <-aof.inChan:
-				aof.write(e)
+			case p := <-aof.inChan:
+				aof.write(p)
+			case c := <-aof.compactChan:
+				c.done <- aof.compact(c.entry)
+			case done := <-aof.flushChan:
+				done <- aof.drain()
+			case done := <-aof.stopChan:
+				// Unblock the callers waiting to hand over an entry, then
+				// wait for those already past the running check
+				close(aof.stopped)
+				aof.lock.Lock()
+				aof.running = false
+				aof.lock.Unlock()
+				err := aof.drain()
+				if cerr := aof.file.Close(); err == nil {
+					err = cerr
+				}
+				done <- err
+				return
 			case <-aof.tickChan:
-				if err := aof.buffer.Flush(); err != nil {
+				if err := aof.flush(aof.fsync == FsyncEverySec); err != nil {
 					logger.Errorf("an error has occurred while flushing AOF: %s", err.Error())
 				}
 			}
@@ -59,37 +164,264 @@ func (aof *AOF) Run() {
 	}()
 }
 
-func (aof *AOF) write(e *Entry) {
-	line := fmt.Sprintf("%d|%s/", e.op, string(e.raw))
-	if _, err := aof.buffer.WriteString(line); err != nil {
-		logger.Errorf("an error has ocurred while writing AOF: %s", err.Error())
+// write writes p along with all other pending entries, if any of them is
+// waiting to be synced they are all synced to disk at once
+func (aof *AOF) write(p *pending) {
+	var err error
+	waiting := []chan error{}
+	for p != nil {
+		if _, werr := aof.buffer.Write(encode(p.entry)); werr != nil {
+			logger.Errorf("an error has ocurred while writing AOF: %s", werr.Error())
+			err = werr
+		}
+		if p.done != nil {
+			waiting = append(waiting, p.done)
+		}
+		select {
+		case p = <-aof.inChan:
+		default:
+			p = nil
+		}
+	}
+	if len(waiting) == 0 {
+		return
+	}
+	if ferr := aof.flush(true); err == nil {
+		err = ferr
+	}
+	for _, done := range waiting {
+		done <- err
+	}
+}
+
+// drain writes all pending entries and syncs them to disk
+func (aof *AOF) drain() error {
+	select {
+	case p := <-aof.inChan:
+		aof.write(p)
+	default:
+	}
+	return aof.flush(true)
+}
+
+func (aof *AOF) flush(sync bool) error {
+	if err := aof.buffer.Flush(); err != nil {
+		return err
+	}
+	if sync {
+		return aof.file.Sync()
 	}
+	return nil
 }
 
 // Append ...
 func (aof *AOF) Append(op uint8, msg proto.Message) error {
-	raw, err := proto.Marshal(msg)
-	if err != nil {
+	return aof.AppendAll(op, []proto.Message{msg})
+}
+
+// AppendAll appends an entry for each of msgs in order, they are synced to
+// disk at once if the fsync policy is always. It returns ErrStopped if the AOF
+// isn't running.
+func (aof *AOF) AppendAll(op uint8, msgs []proto.Message) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+	pendings := make([]*pending, len(msgs))
+	for i, msg := range msgs {
+		raw, err := proto.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		pendings[i] = &pending{entry: &Entry{op, msg, raw}}
+	}
+	// Syncing the last entry syncs the ones written before it
+	last := pendings[len(pendings)-1]
+	if aof.fsync == FsyncAlways {
+		last.done = make(chan error, 1)
+	}
+	if err := aof.send(pendings); err != nil {
 		return err
 	}
-	e := &Entry{op, msg, raw}
-	aof.inChan <- e
+	if last.done == nil {
+		return nil
+	}
+	return <-last.done
+}
+
+// send hands pendings over to the loop started by Run
+func (aof *AOF) send(pendings []*pending) error {
+	aof.lock.RLock()
+	defer aof.lock.RUnlock()
+	if !aof.running {
+		return ErrStopped
+	}
+	for _, p := range pendings {
+		select {
+		case aof.inChan <- p:
+		case <-aof.stopped:
+			return ErrStopped
+		}
+	}
 	return nil
 }
 
-// Read ...
+// Read returns the next entry of the AOF, or io.EOF once all entries were
+// read. An incomplete entry at the end of the AOF, as left by a crash while
+// writing it, is dropped.
 func (aof *AOF) Read() (*Entry, error) {
-	line, err := aof.buffer.ReadBytes('/')
-	if err != nil {
-		return nil, err
+	e, size, err := decode(aof.buffer.Reader)
+	if err == ErrCorrupted {
+		// Only the last entry can be partially written
+		if _, perr := aof.buffer.Peek(1); perr == io.EOF {
+			err = errTruncated
+		}
 	}
-	line = line[:len(line)-1]
-	rs := strings.Split(string(line), "|")
-	msg := rs[1]
-	op, err := strconv.Atoi(rs[0])
-	if err != nil {
+	if err == errTruncated {
+		logger.Warningf("Dropping incomplete entry at the end of the AOF (offset %d)", aof.offset)
+		if err := aof.file.Truncate(aof.offset); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	} else if err != nil {
 		return nil, err
 	}
-	e := &Entry{uint8(op), nil, []byte(msg)}
+	aof.offset += size
 	return e, nil
 }
+
+// Running returns whether Run has started writing appended entries
+func (aof *AOF) Running() bool {
+	aof.lock.RLock()
+	defer aof.lock.RUnlock()
+	return aof.running
+}
+
+// Flush writes all entries appended so far and syncs them to disk. It returns
+// immediately if the AOF isn't running, pending entries are written once Run
+// is called.
+func (aof *AOF) Flush() error {
+	if !aof.Running() {
+		return nil
+	}
+	done := make(chan error)
+	select {
+	case aof.flushChan <- done:
+	case <-aof.stopped:
+		return nil
+	}
+	return <-done
+}
+
+// Stop writes all entries appended so far, syncs them to disk and closes the
+// AOF. Entries can't be appended once it is stopped. Like Flush it returns
+// immediately if the AOF isn't running.
+func (aof *AOF) Stop() error {
+	if !aof.Running() {
+		return nil
+	}
+	done := make(chan error)
+	select {
+	case aof.stopChan <- done:
+	case <-aof.stopped:
+		return nil
+	}
+	return <-done
+}
+
+// Compact rewrites the AOF so that it starts at the last entry matching op and
+// msg, dropping all entries preceding it. The entry must have been appended
+// before calling Compact. It returns ErrStopped if the AOF isn't running.
+func (aof *AOF) Compact(op uint8, msg proto.Message) error {
+	raw, err := proto.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	c := &compaction{&Entry{op, msg, raw}, make(chan error)}
+	aof.lock.RLock()
+	if !aof.running {
+		aof.lock.RUnlock()
+		return ErrStopped
+	}
+	select {
+	case aof.compactChan <- c:
+	case <-aof.stopped:
+		err = ErrStopped
+	}
+	aof.lock.RUnlock()
+	if err != nil {
+		return err
+	}
+	return <-c.done
+}
+
+func (aof *AOF) compact(e *Entry) error {
+	// Write everything appended so far, including the entry to compact to
+	if err := aof.drain(); err != nil {
+		return err
+	}
+
+	file, err := os.Open(aof.path)
+	if err != nil {
+		return err
+	}
+	defer utils.CloseFile(file)
+
+	rdr := bufio.NewReader(file)
+	if _, err := rdr.Discard(len(aofHeader)); err != nil {
+		return err
+	}
+	offset, start := int64(len(aofHeader)), int64(-1)
+	for {
+		entry, size, err := decode(rdr)
+		if err == io.EOF || err == errTruncated {
+			break
+		} else if err != nil {
+			return err
+		}
+		if entry.op == e.op && bytes.Equal(entry.raw, e.raw) {
+			start = offset
+		}
+		offset += size
+	}
+	if start < 0 {
+		return fmt.Errorf("Could not find entry to compact AOF to")
+	}
+	if _, err := file.Seek(start, 0); err != nil {
+		return err
+	}
+
+	// Write the remaining entries to a new file and atomically swap it in
+	tmpPath := aof.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(aofHeader)
+	if err == nil {
+		_, err = io.Copy(tmp, file)
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, aof.path)
+	}
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	syncDir(aof.path)
+
+	newFile, err := os.OpenFile(aof.path, os.O_CREATE|os.O_APPEND|os.O_RDWR, 0600)
+	if err != nil {
+		return err
+	}
+	utils.CloseFile(aof.file)
+	aof.file = newFile
+	aof.buffer = bufio.NewReadWriter(bufio.NewReader(newFile), bufio.NewWriter(newFile))
+	logger.Infof("Compacted AOF from %d to %d bytes", offset, offset-start+int64(len(aofHeader)))
+	return nil
+}
@@ -1,12 +1,18 @@
 package storage
 
 import (
+	"bytes"
 	"config"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
 	pb "datamodel/protobuf"
 	"path/filepath"
 	"testing"
 
 	"github.com/gogo/protobuf/proto"
+	golangproto "github.com/golang/protobuf/proto"
 
 	"utils"
 	"testutils"
@@ -191,3 +197,382 @@ func TestCreateDeleteSketch(t *testing.T) {
 		}
 	}
 }
+
+func TestCompact(t *testing.T) {
+	config.Reset()
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	path := filepath.Join(config.DataDir, "skizze.aof")
+	aof := NewAOF(path)
+	aof.Run()
+
+	for _, id := range []string{"skz1", "skz2", "skz3"} {
+		if err := aof.Append(CreateSketch, createSketch(id, pb.SketchType_CARD)); err != nil {
+			t.Error("Expected no error, got", err)
+		}
+	}
+	marker := &pb.GetSnapshotReply{}
+	status := pb.SnapshotStatus_SUCCESSFUL
+	marker.Status = &status
+	marker.StatusMessage = utils.Stringp("1337")
+	if err := aof.Append(Snapshot, marker); err != nil {
+		t.Error("Expected no error, got", err)
+	}
+	if err := aof.Append(CreateSketch, createSketch("skz4", pb.SketchType_CARD)); err != nil {
+		t.Error("Expected no error, got", err)
+	}
+
+	if err := aof.Compact(Snapshot, marker); err != nil {
+		t.Fatal("Expected no error, got", err)
+	}
+	if err := aof.Append(CreateSketch, createSketch("skz5", pb.SketchType_CARD)); err != nil {
+		t.Error("Expected no error, got", err)
+	}
+	// Compacting to a missing entry must leave the AOF untouched
+	if err := aof.Compact(Snapshot, createSketch("skz1", pb.SketchType_CARD)); err == nil {
+		t.Error("Expected error, got", err)
+	}
+
+	aof = NewAOF(path)
+	ops := []uint8{}
+	for {
+		e, err := aof.Read()
+		if err != nil {
+			if err.Error() != "EOF" {
+				t.Error("Expected no error, got", err)
+			}
+			break
+		}
+		ops = append(ops, e.op)
+	}
+	if len(ops) != 3 || ops[0] != Snapshot || ops[1] != CreateSketch || ops[2] != CreateSketch {
+		t.Error("Expected ops [5 2 2], got", ops)
+	}
+}
+
+func readAll(t *testing.T, aof *AOF) []*Entry {
+	entries := []*Entry{}
+	for {
+		e, err := aof.Read()
+		if err != nil {
+			if err.Error() != "EOF" {
+				t.Error("Expected no error, got", err)
+			}
+			break
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+func TestSeparatorsInValues(t *testing.T) {
+	config.Reset()
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	path := filepath.Join(config.DataDir, "skizze.aof")
+	aof := NewAOF(path)
+	aof.Run()
+
+	addReq := &pb.AddRequest{
+		Sketch: createSketch("skz1", pb.SketchType_CARD),
+		Values: []string{"foo/bar", "a|b", "/|/", string([]byte{0, 1, 2})},
+	}
+	if err := aof.Append(Add, addReq); err != nil {
+		t.Error("Expected no error, got", err)
+	}
+	if err := aof.Append(Add, addReq); err != nil {
+		t.Error("Expected no error, got", err)
+	}
+	time.Sleep(1100 * time.Millisecond)
+
+	entries := readAll(t, NewAOF(path))
+	if len(entries) != 2 {
+		t.Fatal("Expected 2 entries, got", len(entries))
+	}
+	for _, e := range entries {
+		req := &pb.AddRequest{}
+		if err := proto.Unmarshal(e.raw, req); err != nil {
+			t.Error("Expected no error, got", err)
+		} else if !proto.Equal(req, addReq) {
+			t.Errorf("Expected %v, got %v", addReq, req)
+		}
+	}
+}
+
+func TestTruncatedTail(t *testing.T) {
+	config.Reset()
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	path := filepath.Join(config.DataDir, "skizze.aof")
+	aof := NewAOF(path)
+	aof.Run()
+	for _, id := range []string{"skz1", "skz2"} {
+		if err := aof.Append(CreateSketch, createSketch(id, pb.SketchType_CARD)); err != nil {
+			t.Error("Expected no error, got", err)
+		}
+	}
+	time.Sleep(1100 * time.Millisecond)
+
+	// Simulate a torn write of a third entry
+	e := &Entry{CreateSketch, nil, []byte("some payload")}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatal("Expected no error, got", err)
+	}
+	if _, err := file.Write(encode(e)[:8]); err != nil {
+		t.Error("Expected no error, got", err)
+	}
+	_ = file.Close()
+
+	aof = NewAOF(path)
+	if entries := readAll(t, aof); len(entries) != 2 {
+		t.Error("Expected 2 entries, got", len(entries))
+	}
+
+	// Entries appended after the truncated tail are readable
+	aof.Run()
+	if err := aof.Append(CreateSketch, createSketch("skz3", pb.SketchType_CARD)); err != nil {
+		t.Error("Expected no error, got", err)
+	}
+	time.Sleep(1100 * time.Millisecond)
+	if entries := readAll(t, NewAOF(path)); len(entries) != 3 {
+		t.Error("Expected 3 entries, got", len(entries))
+	}
+}
+
+func TestCorruptedEntry(t *testing.T) {
+	config.Reset()
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	path := filepath.Join(config.DataDir, "skizze.aof")
+	data := append([]byte{}, aofHeader...)
+	first := encode(&Entry{CreateSketch, nil, []byte("first")})
+	first[len(first)-1] ^= 0xff
+	data = append(data, first...)
+	data = append(data, encode(&Entry{CreateSketch, nil, []byte("second")})...)
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		t.Fatal("Expected no error, got", err)
+	}
+
+	if _, err := NewAOF(path).Read(); err != ErrCorrupted {
+		t.Error("Expected ErrCorrupted, got", err)
+	}
+}
+
+func TestMigrateLegacy(t *testing.T) {
+	config.Reset()
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	path := filepath.Join(config.DataDir, "skizze.aof")
+	sketches := []*pb.Sketch{
+		createSketch("skz1", pb.SketchType_CARD),
+		createSketch("skz2", pb.SketchType_FREQ),
+	}
+	legacy := ""
+	for _, sketch := range sketches {
+		raw, err := proto.Marshal(sketch)
+		if err != nil {
+			t.Fatal("Expected no error, got", err)
+		}
+		legacy += fmt.Sprintf("%d|%s/", CreateSketch, string(raw))
+	}
+	// Incomplete legacy entry
+	legacy += "2|foo"
+	if err := ioutil.WriteFile(path, []byte(legacy), 0600); err != nil {
+		t.Fatal("Expected no error, got", err)
+	}
+
+	entries := readAll(t, NewAOF(path))
+	if len(entries) != len(sketches) {
+		t.Fatalf("Expected %d entries, got %d", len(sketches), len(entries))
+	}
+	for i, e := range entries {
+		sketch := &pb.Sketch{}
+		if e.op != CreateSketch {
+			t.Errorf("Expected op %d, got %d", CreateSketch, e.op)
+		} else if err := proto.Unmarshal(e.raw, sketch); err != nil {
+			t.Error("Expected no error, got", err)
+		} else if sketch.GetName() != sketches[i].GetName() {
+			t.Errorf("Expected %s, got %s", sketches[i].GetName(), sketch.GetName())
+		}
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal("Expected no error, got", err)
+	} else if !bytes.HasPrefix(data, aofHeader) {
+		t.Error("Expected migrated AOF to start with the header")
+	}
+}
+
+func TestFsyncAlways(t *testing.T) {
+	config.Reset()
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	fsync := config.Fsync
+	config.Fsync = FsyncAlways
+	defer func() { config.Fsync = fsync }()
+
+	path := filepath.Join(config.DataDir, "skizze.aof")
+	aof := NewAOF(path)
+	aof.Run()
+
+	// Every appended entry is on disk once Append returns
+	for i, id := range []string{"skz1", "skz2", "skz3"} {
+		if err := aof.Append(CreateSketch, createSketch(id, pb.SketchType_CARD)); err != nil {
+			t.Error("Expected no error, got", err)
+		}
+		if entries := readAll(t, NewAOF(path)); len(entries) != i+1 {
+			t.Errorf("Expected %d entries, got %d", i+1, len(entries))
+		}
+	}
+
+	// And so are all the entries appended at once
+	msgs := []golangproto.Message{}
+	for _, id := range []string{"skz4", "skz5", "skz6"} {
+		msgs = append(msgs, createSketch(id, pb.SketchType_CARD))
+	}
+	if err := aof.AppendAll(CreateSketch, msgs); err != nil {
+		t.Error("Expected no error, got", err)
+	}
+	entries := readAll(t, NewAOF(path))
+	if len(entries) != 6 {
+		t.Fatalf("Expected 6 entries, got %d", len(entries))
+	}
+	for i, e := range entries[3:] {
+		sketch := &pb.Sketch{}
+		if err := proto.Unmarshal(e.RawMsg(), sketch); err != nil {
+			t.Error("Expected no error, got", err)
+		} else if !proto.Equal(sketch, msgs[i]) {
+			t.Errorf("Expected %v, got %v", msgs[i], sketch)
+		}
+	}
+}
+
+func TestInvalidFsync(t *testing.T) {
+	config.Reset()
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	fsync := config.Fsync
+	config.Fsync = "sometimes"
+	defer func() {
+		config.Fsync = fsync
+		if r := recover(); r == nil {
+			t.Error("Expected NewAOF to panic on an invalid fsync policy")
+		}
+	}()
+	NewAOF(filepath.Join(config.DataDir, "skizze.aof"))
+}
+
+func TestFlushStop(t *testing.T) {
+	config.Reset()
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	path := filepath.Join(config.DataDir, "skizze.aof")
+	aof := NewAOF(path)
+	aof.Run()
+
+	if err := aof.Append(CreateSketch, createSketch("skz1", pb.SketchType_CARD)); err != nil {
+		t.Error("Expected no error, got", err)
+	}
+	if err := aof.Flush(); err != nil {
+		t.Error("Expected no error, got", err)
+	}
+	if entries := readAll(t, NewAOF(path)); len(entries) != 1 {
+		t.Error("Expected 1 entry, got", len(entries))
+	}
+
+	for _, id := range []string{"skz2", "skz3"} {
+		if err := aof.Append(CreateSketch, createSketch(id, pb.SketchType_CARD)); err != nil {
+			t.Error("Expected no error, got", err)
+		}
+	}
+	if err := aof.Stop(); err != nil {
+		t.Error("Expected no error, got", err)
+	}
+	if entries := readAll(t, NewAOF(path)); len(entries) != 3 {
+		t.Error("Expected 3 entries, got", len(entries))
+	}
+}
+
+func TestFlushStopNotRunning(t *testing.T) {
+	config.Reset()
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	aof := NewAOF(filepath.Join(config.DataDir, "skizze.aof"))
+	done := make(chan error, 2)
+	go func() {
+		done <- aof.Flush()
+		done <- aof.Stop()
+	}()
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Error("Expected no error, got", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Expected Flush and Stop to return before Run")
+		}
+	}
+}
+
+func TestAppendStopped(t *testing.T) {
+	config.Reset()
+	testutils.SetupTests()
+	defer testutils.TearDownTests()
+
+	aof := NewAOF(filepath.Join(config.DataDir, "skizze.aof"))
+	sketch := createSketch("skz1", pb.SketchType_CARD)
+	if err := aof.Append(CreateSketch, sketch); err != ErrStopped {
+		t.Error("Expected ErrStopped before Run, got", err)
+	}
+	aof.Run()
+
+	// Appends racing Stop either make it to the AOF or fail, none blocks
+	done := make(chan error)
+	for i := 0; i < 10; i++ {
+		go func() {
+			var err error
+			for j := 0; j < 100 && err == nil; j++ {
+				err = aof.Append(CreateSketch, sketch)
+			}
+			done <- err
+		}()
+	}
+	if err := aof.Stop(); err != nil {
+		t.Error("Expected no error, got", err)
+	}
+	for i := 0; i < 10; i++ {
+		select {
+		case err := <-done:
+			if err != nil && err != ErrStopped {
+				t.Error("Expected ErrStopped, got", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Expected appends to return once the AOF is stopped")
+		}
+	}
+
+	if err := aof.Append(CreateSketch, sketch); err != ErrStopped {
+		t.Error("Expected ErrStopped, got", err)
+	}
+	if err := aof.AppendAll(CreateSketch, []golangproto.Message{sketch}); err != ErrStopped {
+		t.Error("Expected ErrStopped, got", err)
+	}
+	if err := aof.Compact(CreateSketch, sketch); err != ErrStopped {
+		t.Error("Expected ErrStopped, got", err)
+	}
+	if err := aof.Stop(); err != nil {
+		t.Error("Expected no error, got", err)
+	}
+}
@@ -4,11 +4,16 @@ import "github.com/golang/protobuf/proto"
 
 // CreateDom ...
 const (
-	CreateDom    = uint8(0)
-	DeleteDom    = uint8(1)
-	CreateSketch = uint8(2)
-	DeleteSketch = uint8(3)
-	Add          = uint8(4)
+	CreateDom           = uint8(0)
+	DeleteDom           = uint8(1)
+	CreateSketch        = uint8(2)
+	DeleteSketch        = uint8(3)
+	Add                 = uint8(4)
+	Snapshot            = uint8(5)
+	Merge               = uint8(6)
+	AddSketchToDom      = uint8(7)
+	RemoveSketchFromDom = uint8(8)
+	Remove              = uint8(9)
 )
 
 // Entry ...
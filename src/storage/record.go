@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// An AOF starts with a header made of a magic string and the format version,
+// followed by records made of the op (1 byte), the payload size (4 bytes), the
+// payload and a crc32 (4 bytes) of all the preceding fields.
+const (
+	aofVersion       = byte(1)
+	recordHeaderSize = 5
+	recordCRCSize    = 4
+)
+
+var (
+	aofMagic  = []byte("SKZAOF")
+	crcTable  = crc32.MakeTable(crc32.Castagnoli)
+	aofHeader = append(append([]byte{}, aofMagic...), aofVersion)
+)
+
+// ErrCorrupted is returned when reading a record that fails its checksum
+var ErrCorrupted = errors.New("AOF record is corrupted")
+
+// errTruncated is returned when reading a record that was not fully written
+var errTruncated = errors.New("AOF record is truncated")
+
+func encode(e *Entry) []byte {
+	size := len(e.raw)
+	buf := make([]byte, recordHeaderSize+size+recordCRCSize)
+	buf[0] = e.op
+	binary.BigEndian.PutUint32(buf[1:], uint32(size))
+	copy(buf[recordHeaderSize:], e.raw)
+	crc := crc32.Checksum(buf[:recordHeaderSize+size], crcTable)
+	binary.BigEndian.PutUint32(buf[recordHeaderSize+size:], crc)
+	return buf
+}
+
+// decode reads the next record and returns it along with its encoded size.
+// It returns io.EOF when there are no more records.
+func decode(r *bufio.Reader) (*Entry, int64, error) {
+	head := make([]byte, recordHeaderSize)
+	if _, err := io.ReadFull(r, head); err == io.ErrUnexpectedEOF {
+		return nil, 0, errTruncated
+	} else if err != nil {
+		return nil, 0, err
+	}
+	size := int64(binary.BigEndian.Uint32(head[1:]))
+
+	// Don't trust size for allocating before the data was actually read
+	body := &bytes.Buffer{}
+	if _, err := io.CopyN(body, r, size+recordCRCSize); err == io.EOF {
+		return nil, 0, errTruncated
+	} else if err != nil {
+		return nil, 0, err
+	}
+	data := body.Bytes()
+	crc := crc32.Update(crc32.Checksum(head, crcTable), crcTable, data[:size])
+	if crc != binary.BigEndian.Uint32(data[size:]) {
+		return nil, 0, ErrCorrupted
+	}
+	e := &Entry{head[0], nil, data[:size]}
+	return e, recordHeaderSize + size + recordCRCSize, nil
+}
+
+// readLegacy reads the next entry of an AOF written in the text format used
+// before the versioned one: "op|payload/"
+func readLegacy(r *bufio.Reader) (*Entry, error) {
+	line, err := r.ReadBytes('/')
+	if err == io.EOF && len(line) > 0 {
+		return nil, errTruncated
+	} else if err != nil {
+		return nil, err
+	}
+	line = line[:len(line)-1]
+	i := bytes.IndexByte(line, '|')
+	if i < 0 {
+		return nil, ErrCorrupted
+	}
+	op, err := strconv.Atoi(string(line[:i]))
+	if err != nil {
+		return nil, ErrCorrupted
+	}
+	return &Entry{uint8(op), nil, line[i+1:]}, nil
+}
+
+// migrate rewrites an AOF in the legacy text format to the current format,
+// the legacy file is atomically replaced
+func migrate(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = file.Close() }()
+
+	tmpPath := path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmpPath)
+	}()
+
+	rdr := bufio.NewReader(file)
+	wtr := bufio.NewWriter(tmp)
+	if _, err := wtr.Write(aofHeader); err != nil {
+		return err
+	}
+	n := 0
+	for {
+		e, err := readLegacy(rdr)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			// Entries following a broken one can't be recovered in this format
+			logger.Warningf("Legacy AOF %s is broken after %d entries: %s", path, n, err.Error())
+			break
+		}
+		if _, err := wtr.Write(encode(e)); err != nil {
+			return err
+		}
+		n++
+	}
+	if err := wtr.Flush(); err != nil {
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return err
+	}
+	syncDir(path)
+	logger.Infof("Migrated %d entries of legacy AOF %s", n, path)
+	return nil
+}
+
+func syncDir(path string) {
+	if dir, err := os.Open(filepath.Dir(path)); err == nil {
+		_ = dir.Sync()
+		_ = dir.Close()
+	}
+}
@@ -63,6 +63,11 @@ func Float32p(i float32) *float32 {
 	return &i
 }
 
+// Float64p as above
+func Float64p(i float64) *float64 {
+	return &i
+}
+
 // Int64p as above
 func Int64p(i int64) *int64 {
 	return &i
@@ -89,3 +94,12 @@ func FullPath(p string) (string, error) {
 	}
 	return p, nil
 }
+
+// PopCount returns the number of bits set in x
+func PopCount(x uint64) int {
+	n := 0
+	for ; x != 0; n++ {
+		x &= x - 1
+	}
+	return n
+}
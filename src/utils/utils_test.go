@@ -31,3 +31,11 @@ func TestFullPath(t *testing.T) {
 	}
 
 }
+
+func TestPopCount(t *testing.T) {
+	for x, expected := range map[uint64]int{0: 0, 1: 1, 0xff: 8, 0x8000000000000001: 2, ^uint64(0): 64} {
+		if n := PopCount(x); n != expected {
+			t.Errorf("expected %d bits set in %#x, got %d", expected, x, n)
+		}
+	}
+}